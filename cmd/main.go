@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
 
 	_ "embed"
 
@@ -25,19 +26,21 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if err := botApp.Start(context.Background()); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := botApp.Start(ctx); err != nil {
 		log.Fatal(err)
 	}
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
+	go botApp.Run()
+
+	<-ctx.Done()
 
-	go func() {
-		defer close(c)
-		<-c
-		botApp.Stop()
-		cleanup()
-	}()
+	err = botApp.Stop()
+	cleanup()
 
-	botApp.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
 }
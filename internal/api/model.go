@@ -0,0 +1,28 @@
+package api
+
+//easyjson:json
+type Post struct {
+	Text         string   `json:"text"`
+	Destinations []string `json:"destinations,omitempty"`
+}
+
+//easyjson:json
+type PostResult struct {
+	ID string `json:"id"`
+}
+
+type Handler struct {
+	ID     string `json:"id"`
+	Paused bool   `json:"paused"`
+}
+
+//easyjson:json
+type Handlers []Handler
+
+//easyjson:json
+type Checks map[string]string
+
+//easyjson:json
+type Error struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,372 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+const (
+	maxImageSize      = 10 << 20
+	maxBodySize       = maxImageSize + 1<<20
+	readHeaderTimeout = 10 * time.Second
+	sourcePrefix      = "api:"
+)
+
+var (
+	errEmptyPost      = errors.New("text or image is required")
+	errInvalidImage   = errors.New("image must be a jpeg, png, gif or webp file")
+	errUnknownHandler = errors.New("unknown handler")
+)
+
+type Manager interface {
+	Status() []handlers.HandlerStatus
+}
+
+type Check func() error
+
+type Config struct {
+	Address string
+	Token   string
+}
+
+type Server struct {
+	handlers.Workers
+
+	q      pubsub.Queue
+	m      Manager
+	c      Config
+	checks map[string]Check
+	mux    *http.ServeMux
+	srv    *http.Server
+}
+
+type Option func(s *Server)
+
+func WithQueue(q pubsub.Queue) Option {
+	return func(s *Server) {
+		s.q = q
+	}
+}
+
+func WithManager(m Manager) Option {
+	return func(s *Server) {
+		s.m = m
+	}
+}
+
+func WithConfig(c Config) Option {
+	return func(s *Server) {
+		s.c = c
+	}
+}
+
+func WithCheck(name string, c Check) Option {
+	return func(s *Server) {
+		s.checks[name] = c
+	}
+}
+
+// NewServer returns the server of the API, every request needs the token of the config.
+func NewServer(options ...Option) *Server {
+	s := newServer(options...)
+
+	v1 := http.NewServeMux()
+	v1.HandleFunc("POST /v1/posts", s.handlePost)
+	v1.HandleFunc("GET /v1/handlers", s.handleHandlers)
+	v1.HandleFunc("POST /v1/handlers/{id}/stop", s.handleCommand(pubsub.StopCommand))
+	v1.HandleFunc("POST /v1/handlers/{id}/start", s.handleCommand(pubsub.StartCommand))
+
+	s.mux.Handle("/", s.authorize(v1))
+
+	return s
+}
+
+// NewMetricsServer returns the server of the health, readiness and Prometheus endpoints, which don't need a token.
+func NewMetricsServer(options ...Option) *Server {
+	s := newServer(options...)
+
+	s.mux.HandleFunc("GET /healthz", handleHealth)
+	s.mux.HandleFunc("GET /readyz", s.handleReady)
+	s.mux.Handle("GET /metrics", promhttp.Handler())
+
+	return s
+}
+
+func newServer(options ...Option) *Server {
+	s := &Server{mux: http.NewServeMux(), checks: map[string]Check{}}
+
+	for _, o := range options {
+		o(s)
+	}
+
+	return s
+}
+
+func (s *Server) Start() error {
+	l, err := net.Listen("tcp", s.c.Address)
+	if err != nil {
+		return fmt.Errorf("error listening on %s: %w", s.c.Address, err)
+	}
+
+	s.srv = &http.Server{Handler: s, ReadHeaderTimeout: readHeaderTimeout}
+
+	s.Go(func() {
+		if err := s.srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			handlers.SendError(s.q, err)
+		}
+	})
+
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	if s.srv == nil {
+		return nil
+	}
+
+	if err := s.srv.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	s.Wait()
+
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return ok && s.c.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.c.Token)) == 1
+}
+
+func handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, Checks{"status": "ok"})
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	status := http.StatusOK
+	checks := make(Checks, len(s.checks))
+
+	for name, check := range s.checks {
+		if err := check(); err != nil {
+			status = http.StatusServiceUnavailable
+			checks[name] = err.Error()
+
+			continue
+		}
+
+		checks[name] = "ok"
+	}
+
+	writeJSON(w, status, checks)
+}
+
+func (s *Server) handlePost(w http.ResponseWriter, r *http.Request) {
+	p, image, err := readPost(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	if err := s.checkDestinations(p.Destinations); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	id := watermill.NewUUID()
+	topic := pubsub.TextTopic
+
+	var mb []byte
+
+	if image == nil {
+		mb, _ = easyjson.Marshal(pubsub.TextEvent{
+			Text:         p.Text,
+			Destinations: p.Destinations,
+			Source:       sourcePrefix + id,
+		})
+	} else {
+		topic = pubsub.PhotoTopic
+		mb, _ = easyjson.Marshal(pubsub.PhotoEvent{
+			Caption:      p.Text,
+			FileSize:     int64(len(image)),
+			FileContent:  image,
+			Destinations: p.Destinations,
+			Source:       sourcePrefix + id,
+		})
+	}
+
+	if err := s.q.Publish(topic.String(), message.NewMessage(id, mb)); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, PostResult{ID: id})
+}
+
+func (s *Server) handleHandlers(w http.ResponseWriter, _ *http.Request) {
+	status := s.m.Status()
+	hs := make(Handlers, 0, len(status))
+
+	for i := range status {
+		hs = append(hs, Handler{ID: status[i].ID, Paused: status[i].Paused})
+	}
+
+	writeJSON(w, http.StatusOK, hs)
+}
+
+func (s *Server) handleCommand(command pubsub.CommandName) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if !s.exists(id) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("%w %s", errUnknownHandler, id))
+
+			return
+		}
+
+		mb, _ := easyjson.Marshal(pubsub.CommandEvent{Command: command, Handler: id})
+		if err := s.q.Publish(pubsub.CommandTopic.String(), message.NewMessage(watermill.NewUUID(), mb)); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (s *Server) checkDestinations(destinations []string) error {
+	for _, d := range destinations {
+		if !s.exists(d) {
+			return fmt.Errorf("%w %s", errUnknownHandler, d)
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) exists(id string) bool {
+	for _, h := range s.m.Status() {
+		if h.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readPost(r *http.Request) (Post, []byte, error) {
+	var p Post
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := easyjson.UnmarshalFromReader(r.Body, &p); err != nil {
+			return p, nil, err
+		}
+
+		p.Text = strings.TrimSpace(p.Text)
+		if p.Text == "" {
+			return p, nil, errEmptyPost
+		}
+
+		return p, nil, nil
+	}
+
+	if err := r.ParseMultipartForm(maxImageSize); err != nil {
+		return p, nil, err
+	}
+
+	p.Text = strings.TrimSpace(r.FormValue("text"))
+
+	for _, d := range r.MultipartForm.Value["destinations"] {
+		for _, v := range strings.Split(d, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				p.Destinations = append(p.Destinations, v)
+			}
+		}
+	}
+
+	image, err := readImage(r)
+	if err != nil {
+		return p, nil, err
+	}
+
+	if p.Text == "" && image == nil {
+		return p, nil, errEmptyPost
+	}
+
+	return p, image, nil
+}
+
+func readImage(r *http.Request) ([]byte, error) {
+	f, _, err := r.FormFile("image")
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	image, err := io.ReadAll(io.LimitReader(f, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(image) > maxImageSize {
+		return nil, fmt.Errorf("image is bigger than %d bytes", maxImageSize)
+	}
+
+	switch http.DetectContentType(image) {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return image, nil
+	default:
+		return nil, errInvalidImage
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v easyjson.Marshaler) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, _ = easyjson.MarshalToWriter(v, w)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, Error{Error: err.Error()})
+}
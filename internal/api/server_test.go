@@ -0,0 +1,344 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/api"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	ma "github.com/quintodown/quintodownbot/mocks/api"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const token = "s3cr3t"
+
+var pngImage = []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR")
+
+type handlersError struct{}
+
+func (h handlersError) Error() string {
+	return "handlers not running: twitter"
+}
+
+type publishError struct{}
+
+func (p publishError) Error() string {
+	return "error publishing message"
+}
+
+func TestServer_Authorization(t *testing.T) {
+	s, _, _ := getServerAndMocks()
+
+	for name, header := range map[string]string{
+		"it should reject requests without token":    "",
+		"it should reject requests with wrong token": "Bearer wrong",
+		"it should reject tokens of other schemes":   "Basic " + token,
+	} {
+		header := header
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/handlers", nil)
+			if header != "" {
+				r.Header.Set("Authorization", header)
+			}
+
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusUnauthorized, w.Code)
+			require.JSONEq(t, "{\"error\":\"invalid token\"}", w.Body.String())
+		})
+	}
+
+	t.Run("it should not expose metrics", func(t *testing.T) {
+		w := serve(s, http.MethodGet, "/metrics", "", nil)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestServer_Handlers(t *testing.T) {
+	mm := new(ma.Manager)
+	s := api.NewServer(api.WithManager(mm), api.WithConfig(api.Config{Token: token}))
+
+	mm.On("Status").Once().Return([]handlers.HandlerStatus{{ID: "telegram"}, {ID: "twitter", Paused: true}})
+
+	w := serve(s, http.MethodGet, "/v1/handlers", "", nil)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.JSONEq(t, "[{\"id\":\"telegram\",\"paused\":false},{\"id\":\"twitter\",\"paused\":true}]", w.Body.String())
+	mm.AssertExpectations(t)
+}
+
+func TestServer_StopHandler(t *testing.T) {
+	t.Run("it should publish stop command", func(t *testing.T) {
+		s, mockedQueue, mm := getServerAndMocks()
+
+		mockedQueue.On("Publish", pubsub.CommandTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"command\":0,\"handler\":\"twitter\"}"
+		})).Once().
+			Return(nil)
+
+		w := serve(s, http.MethodPost, "/v1/handlers/twitter/stop", "", nil)
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		mockedQueue.AssertExpectations(t)
+		mm.AssertExpectations(t)
+	})
+
+	t.Run("it should publish start command", func(t *testing.T) {
+		s, mockedQueue, _ := getServerAndMocks()
+
+		mockedQueue.On("Publish", pubsub.CommandTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"command\":1,\"handler\":\"telegram\"}"
+		})).Once().
+			Return(nil)
+
+		w := serve(s, http.MethodPost, "/v1/handlers/telegram/start", "", nil)
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should fail with unknown handlers", func(t *testing.T) {
+		s, mockedQueue, _ := getServerAndMocks()
+
+		w := serve(s, http.MethodPost, "/v1/handlers/facebook/stop", "", nil)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.JSONEq(t, "{\"error\":\"unknown handler facebook\"}", w.Body.String())
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+}
+
+func TestServer_Post(t *testing.T) {
+	t.Run("it should publish text posts", func(t *testing.T) {
+		s, mockedQueue, _ := getServerAndMocks()
+
+		var id string
+
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			var e pubsub.TextEvent
+
+			id = m.UUID
+
+			return easyjson.Unmarshal(m.Payload, &e) == nil &&
+				e.Text == "testing message" &&
+				e.Destinations.Includes("twitter") &&
+				!e.Destinations.Includes("telegram") &&
+				e.Source == "api:"+m.UUID
+		})).Once().
+			Return(nil)
+
+		w := serve(
+			s,
+			http.MethodPost,
+			"/v1/posts",
+			"application/json",
+			strings.NewReader("{\"text\":\" testing message \",\"destinations\":[\"twitter\"]}"),
+		)
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.JSONEq(t, "{\"id\":\""+id+"\"}", w.Body.String())
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should publish posts with images as photos", func(t *testing.T) {
+		s, mockedQueue, _ := getServerAndMocks()
+
+		mockedQueue.On("Publish", pubsub.PhotoTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			var e pubsub.PhotoEvent
+
+			return easyjson.Unmarshal(m.Payload, &e) == nil &&
+				e.Caption == "testing caption" &&
+				bytes.Equal(e.FileContent, pngImage) &&
+				e.FileSize == int64(len(pngImage)) &&
+				e.Destinations.Includes("telegram") &&
+				e.Destinations.Includes("twitter")
+		})).Once().
+			Return(nil)
+
+		body, contentType := multipartPost(t, "testing caption", []string{"telegram,twitter"}, pngImage)
+		w := serve(s, http.MethodPost, "/v1/posts", contentType, body)
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should publish multipart posts without image as texts", func(t *testing.T) {
+		s, mockedQueue, _ := getServerAndMocks()
+
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			var e pubsub.TextEvent
+
+			return easyjson.Unmarshal(m.Payload, &e) == nil && e.Text == "testing message" && len(e.Destinations) == 0
+		})).Once().
+			Return(nil)
+
+		body, contentType := multipartPost(t, "testing message", nil, nil)
+		w := serve(s, http.MethodPost, "/v1/posts", contentType, body)
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should reject invalid posts", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			text         string
+			destinations []string
+			image        []byte
+			expected     string
+		}{
+			"empty": {expected: "text or image is required"},
+			"unknown destination": {
+				text:         "testing",
+				destinations: []string{"facebook"},
+				expected:     "unknown handler facebook",
+			},
+			"not an image": {image: []byte("plain text"), expected: "image must be a jpeg, png, gif or webp file"},
+		} {
+			tc := tc
+			t.Run(name, func(t *testing.T) {
+				s, mockedQueue, _ := getServerAndMocks()
+
+				body, contentType := multipartPost(t, tc.text, tc.destinations, tc.image)
+				w := serve(s, http.MethodPost, "/v1/posts", contentType, body)
+
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				require.JSONEq(t, "{\"error\":\""+tc.expected+"\"}", w.Body.String())
+				mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("it should fail when post can't be published", func(t *testing.T) {
+		s, mockedQueue, _ := getServerAndMocks()
+
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.Anything).Once().Return(publishError{})
+
+		w := serve(s, http.MethodPost, "/v1/posts", "application/json", strings.NewReader("{\"text\":\"testing\"}"))
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.JSONEq(t, "{\"error\":\"error publishing message\"}", w.Body.String())
+		mockedQueue.AssertExpectations(t)
+	})
+}
+
+func TestMetricsServer(t *testing.T) {
+	s := api.NewMetricsServer(
+		api.WithCheck("telegram", func() error { return nil }),
+		api.WithCheck("handlers", func() error { return handlersError{} }),
+	)
+
+	t.Run("it should be healthy without token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, "{\"status\":\"ok\"}", w.Body.String())
+	})
+
+	t.Run("it should not be ready when a check fails", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		require.JSONEq(t, "{\"telegram\":\"ok\",\"handlers\":\"handlers not running: twitter\"}", w.Body.String())
+	})
+
+	t.Run("it should be ready when every check passes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		api.NewMetricsServer(api.WithCheck("telegram", func() error { return nil })).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, "{\"telegram\":\"ok\"}", w.Body.String())
+	})
+
+	t.Run("it should expose metrics without token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "go_goroutines")
+	})
+
+	t.Run("it should not serve the api", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/v1/handlers", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestServer_StartStop(t *testing.T) {
+	s := api.NewServer(api.WithConfig(api.Config{Address: "127.0.0.1:0", Token: token}))
+
+	require.NoError(t, s.Start())
+	require.NoError(t, s.Stop(context.Background()))
+}
+
+func getServerAndMocks() (*api.Server, *mq.Queue, *ma.Manager) {
+	mockedQueue := new(mq.Queue)
+	mm := new(ma.Manager)
+
+	mm.On("Status").Maybe().Return([]handlers.HandlerStatus{{ID: "telegram"}, {ID: "twitter"}})
+
+	s := api.NewServer(
+		api.WithQueue(mockedQueue),
+		api.WithManager(mm),
+		api.WithConfig(api.Config{Token: token}),
+	)
+
+	return s, mockedQueue, mm
+}
+
+func serve(s *api.Server, method, target, contentType string, body io.Reader) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, body)
+	r.Header.Set("Authorization", "Bearer "+token)
+
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	return w
+}
+
+func multipartPost(t *testing.T, text string, destinations []string, image []byte) (*bytes.Buffer, string) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	require.NoError(t, mw.WriteField("text", text))
+
+	for _, d := range destinations {
+		require.NoError(t, mw.WriteField("destinations", d))
+	}
+
+	if image != nil {
+		fw, err := mw.CreateFormFile("image", "image.png")
+		require.NoError(t, err)
+
+		_, err = fw.Write(image)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, mw.Close())
+
+	return body, mw.FormDataContentType()
+}
@@ -3,19 +3,28 @@ package app
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/quintodown/quintodownbot/internal/bot"
+	"github.com/quintodown/quintodownbot/internal/config"
 	"github.com/quintodown/quintodownbot/internal/handlers"
 	"github.com/subosito/gotenv"
 )
 
 type botProvider func() (bot.AppBot, error)
 
+type APIServer interface {
+	Start() error
+	Stop(context.Context) error
+}
+
 type App struct {
-	bp botProvider
-	tb bot.AppBot
-	hm *handlers.Manager
+	bp      botProvider
+	tb      bot.AppBot
+	hm      *handlers.Manager
+	servers []APIServer
+	cfg     config.AppConfig
 }
 
 func InitializeConfiguration(testBot bool, envFile []byte, envTestFile []byte) error {
@@ -34,8 +43,8 @@ func InitializeConfiguration(testBot bool, envFile []byte, envTestFile []byte) e
 	return nil
 }
 
-func NewApp(bp botProvider, hm *handlers.Manager) *App {
-	return &App{bp: bp, hm: hm}
+func NewApp(bp botProvider, hm *handlers.Manager, servers []APIServer, cfg config.AppConfig) *App {
+	return &App{bp: bp, hm: hm, servers: servers, cfg: cfg}
 }
 
 func (a *App) Start(ctx context.Context) error {
@@ -48,6 +57,21 @@ func (a *App) Start(ctx context.Context) error {
 		return fmt.Errorf("error starting bot: %w", err)
 	}
 
+	// Servers start before the handlers and the bot is kept, so a failure doesn't stop a bot that never ran, which
+	// blocks until its poller takes the signal.
+	for i := range a.servers {
+		if err := a.servers[i].Start(); err != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+			defer cancel()
+
+			return errors.Join(
+				fmt.Errorf("error starting api: %w", err),
+				stopServers(ctx, a.servers[:i]),
+				a.stopHandlers(ctx),
+			)
+		}
+	}
+
 	a.hm.StartHandlers(ctx)
 	a.tb = tBot
 
@@ -58,6 +82,34 @@ func (a *App) Run() {
 	a.tb.Run()
 }
 
-func (a *App) Stop() {
-	a.tb.Stop()
+func (a *App) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	return errors.Join(stopServers(ctx, a.servers), a.stopHandlers(ctx))
+}
+
+func stopServers(ctx context.Context, servers []APIServer) error {
+	var errs []error
+
+	for _, s := range servers {
+		if err := s.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("error stopping api: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// stopHandlers stops the bot and drains the handlers, closing the queue and the storage.
+func (a *App) stopHandlers(ctx context.Context) error {
+	if a.tb != nil {
+		a.tb.Stop()
+	}
+
+	if err := a.hm.Stop(ctx); err != nil {
+		return fmt.Errorf("error stopping handlers: %w", err)
+	}
+
+	return nil
 }
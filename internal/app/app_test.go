@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill/message"
 	pubsub2 "github.com/quintodown/quintodownbot/internal/pubsub"
@@ -11,18 +12,52 @@ import (
 
 	"github.com/quintodown/quintodownbot/internal/app"
 	"github.com/quintodown/quintodownbot/internal/bot"
+	"github.com/quintodown/quintodownbot/internal/config"
 	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	tb "gopkg.in/telebot.v3"
 
+	mockApp "github.com/quintodown/quintodownbot/mocks/app"
 	mockBot "github.com/quintodown/quintodownbot/mocks/bot"
+	"github.com/quintodown/quintodownbot/mocks/storage"
 )
 
+// telebotApp runs a telebot bot, whose Stop blocks until the loop started by Run takes the signal.
+type telebotApp struct {
+	b *tb.Bot
+}
+
+func (telebotApp) Start(context.Context) error {
+	return nil
+}
+
+func (a telebotApp) Run() {
+	a.b.Start()
+}
+
+func (a telebotApp) Stop() {
+	a.b.Stop()
+}
+
 type startAppError struct{}
 
 func (m startAppError) Error() string {
 	return "could not start"
 }
 
+type closeQueueError struct{}
+
+func (m closeQueueError) Error() string {
+	return "could not close queue"
+}
+
+type listenError struct{}
+
+func (m listenError) Error() string {
+	return "address already in use"
+}
+
 type botInstanceError struct{}
 
 func (m botInstanceError) Error() string {
@@ -69,7 +104,7 @@ func TestStart(t *testing.T) {
 			return nil, botInstanceError{}
 		}
 
-		a := app.NewApp(mbp, handlers.NewHandlersManager(nil))
+		a := app.NewApp(mbp, handlers.NewHandlersManager(nil, nil, nil), nil, config.AppConfig{})
 		e := a.Start(context.Background())
 
 		require.EqualError(t, e, "error getting bot instance: bot instance not ready")
@@ -82,7 +117,7 @@ func TestStart(t *testing.T) {
 		}
 		mb.On("Start", context.Background()).Once().Return(startAppError{})
 
-		a := app.NewApp(mbp, handlers.NewHandlersManager(nil))
+		a := app.NewApp(mbp, handlers.NewHandlersManager(nil, nil, nil), nil, config.AppConfig{})
 		e := a.Start(context.Background())
 
 		require.EqualError(t, e, "error starting bot: could not start")
@@ -96,17 +131,108 @@ func TestStart(t *testing.T) {
 			return mb, nil
 		}
 		mb.On("Start", context.Background()).Once().Return(nil)
-		q.On("Subscribe", context.Background(), pubsub2.CommandTopic.String()).
+		q.On("Subscribe", mock.Anything, pubsub2.CommandTopic.String()).
 			Return(func(context.Context, string) <-chan *message.Message {
 				return make(chan *message.Message)
 			}, nil)
 
-		a := app.NewApp(mbp, handlers.NewHandlersManager(q))
+		a := app.NewApp(mbp, handlers.NewHandlersManager(q, nil, nil), nil, config.AppConfig{})
 		e := a.Start(context.Background())
 
 		require.NoError(t, e)
 		mb.AssertExpectations(t)
 	})
+
+	t.Run("it should close handlers without stopping the bot when starting api server fails", func(t *testing.T) {
+		q := new(pubsub.Queue)
+		s := new(storage.Store)
+		mb := new(mockBot.AppBot)
+		ma := new(mockApp.APIServer)
+		mbp := func() (bot.AppBot, error) {
+			return mb, nil
+		}
+		mb.On("Start", context.Background()).Once().Return(nil)
+		ma.On("Start").Once().Return(listenError{})
+		q.On("Close").Once().Return(nil)
+		s.On("Close").Once().Return(nil)
+
+		a := app.NewApp(
+			mbp,
+			handlers.NewHandlersManager(q, nil, s),
+			[]app.APIServer{ma},
+			config.AppConfig{ShutdownTimeout: time.Second},
+		)
+		e := a.Start(context.Background())
+
+		require.EqualError(t, e, "error starting api: address already in use")
+		mb.AssertExpectations(t)
+		mb.AssertNotCalled(t, "Stop")
+		ma.AssertExpectations(t)
+		q.AssertExpectations(t)
+		s.AssertExpectations(t)
+	})
+
+	t.Run("it should not block on a telegram bot that never ran when starting api server fails", func(t *testing.T) {
+		q := new(pubsub.Queue)
+		s := new(storage.Store)
+		ma := new(mockApp.APIServer)
+		tbBot, err := tb.NewBot(tb.Settings{Poller: &tb.LongPoller{}, Offline: true})
+		require.NoError(t, err)
+
+		mbp := func() (bot.AppBot, error) {
+			return telebotApp{b: tbBot}, nil
+		}
+		ma.On("Start").Once().Return(listenError{})
+		q.On("Close").Once().Return(nil)
+		s.On("Close").Once().Return(nil)
+
+		a := app.NewApp(
+			mbp,
+			handlers.NewHandlersManager(q, nil, s),
+			[]app.APIServer{ma},
+			config.AppConfig{ShutdownTimeout: time.Second},
+		)
+		started := make(chan error)
+
+		go func() { started <- a.Start(context.Background()) }()
+
+		select {
+		case e := <-started:
+			require.EqualError(t, e, "error starting api: address already in use")
+		case <-time.After(time.Second):
+			require.Fail(t, "app start blocked stopping the telegram bot")
+		}
+	})
+
+	t.Run("it should stop started servers when another one fails to start", func(t *testing.T) {
+		q := new(pubsub.Queue)
+		s := new(storage.Store)
+		mb := new(mockBot.AppBot)
+		api := new(mockApp.APIServer)
+		metrics := new(mockApp.APIServer)
+		mbp := func() (bot.AppBot, error) {
+			return mb, nil
+		}
+		mb.On("Start", context.Background()).Once().Return(nil)
+		api.On("Start").Once().Return(nil)
+		api.On("Stop", mock.Anything).Once().Return(nil)
+		metrics.On("Start").Once().Return(listenError{})
+		q.On("Close").Once().Return(nil)
+		s.On("Close").Once().Return(nil)
+
+		a := app.NewApp(
+			mbp,
+			handlers.NewHandlersManager(q, nil, s),
+			[]app.APIServer{api, metrics},
+			config.AppConfig{ShutdownTimeout: time.Second},
+		)
+		e := a.Start(context.Background())
+
+		require.EqualError(t, e, "error starting api: address already in use")
+		mb.AssertExpectations(t)
+		api.AssertExpectations(t)
+		metrics.AssertExpectations(t)
+	})
 }
 
 func TestRun(t *testing.T) {
@@ -118,12 +244,12 @@ func TestRun(t *testing.T) {
 
 	mb.On("Start", context.Background()).Once().Return(nil)
 	mb.On("Run").Once()
-	q.On("Subscribe", context.Background(), pubsub2.CommandTopic.String()).
+	q.On("Subscribe", mock.Anything, pubsub2.CommandTopic.String()).
 		Return(func(context.Context, string) <-chan *message.Message {
 			return make(chan *message.Message)
 		}, nil)
 
-	a := app.NewApp(mbp, handlers.NewHandlersManager(q))
+	a := app.NewApp(mbp, handlers.NewHandlersManager(q, nil, nil), nil, config.AppConfig{})
 	_ = a.Start(context.Background())
 	a.Run()
 
@@ -131,23 +257,109 @@ func TestRun(t *testing.T) {
 }
 
 func TestStop(t *testing.T) {
-	q := new(pubsub.Queue)
-	mb := new(mockBot.AppBot)
-	mbp := func() (bot.AppBot, error) {
-		return mb, nil
+	mbp := func(mb *mockBot.AppBot) func() (bot.AppBot, error) {
+		return func() (bot.AppBot, error) {
+			return mb, nil
+		}
 	}
 
-	mb.On("Start", context.Background()).Once().Return(nil)
-	mb.On("Stop").Once()
-	q.On("Subscribe", context.Background(), pubsub2.CommandTopic.String()).
-		Return(func(context.Context, string) <-chan *message.Message {
-			return make(chan *message.Message)
-		}, nil)
+	t.Run("it should stop bot and drain handlers", func(t *testing.T) {
+		q := new(pubsub.Queue)
+		s := new(storage.Store)
+		mb := new(mockBot.AppBot)
 
-	a := app.NewApp(mbp, handlers.NewHandlersManager(q))
-	e := a.Start(context.Background())
-	a.Stop()
+		mb.On("Start", context.Background()).Once().Return(nil)
+		mb.On("Stop").Once()
+		q.On("Subscribe", mock.Anything, pubsub2.CommandTopic.String()).
+			Return(func(ctx context.Context, _ string) <-chan *message.Message {
+				return closingChannel(ctx)
+			}, nil)
+		q.On("Close").Once().Return(nil)
+		s.On("Close").Once().Return(nil)
+
+		a := app.NewApp(
+			mbp(mb),
+			handlers.NewHandlersManager(q, nil, s),
+			nil,
+			config.AppConfig{ShutdownTimeout: time.Second},
+		)
+		require.NoError(t, a.Start(context.Background()))
+
+		require.NoError(t, a.Stop())
+		mb.AssertExpectations(t)
+		q.AssertExpectations(t)
+		s.AssertExpectations(t)
+	})
 
-	require.NoError(t, e)
-	mb.AssertExpectations(t)
+	t.Run("it should stop api server", func(t *testing.T) {
+		q := new(pubsub.Queue)
+		s := new(storage.Store)
+		mb := new(mockBot.AppBot)
+		ma := new(mockApp.APIServer)
+
+		mb.On("Start", context.Background()).Once().Return(nil)
+		mb.On("Stop").Once()
+		ma.On("Start").Once().Return(nil)
+		ma.On("Stop", mock.Anything).Once().Return(nil)
+		q.On("Subscribe", mock.Anything, pubsub2.CommandTopic.String()).
+			Return(func(ctx context.Context, _ string) <-chan *message.Message {
+				return closingChannel(ctx)
+			}, nil)
+		q.On("Close").Once().Return(nil)
+		s.On("Close").Once().Return(nil)
+
+		a := app.NewApp(
+			mbp(mb),
+			handlers.NewHandlersManager(q, nil, s),
+			[]app.APIServer{ma},
+			config.AppConfig{ShutdownTimeout: time.Second},
+		)
+		require.NoError(t, a.Start(context.Background()))
+
+		require.NoError(t, a.Stop())
+		mb.AssertExpectations(t)
+		ma.AssertExpectations(t)
+	})
+
+	t.Run("it should return all errors found while stopping", func(t *testing.T) {
+		q := new(pubsub.Queue)
+		s := new(storage.Store)
+		mb := new(mockBot.AppBot)
+
+		mb.On("Start", context.Background()).Once().Return(nil)
+		mb.On("Stop").Once()
+		q.On("Subscribe", mock.Anything, pubsub2.CommandTopic.String()).
+			Return(func(context.Context, string) <-chan *message.Message {
+				return make(chan *message.Message)
+			}, nil)
+		q.On("Close").Once().Return(closeQueueError{})
+		s.On("Close").Once().Return(nil)
+
+		a := app.NewApp(
+			mbp(mb),
+			handlers.NewHandlersManager(q, nil, s),
+			nil,
+			config.AppConfig{ShutdownTimeout: time.Millisecond},
+		)
+		require.NoError(t, a.Start(context.Background()))
+
+		require.EqualError(
+			t,
+			a.Stop(),
+			"error stopping handlers: error draining commands: context deadline exceeded\n"+
+				"error closing queue: could not close queue",
+		)
+		mb.AssertExpectations(t)
+	})
+}
+
+func closingChannel(ctx context.Context) <-chan *message.Message {
+	c := make(chan *message.Message)
+
+	go func() {
+		<-ctx.Done()
+		close(c)
+	}()
+
+	return c
 }
@@ -4,13 +4,20 @@
 package app
 
 import (
+	"context"
 	"crypto/tls"
+	"fmt"
 	"net/http"
 	"os"
+	"text/template"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
+	"github.com/quintodown/quintodownbot/internal/bluesky"
 	"github.com/quintodown/quintodownbot/internal/clock"
+	"github.com/quintodown/quintodownbot/internal/deadletter"
+	"github.com/quintodown/quintodownbot/internal/discord"
+	"github.com/quintodown/quintodownbot/internal/feeds"
 	"github.com/quintodown/quintodownbot/internal/games"
 	"github.com/quintodown/quintodownbot/internal/games/clients/espn"
 	proxyclient "github.com/quintodown/quintodownbot/internal/games/clients/proxy"
@@ -21,17 +28,31 @@ import (
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	hsbs "github.com/quintodown/quintodownbot/internal/handlers/bluesky"
+	hsdl "github.com/quintodown/quintodownbot/internal/handlers/deadletter"
+	hsdc "github.com/quintodown/quintodownbot/internal/handlers/discord"
 	hse "github.com/quintodown/quintodownbot/internal/handlers/error"
+	hsfd "github.com/quintodown/quintodownbot/internal/handlers/feed"
+	hsm "github.com/quintodown/quintodownbot/internal/handlers/mastodon"
+	hssc "github.com/quintodown/quintodownbot/internal/handlers/scheduler"
 	hstl "github.com/quintodown/quintodownbot/internal/handlers/telegram"
 	hstw "github.com/quintodown/quintodownbot/internal/handlers/twitter"
+	hswh "github.com/quintodown/quintodownbot/internal/handlers/webhook"
+	"github.com/quintodown/quintodownbot/internal/mastodon"
+	"github.com/quintodown/quintodownbot/internal/metrics"
+	"github.com/quintodown/quintodownbot/internal/posts"
 	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/scheduler"
+	"github.com/quintodown/quintodownbot/internal/storage"
 	"github.com/sirupsen/logrus"
 
 	"github.com/dghubble/oauth1"
 	"github.com/google/wire"
+	"github.com/quintodown/quintodownbot/internal/api"
 	"github.com/quintodown/quintodownbot/internal/bot"
 	"github.com/quintodown/quintodownbot/internal/config"
 	"github.com/quintodown/quintodownbot/internal/twitter"
+	"github.com/quintodown/quintodownbot/internal/webhook"
 
 	gt "github.com/javiyt/go-twitter/twitter"
 	tb "gopkg.in/telebot.v3"
@@ -40,24 +61,50 @@ import (
 const (
 	updateGamesInformationTicker = time.Minute
 	updateGamesListTicker        = 6 * time.Hour
+	publishDueTicker             = 10 * time.Second
+)
+
+const (
+	telegramPollerTimeout = 10 * time.Second
+	telegramClientTimeout = time.Minute
+	telegramHeartbeatAge  = time.Minute
+	discordClientTimeout  = 30 * time.Second
+	webhookClientTimeout  = 30 * time.Second
+	feedsClientTimeout    = 30 * time.Second
+)
+
+var (
+	telegramTransport = metrics.NewTransport("telegram", http.DefaultTransport, metrics.WithHeartbeat("getUpdates"))
+	twitterTransport  = metrics.NewTransport("twitter", http.DefaultTransport)
+	espnTransport     = metrics.NewTransport("espn", &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	})
 )
 
 type customHandlerGenerator func() []handlers.EventHandler
 
 var (
-	queueInstance *gochannel.GoChannel
+	queueInstance pubsub.Queue
+	storeInstance storage.Store
+	postsInstance *posts.Repository
 	twitterClient = wire.NewSet(
 		provideTwitterHttpClient,
 		provideTwitterClient,
-		wire.Bind(new(bot.TwitterClient), new(*twitter.Client)),
 	)
-	queue        = wire.NewSet(provideGoChannelQueue, wire.Bind(new(pubsub.Queue), new(*gochannel.GoChannel)))
-	telegramDeps = wire.NewSet(provideConfiguration, provideTBot, queue)
-	twitterDeps  = wire.NewSet(provideConfiguration, twitterClient, queue)
+	queue        = wire.NewSet(provideQueue)
+	telegramDeps = wire.NewSet(provideConfiguration, provideTBot, queue, providePosts)
+	twitterDeps  = wire.NewSet(provideConfiguration, twitterClient, queue, providePosts)
+	mastodonDeps = wire.NewSet(provideConfiguration, queue, providePosts)
+	blueskyDeps  = wire.NewSet(provideConfiguration, queue, providePosts)
+	discordDeps  = wire.NewSet(provideConfiguration, queue, providePosts)
 	errorDeps    = wire.NewSet(provideConfiguration, queue, provideLogger)
 	tbBot        = wire.NewSet(provideConfiguration, provideTBotSettings, tb.NewBot, wire.Bind(new(telegram.TbBot), new(*tb.Bot)))
+	utcClock     = wire.NewSet(clock.NewUTCClock, wire.Bind(new(clock.Clock), new(clock.UTCClock)))
+	deadLetters  = wire.NewSet(provideStore, utcClock, deadletter.NewRepository)
+	schedule     = wire.NewSet(scheduler.NewScheduler)
+	webhooks     = wire.NewSet(provideConfiguredWebhooks, webhook.NewRepository)
 	gamesDeps    = wire.NewSet(
-		wire.NewSet(clock.NewUTCClock, wire.Bind(new(clock.Clock), new(clock.UTCClock))),
+		utcClock,
 		queue,
 		provideGameInfoClient,
 		provideGameHandler,
@@ -69,7 +116,9 @@ func ProvideApp() (*App, func(), error) {
 		provideBotProvider,
 		initializeCustomHandlers,
 		provideHandlers,
-		wire.NewSet(queue, provideHandlerManager),
+		wire.NewSet(queue, provideTBot, provideStore, provideHandlerManager),
+		provideConfiguration,
+		provideServers,
 		NewApp,
 	))
 }
@@ -84,6 +133,14 @@ func provideBot() (bot.AppBot, error) {
 		provideTBot,
 		twitterClient,
 		queue,
+		deadLetters,
+		wire.Bind(new(bot.DeadLetters), new(*deadletter.Repository)),
+		schedule,
+		wire.Bind(new(bot.Scheduler), new(*scheduler.Scheduler)),
+		providePosts,
+		wire.Bind(new(bot.Posts), new(*posts.Repository)),
+		webhooks,
+		wire.Bind(new(bot.Webhooks), new(*webhook.Repository)),
 		provideBotOptions,
 		bot.NewBot,
 	))
@@ -100,37 +157,126 @@ func provideTBot() (bot.TelegramBot, error) {
 func provideTBotSettings(cfg config.AppConfig) tb.Settings {
 	return tb.Settings{
 		Token:  cfg.BotToken,
-		Poller: &tb.LongPoller{Timeout: 10 * time.Second},
+		Poller: &tb.LongPoller{Timeout: telegramPollerTimeout},
+		Client: &http.Client{Timeout: telegramClientTimeout, Transport: telegramTransport},
 	}
 }
 
-func provideTwitterClient(*http.Client) *twitter.Client {
-	wire.Build(gt.NewClient, twitter.NewTwitterClient)
+func provideTwitterClient(cfg config.AppConfig, hc *http.Client) bot.TwitterClient {
+	if cfg.IsTwitterAPIV2() {
+		return twitter.NewTwitterV2Client(hc, twitter.WithThreadNumbering(cfg.TwitterThreadNumbering))
+	}
 
-	return &twitter.Client{}
+	return twitter.NewTwitterClient(gt.NewClient(hc), twitter.WithThreadNumbering(cfg.TwitterThreadNumbering))
 }
 
 func provideTwitterHttpClient(cfg config.AppConfig) *http.Client {
+	return twitterHttpClient(cfg, cfg.TwitterCredentials()[config.DefaultDestination])
+}
+
+func twitterHttpClient(cfg config.AppConfig, c config.TwitterCredentials) *http.Client {
+	ctx := context.WithValue(oauth1.NoContext, oauth1.HTTPClient, &http.Client{Transport: twitterTransport})
+
 	return oauth1.NewConfig(cfg.TwitterAPIKey, cfg.TwitterAPISecret).
-		Client(oauth1.NoContext, oauth1.NewToken(cfg.TwitterAccessToken, cfg.TwitterAccessSecret))
+		Client(ctx, oauth1.NewToken(c.AccessToken, c.AccessSecret))
 }
 
-func provideGoChannelQueue() *gochannel.GoChannel {
-	if queueInstance == nil {
-		queueInstance = gochannel.NewGoChannel(
+func provideQueue() (pubsub.Queue, error) {
+	if queueInstance != nil {
+		return queueInstance, nil
+	}
+
+	cfg, err := provideConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.IsDurableQueue() {
+		queueInstance = metrics.NewQueue(gochannel.NewGoChannel(
 			gochannel.Config{},
 			watermill.NewStdLogger(true, true),
-		)
+		))
+
+		return queueInstance, nil
 	}
-	return queueInstance
+
+	boltQueue, err := pubsub.NewBoltQueue(cfg.QueueFile)
+	if err != nil {
+		return nil, err
+	}
+
+	queueInstance = metrics.NewQueue(boltQueue)
+
+	return queueInstance, nil
+}
+
+func provideStore() (storage.Store, error) {
+	if storeInstance != nil {
+		return storeInstance, nil
+	}
+
+	cfg, err := provideConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	boltStore, err := storage.NewBoltStore(cfg.StorageFile)
+	if err != nil {
+		return nil, err
+	}
+
+	storeInstance = boltStore
+
+	return storeInstance, nil
 }
 
-func provideBotOptions(b bot.TelegramBot, cfg config.AppConfig, tc bot.TwitterClient, gq pubsub.Queue) []bot.Option {
+func providePosts() (*posts.Repository, error) {
+	if postsInstance != nil {
+		return postsInstance, nil
+	}
+
+	cfg, err := provideConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := provideStore()
+	if err != nil {
+		return nil, err
+	}
+
+	postsInstance = posts.NewRepository(s, clock.NewUTCClock(), posts.WithRetention(cfg.HistoryRetention))
+
+	return postsInstance, nil
+}
+
+func provideBotOptions(
+	b bot.TelegramBot,
+	cfg config.AppConfig,
+	tc bot.TwitterClient,
+	gq pubsub.Queue,
+	dl bot.DeadLetters,
+	sc bot.Scheduler,
+	ps bot.Posts,
+	wh bot.Webhooks,
+) []bot.Option {
 	return []bot.Option{
 		bot.WithTelegramBot(b),
 		bot.WithConfig(cfg),
 		bot.WithTwitterClient(tc),
 		bot.WithQueue(gq),
+		bot.WithDeadLetters(dl),
+		bot.WithScheduler(sc),
+		bot.WithPosts(ps),
+		bot.WithWebhooks(wh),
+	}
+}
+
+func provideRetryPolicy(cfg config.AppConfig) handlers.RetryPolicy {
+	return handlers.RetryPolicy{
+		MaxAttempts:    cfg.RetryMaxAttempts,
+		InitialBackoff: cfg.RetryInitialBackoff,
+		MaxBackoff:     cfg.RetryMaxBackoff,
 	}
 }
 
@@ -168,11 +314,18 @@ func provideLogger(cfg config.AppConfig) (*logrus.Logger, func()) {
 	}
 }
 
-func provideTelegramOptions(cfg config.AppConfig, tb bot.TelegramBot, pq pubsub.Queue) []hstl.Option {
+func provideTelegramOptions(
+	cfg config.AppConfig,
+	tb bot.TelegramBot,
+	pq pubsub.Queue,
+	ps *posts.Repository,
+) []hstl.Option {
 	return []hstl.Option{
 		hstl.WithAppConfig(cfg),
 		hstl.WithTelegramBot(tb),
 		hstl.WithQueue(pq),
+		hstl.WithPosts(ps),
+		hstl.WithRetryPolicy(provideRetryPolicy(cfg)),
 	}
 }
 
@@ -180,21 +333,176 @@ func provideTelegramHandler() (*hstl.Telegram, error) {
 	panic(wire.Build(telegramDeps, provideTelegramOptions, hstl.NewTelegram))
 }
 
-func provideTwitterOptions(tc bot.TwitterClient, pq pubsub.Queue) []hstw.Option {
-	return []hstw.Option{
+func provideTwitterOptions(
+	cfg config.AppConfig,
+	tc bot.TwitterClient,
+	pq pubsub.Queue,
+	ps *posts.Repository,
+) []hstw.Option {
+	options := []hstw.Option{
 		hstw.WithTwitterClient(tc),
+		hstw.WithAppConfig(cfg),
 		hstw.WithQueue(pq),
+		hstw.WithPosts(ps),
+		hstw.WithRetryPolicy(provideRetryPolicy(cfg)),
 	}
+
+	for name, c := range cfg.TwitterCredentials() {
+		if name != config.DefaultDestination {
+			options = append(options, hstw.WithAccount(name, provideTwitterClient(cfg, twitterHttpClient(cfg, c))))
+		}
+	}
+
+	return options
 }
 
 func provideTwitterHandler() (*hstw.Twitter, error) {
 	panic(wire.Build(twitterDeps, provideTwitterOptions, hstw.NewTwitter))
 }
 
+func provideMastodonOptions(cfg config.AppConfig, pq pubsub.Queue, ps *posts.Repository) []hsm.Option {
+	return []hsm.Option{
+		hsm.WithMastodonClient(
+			mastodon.NewMastodonClient(http.DefaultClient, cfg.MastodonInstanceURL, cfg.MastodonAccessToken),
+		),
+		hsm.WithQueue(pq),
+		hsm.WithPosts(ps),
+		hsm.WithRetryPolicy(provideRetryPolicy(cfg)),
+	}
+}
+
+func provideMastodonHandler() (*hsm.Mastodon, error) {
+	panic(wire.Build(mastodonDeps, provideMastodonOptions, hsm.NewMastodon))
+}
+
+func provideBlueskyOptions(cfg config.AppConfig, pq pubsub.Queue, ps *posts.Repository) []hsbs.Option {
+	return []hsbs.Option{
+		hsbs.WithBlueskyClient(bluesky.NewBlueskyClient(
+			http.DefaultClient,
+			clock.NewUTCClock(),
+			cfg.BlueskyHost,
+			cfg.BlueskyIdentifier,
+			cfg.BlueskyPassword,
+		)),
+		hsbs.WithQueue(pq),
+		hsbs.WithPosts(ps),
+		hsbs.WithRetryPolicy(provideRetryPolicy(cfg)),
+	}
+}
+
+func provideBlueskyHandler() (*hsbs.Bluesky, error) {
+	panic(wire.Build(blueskyDeps, provideBlueskyOptions, hsbs.NewBluesky))
+}
+
+func provideDiscordOptions(cfg config.AppConfig, pq pubsub.Queue, ps *posts.Repository) []hsdc.Option {
+	return []hsdc.Option{
+		hsdc.WithDiscordClient(discord.NewDiscordClient(&http.Client{Timeout: discordClientTimeout})),
+		hsdc.WithWebhooks(cfg.DiscordWebhooks),
+		hsdc.WithQueue(pq),
+		hsdc.WithPosts(ps),
+		hsdc.WithRetryPolicy(provideRetryPolicy(cfg)),
+	}
+}
+
+func provideDiscordHandler() (*hsdc.Discord, error) {
+	panic(wire.Build(discordDeps, provideDiscordOptions, hsdc.NewDiscord))
+}
+
+func provideConfiguredWebhooks(cfg config.AppConfig) ([]webhook.Subscription, error) {
+	return webhook.ParseConfigured(cfg.Webhooks, cfg.WebhookSecrets, cfg.WebhookSecret)
+}
+
+func provideWebhookOptions(cfg config.AppConfig, pq pubsub.Queue, r *webhook.Repository) []hswh.Option {
+	return []hswh.Option{
+		hswh.WithWebhookClient(webhook.NewWebhookClient(&http.Client{Timeout: webhookClientTimeout})),
+		hswh.WithSubscriptions(r),
+		hswh.WithQueue(pq),
+		hswh.WithRetryPolicy(provideRetryPolicy(cfg)),
+	}
+}
+
+func provideWebhookHandler() (*hswh.Webhook, error) {
+	panic(wire.Build(provideConfiguration, queue, provideStore, webhooks, provideWebhookOptions, hswh.NewWebhook))
+}
+
+func provideFeedHandlers(cfg config.AppConfig) ([]handlers.EventHandler, error) {
+	q, err := provideQueue()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := provideStore()
+	if err != nil {
+		return nil, err
+	}
+
+	fc := feeds.NewFeedsClient(&http.Client{Timeout: feedsClientTimeout})
+	r := feeds.NewRepository(s)
+
+	var feedHandlers []handlers.EventHandler
+
+	for _, source := range cfg.FeedSources() {
+		tpl, err := template.New(source.Name).Parse(source.Template)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing template of feed %s: %w", source.Name, err)
+		}
+
+		feedHandlers = append(feedHandlers, hsfd.NewFeed(
+			hsfd.WithFeedsClient(fc),
+			hsfd.WithRepository(r),
+			hsfd.WithConfig(hsfd.Config{
+				Name:         source.Name,
+				URL:          source.URL,
+				Template:     tpl,
+				PollInterval: cfg.FeedPollInterval,
+			}),
+			hsfd.WithQueue(q),
+		))
+	}
+
+	return feedHandlers, nil
+}
+
 func provideErrorHandler() (*hse.ErrorHandler, func(), error) {
 	panic(wire.Build(errorDeps, hse.NewErrorHandler))
 }
 
+func provideDeadLetterOptions(r hsdl.Repository, q pubsub.Queue) []hsdl.Option {
+	return []hsdl.Option{
+		hsdl.WithRepository(r),
+		hsdl.WithQueue(q),
+	}
+}
+
+func provideDeadLetterHandler() (*hsdl.DeadLetter, error) {
+	panic(wire.Build(
+		queue,
+		deadLetters,
+		wire.Bind(new(hsdl.Repository), new(*deadletter.Repository)),
+		provideDeadLetterOptions,
+		hsdl.NewDeadLetter,
+	))
+}
+
+func provideSchedulerOptions(p hssc.Publisher, q pubsub.Queue) []hssc.Option {
+	return []hssc.Option{
+		hssc.WithPublisher(p),
+		hssc.WithQueue(q),
+		hssc.WithConfig(hssc.Config{PublishDueTicker: publishDueTicker}),
+	}
+}
+
+func provideSchedulerHandler() (*hssc.Scheduler, error) {
+	panic(wire.Build(
+		queue,
+		deadLetters,
+		schedule,
+		wire.Bind(new(hssc.Publisher), new(*scheduler.Scheduler)),
+		provideSchedulerOptions,
+		hssc.NewScheduler,
+	))
+}
+
 func initializeCustomHandlers() customHandlerGenerator {
 	return func() []handlers.EventHandler {
 		gamesHandler, _ := provideGames()
@@ -204,6 +512,10 @@ func initializeCustomHandlers() customHandlerGenerator {
 }
 
 func provideHandlers(customHandlers customHandlerGenerator) ([]handlers.EventHandler, func(), error) {
+	cfg, err := provideConfiguration()
+	if err != nil {
+		return nil, nil, err
+	}
 	telegramHandler, err := provideTelegramHandler()
 	if err != nil {
 		return nil, nil, err
@@ -212,20 +524,101 @@ func provideHandlers(customHandlers customHandlerGenerator) ([]handlers.EventHan
 	if err != nil {
 		return nil, nil, err
 	}
+	schedulerHandler, err := provideSchedulerHandler()
+	if err != nil {
+		return nil, nil, err
+	}
+	deadLetterHandler, err := provideDeadLetterHandler()
+	if err != nil {
+		return nil, nil, err
+	}
+	webhookHandler, err := provideWebhookHandler()
+	if err != nil {
+		return nil, nil, err
+	}
 	errorHandler, cleanup, err := provideErrorHandler()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return append(customHandlers(),
+	eventHandlers := append(customHandlers(),
 		telegramHandler,
 		twitterHandler,
+		schedulerHandler,
+		deadLetterHandler,
+		webhookHandler,
 		errorHandler,
-	), cleanup, nil
+	)
+
+	if cfg.IsMastodonEnabled() {
+		mastodonHandler, err := provideMastodonHandler()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		eventHandlers = append(eventHandlers, mastodonHandler)
+	}
+
+	if cfg.IsBlueskyEnabled() {
+		blueskyHandler, err := provideBlueskyHandler()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		eventHandlers = append(eventHandlers, blueskyHandler)
+	}
+
+	if cfg.IsDiscordEnabled() {
+		discordHandler, err := provideDiscordHandler()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		eventHandlers = append(eventHandlers, discordHandler)
+	}
+
+	feedHandlers, err := provideFeedHandlers(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eventHandlers = append(eventHandlers, feedHandlers...)
+
+	return eventHandlers, cleanup, nil
 }
 
-func provideHandlerManager(q pubsub.Queue, h []handlers.EventHandler) *handlers.Manager {
-	return handlers.NewHandlersManager(q, h...)
+func provideHandlerManager(
+	q pubsub.Queue,
+	b bot.TelegramBot,
+	s storage.Store,
+	h []handlers.EventHandler,
+) *handlers.Manager {
+	return handlers.NewHandlersManager(q, b, s, h...)
+}
+
+func provideServers(cfg config.AppConfig, q pubsub.Queue, hm *handlers.Manager) []APIServer {
+	var servers []APIServer
+
+	if cfg.IsAPIEnabled() {
+		servers = append(servers, api.NewServer(
+			api.WithQueue(q),
+			api.WithManager(hm),
+			api.WithConfig(api.Config{Address: cfg.APIAddress, Token: cfg.APIToken}),
+		))
+	}
+
+	if cfg.IsMetricsEnabled() {
+		servers = append(servers, api.NewMetricsServer(
+			api.WithQueue(q),
+			api.WithConfig(api.Config{Address: cfg.MetricsAddress}),
+			api.WithCheck("telegram", func() error { return telegramTransport.Alive(telegramHeartbeatAge) }),
+			api.WithCheck("twitter", twitterTransport.Healthy),
+			api.WithCheck("espn", espnTransport.Healthy),
+			api.WithCheck("handlers", hm.Check),
+		))
+	}
+
+	return servers
 }
 
 func provideGameOptions(gh games.Handler, q pubsub.Queue) []handlersgames.Option {
@@ -255,9 +648,7 @@ func provideHTTClient() *http.Client {
 	retryClient := retryablehttp.NewClient()
 	retryClient.RetryMax = 5
 	httpClient := retryClient.HTTPClient
-	httpClient.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
+	httpClient.Transport = espnTransport
 
 	return httpClient
 }
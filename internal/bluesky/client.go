@@ -0,0 +1,569 @@
+package bluesky
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // GIF decoder for shrink
+	"image/jpeg"
+	_ "image/png" // PNG decoder for shrink
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/javiyt/twitter-text-go/extract"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/clock"
+	"github.com/quintodown/quintodownbot/internal/textsplit"
+)
+
+const (
+	postMaxLength        = 300
+	postMaxImages        = 4
+	blobMaxSize          = 1000000
+	blobQuality          = 85
+	postCollection       = "app.bsky.feed.post"
+	imagesEmbed          = "app.bsky.embed.images"
+	linkFeature          = "app.bsky.richtext.facet#link"
+	tagFeature           = "app.bsky.richtext.facet#tag"
+	createSessionMethod  = "com.atproto.server.createSession"
+	refreshSessionMethod = "com.atproto.server.refreshSession"
+	uploadBlobMethod     = "com.atproto.repo.uploadBlob"
+	createRecordMethod   = "com.atproto.repo.createRecord"
+	deleteRecordMethod   = "com.atproto.repo.deleteRecord"
+	getRecordMethod      = "com.atproto.repo.getRecord"
+	expiredTokenError    = "ExpiredToken"
+	applicationJSONValue = "application/json"
+)
+
+var (
+	errUnexpectedStatus = errors.New("unexpected response status")
+	errInvalidPostURI   = errors.New("invalid post uri")
+	errExpiredToken     = errors.New("expired token")
+	errNoSession        = errors.New("no session to refresh")
+)
+
+//easyjson:json
+type sessionRequest struct {
+	Identifier string `json:"identifier"`
+	Password   string `json:"password"`
+}
+
+//easyjson:json
+type sessionResponse struct {
+	AccessJwt  string `json:"accessJwt"`
+	RefreshJwt string `json:"refreshJwt"`
+	Did        string `json:"did"`
+}
+
+//easyjson:json
+type blobResponse struct {
+	Blob easyjson.RawMessage `json:"blob"`
+}
+
+//easyjson:json
+type createRecordRequest struct {
+	Repo       string     `json:"repo"`
+	Collection string     `json:"collection"`
+	Record     postRecord `json:"record"`
+}
+
+type postRecord struct {
+	Type      string    `json:"$type"`
+	Text      string    `json:"text"`
+	CreatedAt string    `json:"createdAt"`
+	Facets    []facet   `json:"facets,omitempty"`
+	Reply     *replyRef `json:"reply,omitempty"`
+	Embed     *embed    `json:"embed,omitempty"`
+}
+
+type facet struct {
+	Index    facetIndex     `json:"index"`
+	Features []facetFeature `json:"features"`
+}
+
+type facetIndex struct {
+	ByteStart int `json:"byteStart"`
+	ByteEnd   int `json:"byteEnd"`
+}
+
+type facetFeature struct {
+	Type string `json:"$type"`
+	URI  string `json:"uri,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+type replyRef struct {
+	Root   strongRef `json:"root"`
+	Parent strongRef `json:"parent"`
+}
+
+type strongRef struct {
+	URI string `json:"uri"`
+	CID string `json:"cid"`
+}
+
+type embed struct {
+	Type   string       `json:"$type"`
+	Images []embedImage `json:"images,omitempty"`
+}
+
+type embedImage struct {
+	Image easyjson.RawMessage `json:"image"`
+	Alt   string              `json:"alt"`
+}
+
+//easyjson:json
+type recordResponse struct {
+	URI string `json:"uri"`
+	CID string `json:"cid"`
+}
+
+//easyjson:json
+type deleteRecordRequest struct {
+	Repo       string `json:"repo"`
+	Collection string `json:"collection"`
+	Rkey       string `json:"rkey"`
+}
+
+//easyjson:json
+type xrpcError struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+type blobTooLargeError struct {
+	size int
+}
+
+func (e blobTooLargeError) Error() string {
+	return fmt.Sprintf("image of %d bytes exceeds the %d bytes limit of Bluesky", e.size, blobMaxSize)
+}
+
+// Permanent tells retrying the upload is pointless, the image won't get any smaller.
+func (blobTooLargeError) Permanent() bool {
+	return true
+}
+
+type Client struct {
+	hc         *http.Client
+	clk        clock.Clock
+	host       string
+	identifier string
+	password   string
+	numbering  bool
+	splitter   *textsplit.Splitter
+	mu         sync.Mutex
+	session    sessionResponse
+}
+
+type Option func(c *Client)
+
+func WithThreadNumbering(numbering bool) Option {
+	return func(c *Client) {
+		c.numbering = numbering
+	}
+}
+
+func NewBlueskyClient(
+	hc *http.Client,
+	clk clock.Clock,
+	host, identifier, password string,
+	options ...Option,
+) *Client {
+	c := &Client{
+		hc:         hc,
+		clk:        clk,
+		host:       strings.TrimSuffix(host, "/"),
+		identifier: identifier,
+		password:   password,
+	}
+
+	for _, o := range options {
+		o(c)
+	}
+
+	c.splitter = textsplit.NewSplitter(
+		textsplit.WithMaxLength(postMaxLength),
+		textsplit.WithNumbering(c.numbering),
+	)
+
+	return c
+}
+
+func (c *Client) SendUpdate(s string) ([]string, error) {
+	return c.publishPost(s, nil)
+}
+
+// ContinueThread publishes the chunks of s missing from a thread that failed partway, replying to its last post.
+func (c *Client) ContinueThread(s string, thread []string) ([]string, error) {
+	if len(thread) == 0 {
+		return c.publishPost(s, nil)
+	}
+
+	root, err := c.getRecord(thread[0])
+	if err != nil {
+		return thread, err
+	}
+
+	parent := root
+	if len(thread) > 1 {
+		if parent, err = c.getRecord(thread[len(thread)-1]); err != nil {
+			return thread, err
+		}
+	}
+
+	return c.publishThread(s, nil, thread, &replyRef{Root: root, Parent: parent})
+}
+
+func (c *Client) SendUpdateWithPhoto(s string, pics ...[]byte) ([]string, error) {
+	if len(pics) > postMaxImages {
+		pics = pics[:postMaxImages]
+	}
+
+	images := make([]embedImage, 0, len(pics))
+
+	for _, pic := range pics {
+		blob, err := c.uploadBlob(pic)
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, embedImage{Image: blob, Alt: s})
+	}
+
+	return c.publishPost(s, &embed{Type: imagesEmbed, Images: images})
+}
+
+func (c *Client) DeleteUpdates(ids ...string) error {
+	for i := range ids {
+		repo, rkey, err := parsePostURI(ids[i])
+		if err != nil {
+			return err
+		}
+
+		body, _ := easyjson.Marshal(deleteRecordRequest{Repo: repo, Collection: postCollection, Rkey: rkey})
+
+		if err := c.call(deleteRecordMethod, applicationJSONValue, body, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) Chunks(s string) []string {
+	return c.splitter.Split(s)
+}
+
+func (c *Client) publishPost(s string, e *embed) ([]string, error) {
+	if s == "" && e == nil {
+		return nil, nil
+	}
+
+	return c.publishThread(s, e, nil, nil)
+}
+
+func (c *Client) publishThread(s string, e *embed, thread []string, reply *replyRef) ([]string, error) {
+	ids := append([]string(nil), thread...)
+
+	for _, chunk := range textsplit.Remaining(c.Chunks(s), len(ids)) {
+		record := postRecord{
+			Type:      postCollection,
+			Text:      chunk,
+			CreatedAt: c.clk.Now().Format(time.RFC3339),
+			Facets:    facets(chunk),
+			Reply:     reply,
+		}
+
+		if len(ids) == 0 {
+			record.Embed = e
+		}
+
+		created, err := c.createRecord(record)
+		if err != nil {
+			return ids, err
+		}
+
+		ids = append(ids, created.URI)
+
+		parent := strongRef{URI: created.URI, CID: created.CID}
+		if reply == nil {
+			reply = &replyRef{Root: parent, Parent: parent}
+
+			continue
+		}
+
+		reply = &replyRef{Root: reply.Root, Parent: parent}
+	}
+
+	return ids, nil
+}
+
+func (c *Client) createRecord(record postRecord) (recordResponse, error) {
+	did, err := c.did()
+	if err != nil {
+		return recordResponse{}, err
+	}
+
+	body, _ := easyjson.Marshal(createRecordRequest{Repo: did, Collection: postCollection, Record: record})
+
+	var created recordResponse
+	err = c.call(createRecordMethod, applicationJSONValue, body, &created)
+
+	return created, err
+}
+
+func (c *Client) getRecord(uri string) (strongRef, error) {
+	repo, rkey, err := parsePostURI(uri)
+	if err != nil {
+		return strongRef{}, err
+	}
+
+	params := url.Values{"repo": {repo}, "collection": {postCollection}, "rkey": {rkey}}
+
+	var record recordResponse
+	if err := c.query(getRecordMethod, params, &record); err != nil {
+		return strongRef{}, err
+	}
+
+	return strongRef{URI: record.URI, CID: record.CID}, nil
+}
+
+func (c *Client) uploadBlob(media []byte) (easyjson.RawMessage, error) {
+	if len(media) > blobMaxSize {
+		var err error
+		if media, err = shrink(media); err != nil {
+			return nil, err
+		}
+	}
+
+	var uploaded blobResponse
+	if err := c.call(uploadBlobMethod, http.DetectContentType(media), media, &uploaded); err != nil {
+		return nil, err
+	}
+
+	return uploaded.Blob, nil
+}
+
+func (c *Client) did() (string, error) {
+	s, err := c.currentSession(false)
+
+	return s.Did, err
+}
+
+func (c *Client) currentSession(renew bool) (sessionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session.AccessJwt != "" && !renew {
+		return c.session, nil
+	}
+
+	s, err := c.refreshSession()
+	if err != nil {
+		body, _ := easyjson.Marshal(sessionRequest{Identifier: c.identifier, Password: c.password})
+
+		if s, err = c.openSession(createSessionMethod, applicationJSONValue, body, ""); err != nil {
+			return sessionResponse{}, err
+		}
+	}
+
+	c.session = s
+
+	return s, nil
+}
+
+func (c *Client) refreshSession() (sessionResponse, error) {
+	if c.session.RefreshJwt == "" {
+		return sessionResponse{}, errNoSession
+	}
+
+	return c.openSession(refreshSessionMethod, "", nil, c.session.RefreshJwt)
+}
+
+func (c *Client) openSession(method, contentType string, body []byte, token string) (sessionResponse, error) {
+	resp, err := c.request(http.MethodPost, method, contentType, body, token)
+	if err != nil {
+		return sessionResponse{}, err
+	}
+
+	var s sessionResponse
+	err = decode(resp, &s)
+
+	return s, err
+}
+
+func (c *Client) call(method, contentType string, body []byte, out easyjson.Unmarshaler) error {
+	return c.authorized(out, func(token string) (*http.Response, error) {
+		return c.request(http.MethodPost, method, contentType, body, token)
+	})
+}
+
+func (c *Client) query(method string, params url.Values, out easyjson.Unmarshaler) error {
+	return c.authorized(out, func(token string) (*http.Response, error) {
+		return c.request(http.MethodGet, method+"?"+params.Encode(), "", nil, token)
+	})
+}
+
+func (c *Client) authorized(out easyjson.Unmarshaler, send func(token string) (*http.Response, error)) error {
+	s, err := c.currentSession(false)
+	if err != nil {
+		return err
+	}
+
+	resp, err := send(s.AccessJwt)
+	if errors.Is(err, errExpiredToken) {
+		if s, err = c.currentSession(true); err != nil {
+			return err
+		}
+
+		resp, err = send(s.AccessJwt)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return decode(resp, out)
+}
+
+func (c *Client) request(httpMethod, method, contentType string, body []byte, token string) (*http.Response, error) {
+	req, err := http.NewRequest(httpMethod, c.host+"/xrpc/"+method, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending post: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	content, _ := io.ReadAll(resp.Body)
+
+	var xe xrpcError
+	if easyjson.Unmarshal(content, &xe) == nil && xe.Error == expiredTokenError {
+		return nil, errExpiredToken
+	}
+
+	return nil, fmt.Errorf(
+		"error sending post: %w. Response status code: %v and body: %s",
+		errUnexpectedStatus,
+		resp.StatusCode,
+		content,
+	)
+}
+
+func decode(resp *http.Response, out easyjson.Unmarshaler) error {
+	defer func() { _ = resp.Body.Close() }()
+
+	if out == nil {
+		return nil
+	}
+
+	if err := easyjson.UnmarshalFromReader(resp.Body, out); err != nil {
+		return fmt.Errorf("error sending post: %w", err)
+	}
+
+	return nil
+}
+
+// shrink encodes media as JPEG, halving its size until it fits in the blob limit.
+func shrink(media []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(media))
+	if err != nil {
+		return nil, blobTooLargeError{size: len(media)}
+	}
+
+	for {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: blobQuality}); err != nil {
+			return nil, err
+		}
+
+		if buf.Len() <= blobMaxSize {
+			return buf.Bytes(), nil
+		}
+
+		if img.Bounds().Dx() < 2 || img.Bounds().Dy() < 2 {
+			return nil, blobTooLargeError{size: buf.Len()}
+		}
+
+		img = halve(img)
+	}
+}
+
+func halve(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewRGBA64(image.Rect(0, 0, b.Dx()/2, b.Dy()/2))
+
+	for y := range out.Rect.Dy() {
+		for x := range out.Rect.Dx() {
+			var r, g, bl, a uint32
+
+			for _, p := range []image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				pr, pg, pb, pa := img.At(b.Min.X+2*x+p.X, b.Min.Y+2*y+p.Y).RGBA()
+				r, g, bl, a = r+pr, g+pg, bl+pb, a+pa
+			}
+
+			out.SetRGBA64(x, y, color.RGBA64{R: uint16(r / 4), G: uint16(g / 4), B: uint16(bl / 4), A: uint16(a / 4)})
+		}
+	}
+
+	return out
+}
+
+func facets(text string) []facet {
+	var ff []facet
+
+	for _, url := range extract.ExtractUrls(text) {
+		uri := url.Text
+		if !strings.Contains(uri, "://") {
+			uri = "https://" + uri
+		}
+
+		ff = append(ff, facet{
+			Index:    facetIndex{ByteStart: url.ByteRange.Start, ByteEnd: url.ByteRange.Stop},
+			Features: []facetFeature{{Type: linkFeature, URI: uri}},
+		})
+	}
+
+	for _, hashtag := range extract.ExtractHashtags(text) {
+		tag, _ := hashtag.Hashtag()
+
+		ff = append(ff, facet{
+			Index:    facetIndex{ByteStart: hashtag.ByteRange.Start, ByteEnd: hashtag.ByteRange.Stop},
+			Features: []facetFeature{{Type: tagFeature, Tag: tag}},
+		})
+	}
+
+	return ff
+}
+
+func parsePostURI(uri string) (string, string, error) {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+	if !strings.HasPrefix(uri, "at://") || len(parts) != 3 || parts[1] != postCollection {
+		return "", "", fmt.Errorf("%w: %s", errInvalidPostURI, uri)
+	}
+
+	return parts[0], parts[2], nil
+}
@@ -0,0 +1,446 @@
+package bluesky_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quintodown/quintodownbot/internal/bluesky"
+	"github.com/quintodown/quintodownbot/mocks/clock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	did      = "did:plc:quintodown"
+	password = "app-password"
+)
+
+type facet struct {
+	Index struct {
+		ByteStart int `json:"byteStart"`
+		ByteEnd   int `json:"byteEnd"`
+	} `json:"index"`
+	Features []struct {
+		Type string `json:"$type"`
+		URI  string `json:"uri"`
+		Tag  string `json:"tag"`
+	} `json:"features"`
+}
+
+type ref struct {
+	URI string `json:"uri"`
+	CID string `json:"cid"`
+}
+
+type record struct {
+	Type      string  `json:"$type"`
+	Text      string  `json:"text"`
+	CreatedAt string  `json:"createdAt"`
+	Facets    []facet `json:"facets"`
+	Reply     *struct {
+		Root   ref `json:"root"`
+		Parent ref `json:"parent"`
+	} `json:"reply"`
+	Embed *struct {
+		Type   string `json:"$type"`
+		Images []struct {
+			Image json.RawMessage `json:"image"`
+			Alt   string          `json:"alt"`
+		} `json:"images"`
+	} `json:"embed"`
+}
+
+type pds struct {
+	mu        sync.Mutex
+	sessions  int
+	refreshes int
+	expired   bool
+	revoked   bool
+	records   []record
+	blobs     []string
+	deleted   []string
+}
+
+func (p *pds) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r.URL.Path == "/xrpc/com.atproto.server.createSession" {
+		p.createSession(w, r)
+
+		return
+	}
+
+	if r.URL.Path == "/xrpc/com.atproto.server.refreshSession" {
+		p.refreshSession(w, r)
+
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer token"+strconv.Itoa(p.sessions) || p.expired {
+		p.expired = false
+
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":"ExpiredToken","message":"Token has expired"}`)
+
+		return
+	}
+
+	switch r.URL.Path {
+	case "/xrpc/com.atproto.repo.uploadBlob":
+		p.uploadBlob(w, r)
+	case "/xrpc/com.atproto.repo.createRecord":
+		p.createRecord(w, r)
+	case "/xrpc/com.atproto.repo.deleteRecord":
+		p.deleteRecord(w, r)
+	case "/xrpc/com.atproto.repo.getRecord":
+		p.getRecord(w, r)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func (p *pds) createSession(w http.ResponseWriter, r *http.Request) {
+	var s struct {
+		Identifier string `json:"identifier"`
+		Password   string `json:"password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil || s.Password != password {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"error":"AuthenticationRequired","message":"Invalid identifier or password"}`)
+
+		return
+	}
+
+	p.openSession(w)
+}
+
+func (p *pds) refreshSession(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer refresh"+strconv.Itoa(p.sessions) || p.revoked {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":"ExpiredToken","message":"Token has been revoked"}`)
+
+		return
+	}
+
+	p.refreshes++
+	p.openSession(w)
+}
+
+func (p *pds) openSession(w http.ResponseWriter) {
+	p.sessions++
+	n := strconv.Itoa(p.sessions)
+	_, _ = io.WriteString(w, `{"accessJwt":"token`+n+`","refreshJwt":"refresh`+n+`","did":"`+did+`"}`)
+}
+
+func (p *pds) uploadBlob(w http.ResponseWriter, r *http.Request) {
+	content, _ := io.ReadAll(r.Body)
+	if string(content) == "invalid media" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":"InvalidRequest","message":"Unsupported media"}`)
+
+		return
+	}
+
+	if len(content) > 1000000 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":"BlobTooLarge","message":"This file is too large"}`)
+
+		return
+	}
+
+	p.blobs = append(p.blobs, r.Header.Get("Content-Type"))
+	_, _ = io.WriteString(w, `{"blob":{"$type":"blob","ref":{"$link":"blob`+strconv.Itoa(len(p.blobs))+`"}}}`)
+}
+
+func (p *pds) createRecord(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Repo       string `json:"repo"`
+		Collection string `json:"collection"`
+		Record     record `json:"record"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Record.Text == "it should fail" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":"InvalidRequest","message":"Invalid record"}`)
+
+		return
+	}
+
+	p.records = append(p.records, req.Record)
+	n := strconv.Itoa(len(p.records))
+	_, _ = io.WriteString(w, `{"uri":"at://`+req.Repo+`/`+req.Collection+`/post`+n+`","cid":"cid`+n+`"}`)
+}
+
+func (p *pds) deleteRecord(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Repo       string `json:"repo"`
+		Collection string `json:"collection"`
+		Rkey       string `json:"rkey"`
+	}
+
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	p.deleted = append(p.deleted, req.Repo+"/"+req.Collection+"/"+req.Rkey)
+	_, _ = io.WriteString(w, `{}`)
+}
+
+func (p *pds) getRecord(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	uri := "at://" + q.Get("repo") + "/" + q.Get("collection") + "/" + q.Get("rkey")
+	_, _ = io.WriteString(w, `{"uri":"`+uri+`","cid":"cid-`+q.Get("rkey")+`","value":{}}`)
+}
+
+func TestClient_SendUpdate(t *testing.T) {
+	t.Run("it should fail when session couldn't be created", func(t *testing.T) {
+		p := &pds{}
+		server := httptest.NewServer(p)
+		defer server.Close()
+
+		client := bluesky.NewBlueskyClient(server.Client(), getClock(), server.URL, "quintodown.com", "invalid")
+
+		_, err := client.SendUpdate("testing")
+
+		require.EqualError(
+			t,
+			err,
+			"error sending post: unexpected response status. Response status code: 401 and body: "+
+				`{"error":"AuthenticationRequired","message":"Invalid identifier or password"}`,
+		)
+	})
+
+	t.Run("it should fail when error happens on Bluesky API", func(t *testing.T) {
+		client, _ := getClient(t)
+
+		_, err := client.SendUpdate("it should fail")
+
+		require.EqualError(
+			t,
+			err,
+			"error sending post: unexpected response status. Response status code: 400 and body: "+
+				`{"error":"InvalidRequest","message":"Invalid record"}`,
+		)
+	})
+
+	t.Run("it should not send post when it's empty", func(t *testing.T) {
+		client, p := getClient(t)
+
+		ids, err := client.SendUpdate("")
+
+		require.NoError(t, err)
+		require.Empty(t, ids)
+		require.Zero(t, p.sessions)
+	})
+
+	t.Run("it should create a post record with facets for links and hashtags", func(t *testing.T) {
+		client, p := getClient(t)
+
+		ids, err := client.SendUpdate("😀 Touchdown #NFL quintodown.com/live")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"at://" + did + "/app.bsky.feed.post/post1"}, ids)
+		require.Len(t, p.records, 1)
+		require.Equal(t, "app.bsky.feed.post", p.records[0].Type)
+		require.Equal(t, "2021-10-05T20:00:00Z", p.records[0].CreatedAt)
+		require.Nil(t, p.records[0].Reply)
+		require.Nil(t, p.records[0].Embed)
+		require.Len(t, p.records[0].Facets, 2)
+
+		link := p.records[0].Facets[0]
+		require.Equal(t, "quintodown.com/live", p.records[0].Text[link.Index.ByteStart:link.Index.ByteEnd])
+		require.Equal(t, "app.bsky.richtext.facet#link", link.Features[0].Type)
+		require.Equal(t, "https://quintodown.com/live", link.Features[0].URI)
+
+		tag := p.records[0].Facets[1]
+		require.Equal(t, "#NFL", p.records[0].Text[tag.Index.ByteStart:tag.Index.ByteEnd])
+		require.Equal(t, "app.bsky.richtext.facet#tag", tag.Features[0].Type)
+		require.Equal(t, "NFL", tag.Features[0].Tag)
+	})
+
+	t.Run("it should send long post as a reply chain", func(t *testing.T) {
+		client, p := getClient(t)
+
+		ids, err := client.SendUpdate(strings.Repeat("word ", 130))
+
+		require.NoError(t, err)
+		require.Len(t, ids, 3)
+		require.Len(t, p.records, 3)
+		require.Nil(t, p.records[0].Reply)
+		require.Equal(t, ref{URI: ids[0], CID: "cid1"}, p.records[1].Reply.Root)
+		require.Equal(t, ref{URI: ids[0], CID: "cid1"}, p.records[1].Reply.Parent)
+		require.Equal(t, ref{URI: ids[0], CID: "cid1"}, p.records[2].Reply.Root)
+		require.Equal(t, ref{URI: ids[1], CID: "cid2"}, p.records[2].Reply.Parent)
+	})
+
+	t.Run("it should continue a reply chain from its last post", func(t *testing.T) {
+		client, p := getClient(t)
+
+		thread := []string{"at://" + did + "/app.bsky.feed.post/root", "at://" + did + "/app.bsky.feed.post/last"}
+
+		ids, err := client.ContinueThread(strings.Repeat("word ", 130), thread)
+
+		require.NoError(t, err)
+		require.Len(t, ids, 3)
+		require.Equal(t, thread, ids[:2])
+		require.Len(t, p.records, 1)
+		require.Equal(t, ref{URI: thread[0], CID: "cid-root"}, p.records[0].Reply.Root)
+		require.Equal(t, ref{URI: thread[1], CID: "cid-last"}, p.records[0].Reply.Parent)
+	})
+
+	t.Run("it should refresh the session when the token expires", func(t *testing.T) {
+		client, p := getClient(t)
+
+		_, err := client.SendUpdate("first")
+		require.NoError(t, err)
+
+		p.expired = true
+
+		_, err = client.SendUpdate("second")
+
+		require.NoError(t, err)
+		require.Equal(t, 2, p.sessions)
+		require.Equal(t, 1, p.refreshes)
+		require.Len(t, p.records, 2)
+	})
+
+	t.Run("it should create a new session when the session can't be refreshed", func(t *testing.T) {
+		client, p := getClient(t)
+
+		_, err := client.SendUpdate("first")
+		require.NoError(t, err)
+
+		p.expired = true
+		p.revoked = true
+
+		_, err = client.SendUpdate("second")
+
+		require.NoError(t, err)
+		require.Equal(t, 2, p.sessions)
+		require.Zero(t, p.refreshes)
+		require.Len(t, p.records, 2)
+	})
+}
+
+func TestClient_SendUpdateWithPhoto(t *testing.T) {
+	t.Run("it should fail when image couldn't be uploaded", func(t *testing.T) {
+		client, p := getClient(t)
+
+		_, err := client.SendUpdateWithPhoto("testing", []byte("invalid media"))
+
+		require.EqualError(
+			t,
+			err,
+			"error sending post: unexpected response status. Response status code: 400 and body: "+
+				`{"error":"InvalidRequest","message":"Unsupported media"}`,
+		)
+		require.Empty(t, p.records)
+	})
+
+	t.Run("it should shrink images over the blob size limit", func(t *testing.T) {
+		client, p := getClient(t)
+
+		ids, err := client.SendUpdateWithPhoto("testing", noise(t, 1000, 1000))
+
+		require.NoError(t, err)
+		require.Len(t, ids, 1)
+		require.Equal(t, []string{"image/jpeg"}, p.blobs)
+	})
+
+	t.Run("it should fail without retrying when a large image can't be shrunk", func(t *testing.T) {
+		client, p := getClient(t)
+
+		_, err := client.SendUpdateWithPhoto("testing", bytes.Repeat([]byte("a"), 1000001))
+
+		var permanent interface{ Permanent() bool }
+
+		require.EqualError(t, err, "image of 1000001 bytes exceeds the 1000000 bytes limit of Bluesky")
+		require.ErrorAs(t, err, &permanent)
+		require.True(t, permanent.Permanent())
+		require.Empty(t, p.blobs)
+		require.Empty(t, p.records)
+	})
+
+	t.Run("it should embed the uploaded images in the first post", func(t *testing.T) {
+		client, p := getClient(t)
+		pics := make([][]byte, 5)
+
+		for i := range pics {
+			pics[i] = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+		}
+
+		ids, err := client.SendUpdateWithPhoto("testing", pics...)
+
+		require.NoError(t, err)
+		require.Len(t, ids, 1)
+		require.Equal(t, []string{"image/png", "image/png", "image/png", "image/png"}, p.blobs)
+		require.Equal(t, "app.bsky.embed.images", p.records[0].Embed.Type)
+		require.Len(t, p.records[0].Embed.Images, 4)
+		require.Equal(t, "testing", p.records[0].Embed.Images[0].Alt)
+		require.JSONEq(t, `{"$type":"blob","ref":{"$link":"blob1"}}`, string(p.records[0].Embed.Images[0].Image))
+	})
+}
+
+func TestClient_DeleteUpdates(t *testing.T) {
+	t.Run("it should delete the post records", func(t *testing.T) {
+		client, p := getClient(t)
+
+		err := client.DeleteUpdates(
+			"at://"+did+"/app.bsky.feed.post/post1",
+			"at://"+did+"/app.bsky.feed.post/post2",
+		)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{did + "/app.bsky.feed.post/post1", did + "/app.bsky.feed.post/post2"}, p.deleted)
+	})
+
+	t.Run("it should fail when post uri is not valid", func(t *testing.T) {
+		client, p := getClient(t)
+
+		require.EqualError(t, client.DeleteUpdates("post1"), "invalid post uri: post1")
+		require.Empty(t, p.deleted)
+	})
+}
+
+func noise(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	r := rand.New(rand.NewPCG(1, 2))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for i := range img.Pix {
+		img.Pix[i] = uint8(r.UintN(256))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	require.Greater(t, buf.Len(), 1000000)
+
+	return buf.Bytes()
+}
+
+func getClock() *clock.Clock {
+	clk := new(clock.Clock)
+	clk.On("Now").Return(time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC)).Maybe()
+
+	return clk
+}
+
+func getClient(t *testing.T) (*bluesky.Client, *pds) {
+	t.Helper()
+
+	p := &pds{}
+	server := httptest.NewServer(p)
+	t.Cleanup(server.Close)
+
+	return bluesky.NewBlueskyClient(server.Client(), getClock(), server.URL+"/", "quintodown.com", password), p
+}
@@ -5,8 +5,13 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"sync"
 
+	"github.com/quintodown/quintodownbot/internal/clock"
+	"github.com/quintodown/quintodownbot/internal/posts"
 	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/scheduler"
+	"github.com/quintodown/quintodownbot/internal/webhook"
 
 	"github.com/quintodown/quintodownbot/internal/config"
 	tb "gopkg.in/telebot.v3"
@@ -18,11 +23,19 @@ type TelegramBot interface {
 	SetCommands([]TelegramBotCommand) error
 	Handle(string, TelegramHandler)
 	Send(string, interface{}, ...interface{}) error
+	Publish(string, interface{}, ...interface{}) ([]string, error)
+	Edit(string, []string, interface{}) ([]string, error)
+	Delete(string, ...string) error
 	GetFile(string) (io.ReadCloser, error)
+	HandleCallback(string, TelegramCallbackHandler)
+	Respond(string, string) error
+	Chunks(string) []string
 }
 
 type TelegramHandler func(TelegramMessage) error
 
+type TelegramCallbackHandler func(TelegramCallback) error
+
 type TelegramBotCommand struct {
 	Text        string
 	Description string
@@ -30,20 +43,75 @@ type TelegramBotCommand struct {
 }
 
 type TelegramMessage struct {
+	ID        string
 	SenderID  string
+	ReplyTo   string
 	Text      string
+	Entities  []TelegramEntity
 	Payload   string
 	Photo     TelegramPhoto
+	Video     TelegramVideo
+	Document  TelegramDocument
 	IsPrivate bool
+	Edited    bool
+}
+
+type TelegramEntity struct {
+	Type   string
+	Offset int
+	Length int
+	URL    string
+}
+
+type TelegramText struct {
+	Text     string
+	Entities []TelegramEntity
 }
 
 type TelegramPhoto struct {
+	Caption     string
+	FileID      string
+	FileURL     string
+	FileSize    int64
+	FileContent []byte
+	AlbumID     string
+}
+
+type TelegramAlbum []TelegramPhoto
+
+type TelegramVideo struct {
+	Caption   string
+	FileID    string
+	FileURL   string
+	FileSize  int64
+	MimeType  string
+	Animation bool
+}
+
+type TelegramDocument struct {
 	Caption  string
 	FileID   string
 	FileURL  string
 	FileSize int64
+	FileName string
+	MimeType string
 }
 
+type TelegramCallback struct {
+	ID        string
+	SenderID  string
+	MessageID string
+	Data      string
+}
+
+type TelegramButton struct {
+	Unique string
+	Text   string
+	Data   string
+}
+
+type TelegramButtons []TelegramButton
+
 type AppBot interface {
 	Start(ctx context.Context) error
 	Run()
@@ -51,8 +119,35 @@ type AppBot interface {
 }
 
 type TwitterClient interface {
-	SendUpdate(string) error
-	SendUpdateWithPhoto(string, []byte) error
+	SendUpdate(string) ([]string, error)
+	SendUpdateWithPhoto(string, ...[]byte) ([]string, error)
+	SendUpdateWithVideo(string, []byte) ([]string, error)
+	ContinueThread(string, []string) ([]string, error)
+	DeleteUpdates(...string) error
+	Chunks(string) []string
+}
+
+type DeadLetters interface {
+	List() ([]pubsub.DeadLetterEvent, error)
+	Replay(string) error
+	Discard(string) error
+}
+
+type Posts interface {
+	Get(string) (posts.Post, error)
+	History(int) ([]posts.Publication, error)
+}
+
+type Scheduler interface {
+	Schedule(string, pubsub.TopicName, string, []byte) (scheduler.Job, error)
+	List() ([]scheduler.Job, error)
+	Unschedule(string) error
+}
+
+type Webhooks interface {
+	Add(string, []string) (webhook.Subscription, error)
+	List() ([]webhook.Subscription, error)
+	Remove(string) error
 }
 
 type Bot struct {
@@ -60,6 +155,15 @@ type Bot struct {
 	tc  TwitterClient
 	cfg config.AppConfig
 	q   pubsub.Queue
+	dl  DeadLetters
+	sc  Scheduler
+	ps  Posts
+	wh  Webhooks
+	clk clock.Clock
+
+	mu     sync.Mutex
+	drafts map[string]draft
+	albums map[string]*album
 }
 
 type Option func(b *Bot)
@@ -95,8 +199,38 @@ func WithQueue(q pubsub.Queue) Option {
 	}
 }
 
+func WithDeadLetters(dl DeadLetters) Option {
+	return func(b *Bot) {
+		b.dl = dl
+	}
+}
+
+func WithScheduler(sc Scheduler) Option {
+	return func(b *Bot) {
+		b.sc = sc
+	}
+}
+
+func WithPosts(ps Posts) Option {
+	return func(b *Bot) {
+		b.ps = ps
+	}
+}
+
+func WithWebhooks(wh Webhooks) Option {
+	return func(b *Bot) {
+		b.wh = wh
+	}
+}
+
+func WithClock(clk clock.Clock) Option {
+	return func(b *Bot) {
+		b.clk = clk
+	}
+}
+
 func NewBot(options ...Option) AppBot {
-	b := &Bot{}
+	b := &Bot{clk: clock.NewUTCClock(), drafts: map[string]draft{}, albums: map[string]*album{}}
 
 	for _, o := range options {
 		o(b)
@@ -121,7 +255,6 @@ func (b *Bot) Run() {
 
 func (b *Bot) Stop() {
 	b.bot.Stop()
-	_ = b.q.Close()
 }
 
 func (b *Bot) getHandlers() map[string]botHandler {
@@ -149,6 +282,123 @@ func (b *Bot) getHandlers() map[string]botHandler {
 			},
 			isAdmin: true,
 		},
+		"/resume": {
+			handlerFunc: b.handleResumeNotificationsCommand,
+			help:        "Resume notifications for all handlers or specific handler",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/status": {
+			handlerFunc: b.handleStatusCommand,
+			help:        "Show which handlers are paused",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/deadletters": {
+			handlerFunc: b.handleDeadLettersCommand,
+			help:        "List messages that couldn't be delivered after retrying",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/replay": {
+			handlerFunc: b.handleReplayCommand,
+			help:        "Send again a message that couldn't be delivered",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/discard": {
+			handlerFunc: b.handleDiscardCommand,
+			help:        "Discard a message that couldn't be delivered",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/schedule": {
+			handlerFunc: b.handleScheduleCommand,
+			help:        "Schedule a post to be published later",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/scheduled": {
+			handlerFunc: b.handleScheduledCommand,
+			help:        "List posts pending to be published",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/delete": {
+			handlerFunc: b.handleDeleteCommand,
+			help:        "Delete a published post replying to it",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/history": {
+			handlerFunc: b.handleHistoryCommand,
+			help:        "List the latest publications, 10 unless a number is given",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/telegram": {
+			handlerFunc: b.handleTelegramCommand,
+			help:        "Publish a post only in the Telegram channel",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/twitter": {
+			handlerFunc: b.handleTwitterCommand,
+			help:        "Publish a post only in Twitter",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/unschedule": {
+			handlerFunc: b.handleUnscheduleCommand,
+			help:        "Remove a post pending to be published",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
+		"/webhook": {
+			handlerFunc: b.handleWebhookCommand,
+			help:        "Add, list or remove the webhooks notified of every event",
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+			isAdmin: true,
+		},
 		tb.OnPhoto: {
 			handlerFunc: b.handlePhoto,
 			filters: []filterFunc{
@@ -156,6 +406,27 @@ func (b *Bot) getHandlers() map[string]botHandler {
 				b.onlyAdmins,
 			},
 		},
+		tb.OnVideo: {
+			handlerFunc: b.handleVideo,
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+		},
+		tb.OnAnimation: {
+			handlerFunc: b.handleVideo,
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+		},
+		tb.OnDocument: {
+			handlerFunc: b.handleDocument,
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+		},
 		tb.OnText: {
 			handlerFunc: b.handleText,
 			filters: []filterFunc{
@@ -163,6 +434,13 @@ func (b *Bot) getHandlers() map[string]botHandler {
 				b.onlyAdmins,
 			},
 		},
+		tb.OnEdited: {
+			handlerFunc: b.handleEdited,
+			filters: []filterFunc{
+				b.onlyPrivate,
+				b.onlyAdmins,
+			},
+		},
 	}
 }
 
@@ -203,4 +481,18 @@ func (b *Bot) setUpHandlers() {
 
 		b.bot.Handle(c, exec)
 	}
+
+	for c, h := range b.getCallbacks() {
+		b.bot.HandleCallback(c, b.onlyAdminCallbacks(h))
+	}
+}
+
+func (b *Bot) getCallbacks() map[string]TelegramCallbackHandler {
+	return map[string]TelegramCallbackHandler{
+		publishButton:  b.handlePublishCallback,
+		telegramButton: b.handlePublishTelegramCallback,
+		twitterButton:  b.handlePublishTwitterCallback,
+		editButton:     b.handleEditCallback,
+		cancelButton:   b.handleCancelCallback,
+	}
 }
@@ -52,8 +52,30 @@ func TestStart(t *testing.T) {
 		mockedBot.On("Handle", "/start", mock.Anything).Once().Return(nil, nil)
 		mockedBot.On("Handle", "/help", mock.Anything).Once().Return(nil, nil)
 		mockedBot.On("Handle", "/stop", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/resume", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/status", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/deadletters", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/replay", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/discard", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/schedule", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/scheduled", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/delete", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/history", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/telegram", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/twitter", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/unschedule", mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", "/webhook", mock.Anything).Once().Return(nil, nil)
 		mockedBot.On("Handle", tb.OnPhoto, mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", tb.OnVideo, mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", tb.OnAnimation, mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", tb.OnDocument, mock.Anything).Once().Return(nil, nil)
 		mockedBot.On("Handle", tb.OnText, mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("Handle", tb.OnEdited, mock.Anything).Once().Return(nil, nil)
+		mockedBot.On("HandleCallback", "publish", mock.Anything).Once()
+		mockedBot.On("HandleCallback", "publishtelegram", mock.Anything).Once()
+		mockedBot.On("HandleCallback", "publishtwitter", mock.Anything).Once()
+		mockedBot.On("HandleCallback", "edit", mock.Anything).Once()
+		mockedBot.On("HandleCallback", "cancel", mock.Anything).Once()
 
 		require.Nil(t, b.Start(nil))
 
@@ -75,10 +97,9 @@ func TestStop(t *testing.T) {
 	mockedBot.On("Stop").Once()
 
 	mockedQueue := new(mq.Queue)
-	mockedQueue.On("Close").Once().Return(nil)
 
 	bot.NewBot(bot.WithTelegramBot(mockedBot), bot.WithQueue(mockedQueue)).Stop()
 
 	mockedBot.AssertExpectations(t)
-	mockedQueue.AssertExpectations(t)
+	mockedQueue.AssertNotCalled(t, "Close")
 }
@@ -30,3 +30,18 @@ func (b *Bot) onlyAdmins(f TelegramHandler) TelegramHandler {
 		return f(m)
 	}
 }
+
+func (b *Bot) onlyAdminCallbacks(f TelegramCallbackHandler) TelegramCallbackHandler {
+	return func(c TelegramCallback) error {
+		senderID, err := strconv.Atoi(c.SenderID)
+		if err != nil {
+			return err
+		}
+
+		if !b.cfg.IsAdmin(senderID) {
+			return nil
+		}
+
+		return f(c)
+	}
+}
@@ -2,15 +2,55 @@ package bot
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf16"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/mailru/easyjson"
 	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/scheduler"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	"github.com/quintodown/quintodownbot/internal/webhook"
 )
 
+const (
+	scheduleCommand = "/schedule"
+	scheduleUsage   = "Usage: /schedule <RFC3339 time|+duration> <text>"
+	telegramCommand = "/telegram"
+	twitterCommand  = "/twitter"
+	webhookUsage    = "Usage: /webhook add <url> [topic+topic] | list | remove <id>"
+	telegramHandler = "telegram"
+	twitterHandler  = "twitter"
+	publishButton   = "publish"
+	telegramButton  = "publishtelegram"
+	twitterButton   = "publishtwitter"
+	editButton      = "edit"
+	cancelButton    = "cancel"
+
+	defaultHistoryLength = 10
+	draftTTL             = time.Hour
+)
+
+type draft struct {
+	text         string
+	entities     []TelegramEntity
+	destinations pubsub.Destinations
+	source       string
+	edit         bool
+	createdAt    time.Time
+}
+
+type album struct {
+	senderID string
+	source   string
+	photos   []TelegramPhoto
+}
+
 func (b *Bot) handleStartCommand(m TelegramMessage) error {
 	return b.bot.Send(m.SenderID, "Thanks for using the bot! You can type /help command to know what can I do")
 }
@@ -30,39 +70,489 @@ func (b *Bot) handleHelpCommand(m TelegramMessage) error {
 }
 
 func (b *Bot) handleStopNotificationsCommand(m TelegramMessage) error {
-	ce := pubsub.CommandEvent{Command: pubsub.StopCommand}
-	if m.Payload != "" {
-		ce.Handler = m.Payload
-	}
+	return b.publishCommand(pubsub.CommandEvent{Command: pubsub.StopCommand, Handler: m.Payload})
+}
 
+func (b *Bot) handleResumeNotificationsCommand(m TelegramMessage) error {
+	return b.publishCommand(pubsub.CommandEvent{Command: pubsub.StartCommand, Handler: m.Payload})
+}
+
+func (b *Bot) handleStatusCommand(m TelegramMessage) error {
+	return b.publishCommand(pubsub.CommandEvent{Command: pubsub.StatusCommand, ReplyTo: m.SenderID})
+}
+
+func (b *Bot) publishCommand(ce pubsub.CommandEvent) error {
 	marshal, _ := easyjson.Marshal(ce)
 
 	return b.q.Publish(pubsub.CommandTopic.String(), message.NewMessage(watermill.NewUUID(), marshal))
 }
 
+func (b *Bot) handleDeadLettersCommand(m TelegramMessage) error {
+	letters, err := b.dl.List()
+	if err != nil {
+		return err
+	}
+
+	if len(letters) == 0 {
+		return b.bot.Send(m.SenderID, "There are no dead letters")
+	}
+
+	var text string
+	for _, l := range letters {
+		text += fmt.Sprintf(
+			"%s - %s %s after %d attempts (%s): %s\n",
+			l.ID,
+			l.Handler,
+			l.Topic,
+			l.Attempts,
+			l.FailedAt.Format(time.RFC3339),
+			l.Error,
+		)
+	}
+
+	return b.bot.Send(m.SenderID, text)
+}
+
+func (b *Bot) handleReplayCommand(m TelegramMessage) error {
+	return b.handleIDAction(m, "/replay", "Dead letter", "replayed", b.dl.Replay)
+}
+
+func (b *Bot) handleDiscardCommand(m TelegramMessage) error {
+	return b.handleIDAction(m, "/discard", "Dead letter", "discarded", b.dl.Discard)
+}
+
+func (b *Bot) handleTelegramCommand(m TelegramMessage) error {
+	return b.handleDestinationCommand(m, telegramCommand, telegramHandler)
+}
+
+func (b *Bot) handleTwitterCommand(m TelegramMessage) error {
+	return b.handleDestinationCommand(m, twitterCommand, twitterHandler)
+}
+
+func (b *Bot) handleDestinationCommand(m TelegramMessage, command, handler string) error {
+	text := strings.TrimSpace(m.Payload)
+	if text == "" {
+		return b.bot.Send(m.SenderID, "Usage: "+command+" <text>")
+	}
+
+	return b.sendText(m.SenderID, draft{
+		text:         text,
+		entities:     textEntities(m, text),
+		destinations: pubsub.Destinations{handler},
+		source:       source(m.SenderID, m.ID),
+	})
+}
+
+func (b *Bot) handleScheduleCommand(m TelegramMessage) error {
+	when, text, ok := parseSchedule(m.Payload)
+	if !ok {
+		return b.bot.Send(m.SenderID, scheduleUsage)
+	}
+
+	destinations, text := parseDestinations(text)
+	if text == "" {
+		return b.bot.Send(m.SenderID, scheduleUsage)
+	}
+
+	mb, _ := easyjson.Marshal(textEvent(draft{
+		text:         text,
+		entities:     textEntities(m, text),
+		destinations: destinations,
+		source:       source(m.SenderID, m.ID),
+	}))
+
+	return b.schedule(m.SenderID, when, pubsub.TextTopic, text, mb)
+}
+
+func (b *Bot) handleScheduledCommand(m TelegramMessage) error {
+	jobs, err := b.sc.List()
+	if err != nil {
+		return err
+	}
+
+	if len(jobs) == 0 {
+		return b.bot.Send(m.SenderID, "There are no scheduled posts")
+	}
+
+	var text string
+	for _, j := range jobs {
+		text += fmt.Sprintf("%s - %s %s: %s\n", j.ID, j.PublishAt.Format(time.RFC3339), j.Topic, j.Text)
+	}
+
+	return b.bot.Send(m.SenderID, text)
+}
+
+func (b *Bot) handleUnscheduleCommand(m TelegramMessage) error {
+	return b.handleIDAction(m, "/unschedule", "Scheduled post", "unscheduled", b.sc.Unschedule)
+}
+
+func (b *Bot) handleDeleteCommand(m TelegramMessage) error {
+	if m.ReplyTo == "" {
+		return b.bot.Send(m.SenderID, "Usage: reply to the message of a post with /delete")
+	}
+
+	src := source(m.SenderID, m.ReplyTo)
+	if _, err := b.ps.Get(src); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return b.bot.Send(m.SenderID, "Post not found")
+		}
+
+		return err
+	}
+
+	eb, _ := easyjson.Marshal(pubsub.DeleteEvent{Source: src})
+	if err := b.q.Publish(pubsub.DeleteTopic.String(), message.NewMessage(watermill.NewUUID(), eb)); err != nil {
+		return err
+	}
+
+	return b.bot.Send(m.SenderID, "Post deleted")
+}
+
+func (b *Bot) handleHistoryCommand(m TelegramMessage) error {
+	n := defaultHistoryLength
+
+	if payload := strings.TrimSpace(m.Payload); payload != "" {
+		var err error
+		if n, err = strconv.Atoi(payload); err != nil || n <= 0 {
+			return b.bot.Send(m.SenderID, "Usage: /history [n]")
+		}
+	}
+
+	history, err := b.ps.History(n)
+	if err != nil {
+		return err
+	}
+
+	if len(history) == 0 {
+		return b.bot.Send(m.SenderID, "There are no publications")
+	}
+
+	var text string
+	for _, p := range history {
+		text += fmt.Sprintf(
+			"%s - %s %s %s %s: %s\n",
+			p.ID,
+			p.At.Format(time.RFC3339),
+			p.Destination,
+			p.Action,
+			p.Source,
+			strings.Join(p.Messages, ", "),
+		)
+	}
+
+	return b.bot.Send(m.SenderID, text)
+}
+
+func (b *Bot) handleWebhookCommand(m TelegramMessage) error {
+	action, args, _ := strings.Cut(strings.TrimSpace(m.Payload), " ")
+	args = strings.TrimSpace(args)
+
+	switch {
+	case action == "add" && args != "":
+		return b.addWebhook(m.SenderID, args)
+	case action == "list" && args == "":
+		return b.listWebhooks(m.SenderID)
+	case action == "remove" && args != "":
+		return b.removeWebhook(m.SenderID, args)
+	}
+
+	return b.bot.Send(m.SenderID, webhookUsage)
+}
+
+func (b *Bot) addWebhook(to, args string) error {
+	s, err := webhook.ParseSubscription(args, "")
+	if err != nil {
+		return b.bot.Send(to, "Couldn't add webhook: "+err.Error())
+	}
+
+	if s, err = b.wh.Add(s.URL, s.Topics); err != nil {
+		return err
+	}
+
+	return b.bot.Send(to, "Webhook "+s.ID+" added, its requests are signed with the secret "+s.Secret)
+}
+
+func (b *Bot) listWebhooks(to string) error {
+	subscriptions, err := b.wh.List()
+	if err != nil {
+		return err
+	}
+
+	if len(subscriptions) == 0 {
+		return b.bot.Send(to, "There are no webhooks")
+	}
+
+	var text string
+	for _, s := range subscriptions {
+		topics := "all"
+		if len(s.Topics) > 0 {
+			topics = strings.Join(s.Topics, "+")
+		}
+
+		text += fmt.Sprintf("%s - %s: %s\n", s.ID, s.URL, topics)
+	}
+
+	return b.bot.Send(to, text)
+}
+
+func (b *Bot) removeWebhook(to, id string) error {
+	err := b.wh.Remove(id)
+
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return b.bot.Send(to, "Webhook "+id+" not found")
+	case errors.Is(err, webhook.ErrConfigured):
+		return b.bot.Send(to, "Webhook "+id+" is configured and can't be removed")
+	case err != nil:
+		return err
+	}
+
+	return b.bot.Send(to, "Webhook "+id+" removed")
+}
+
+func (b *Bot) handleIDAction(m TelegramMessage, command, subject, done string, action func(string) error) error {
+	id := strings.TrimSpace(m.Payload)
+	if id == "" {
+		return b.bot.Send(m.SenderID, "Usage: "+command+" <id>")
+	}
+
+	if err := action(id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return b.bot.Send(m.SenderID, subject+" "+id+" not found")
+		}
+
+		return err
+	}
+
+	return b.bot.Send(m.SenderID, subject+" "+id+" "+done)
+}
+
+func (b *Bot) schedule(to, when string, topic pubsub.TopicName, text string, payload []byte) error {
+	j, err := b.sc.Schedule(when, topic, text, payload)
+	if errors.Is(err, scheduler.ErrInvalidTime) || errors.Is(err, scheduler.ErrPastTime) {
+		return b.bot.Send(to, "Couldn't schedule post: "+err.Error())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return b.bot.Send(to, "Post "+j.ID+" scheduled for "+j.PublishAt.Format(time.RFC3339))
+}
+
+func parseSchedule(s string) (string, string, bool) {
+	when, text, _ := strings.Cut(strings.TrimSpace(s), " ")
+	text = strings.TrimSpace(text)
+
+	return when, text, when != "" && text != ""
+}
+
+func source(senderID, messageID string) string {
+	if messageID == "" {
+		return ""
+	}
+
+	return senderID + ":" + messageID
+}
+
+func parseDestinations(s string) (pubsub.Destinations, string) {
+	for command, handler := range map[string]string{telegramCommand: telegramHandler, twitterCommand: twitterHandler} {
+		if s == command || strings.HasPrefix(s, command+" ") {
+			return pubsub.Destinations{handler}, strings.TrimSpace(strings.TrimPrefix(s, command))
+		}
+	}
+
+	return nil, s
+}
+
 func (b *Bot) handlePhoto(m TelegramMessage) error {
-	caption := strings.TrimSpace(m.Photo.Caption)
+	if m.Photo.AlbumID != "" {
+		b.collectAlbumPhoto(m)
+
+		return nil
+	}
+
+	return b.publishMedia(m.SenderID, m.Photo.Caption, pubsub.PhotoTopic, m.Edited, func(
+		caption string,
+		destinations pubsub.Destinations,
+	) ([]byte, error) {
+		fileContent, err := b.downloadFile(m.Photo.FileID)
+		if err != nil {
+			return nil, err
+		}
+
+		return easyjson.Marshal(pubsub.PhotoEvent{
+			Caption:      caption,
+			FileID:       m.Photo.FileID,
+			FileURL:      m.Photo.FileURL,
+			FileSize:     m.Photo.FileSize,
+			FileContent:  fileContent,
+			Destinations: destinations,
+			Source:       source(m.SenderID, m.ID),
+			Edit:         m.Edited,
+		})
+	})
+}
+
+func (b *Bot) handleVideo(m TelegramMessage) error {
+	return b.publishMedia(m.SenderID, m.Video.Caption, pubsub.VideoTopic, m.Edited, func(
+		caption string,
+		destinations pubsub.Destinations,
+	) ([]byte, error) {
+		fileContent, err := b.downloadFile(m.Video.FileID)
+		if err != nil {
+			return nil, err
+		}
+
+		return easyjson.Marshal(pubsub.VideoEvent{
+			Caption:      caption,
+			FileID:       m.Video.FileID,
+			FileURL:      m.Video.FileURL,
+			FileSize:     m.Video.FileSize,
+			FileContent:  fileContent,
+			MimeType:     m.Video.MimeType,
+			Animation:    m.Video.Animation,
+			Destinations: destinations,
+			Source:       source(m.SenderID, m.ID),
+			Edit:         m.Edited,
+		})
+	})
+}
+
+func (b *Bot) handleDocument(m TelegramMessage) error {
+	return b.publishMedia(m.SenderID, m.Document.Caption, pubsub.DocumentTopic, m.Edited, func(
+		caption string,
+		destinations pubsub.Destinations,
+	) ([]byte, error) {
+		return easyjson.Marshal(pubsub.DocumentEvent{
+			Caption:      caption,
+			FileID:       m.Document.FileID,
+			FileURL:      m.Document.FileURL,
+			FileSize:     m.Document.FileSize,
+			FileName:     m.Document.FileName,
+			MimeType:     m.Document.MimeType,
+			Destinations: destinations,
+			Source:       source(m.SenderID, m.ID),
+			Edit:         m.Edited,
+		})
+	})
+}
+
+func (b *Bot) collectAlbumPhoto(m TelegramMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	a, ok := b.albums[m.Photo.AlbumID]
+	if !ok {
+		a = &album{senderID: m.SenderID, source: source(m.SenderID, m.ID)}
+		b.albums[m.Photo.AlbumID] = a
+
+		time.AfterFunc(b.cfg.AlbumWindow, func() {
+			b.publishAlbum(m.Photo.AlbumID)
+		})
+	}
+
+	a.photos = append(a.photos, m.Photo)
+}
+
+func (b *Bot) publishAlbum(albumID string) {
+	b.mu.Lock()
+	a := b.albums[albumID]
+	delete(b.albums, albumID)
+	b.mu.Unlock()
+
+	var caption string
+
+	for i := range a.photos {
+		if caption = strings.TrimSpace(a.photos[i].Caption); caption != "" {
+			break
+		}
+	}
+
+	err := b.publishMedia(a.senderID, caption, pubsub.AlbumTopic, false, func(
+		caption string,
+		destinations pubsub.Destinations,
+	) ([]byte, error) {
+		photos := make([]pubsub.AlbumPhoto, 0, len(a.photos))
+
+		for i := range a.photos {
+			fileContent, err := b.downloadFile(a.photos[i].FileID)
+			if err != nil {
+				return nil, err
+			}
+
+			photos = append(photos, pubsub.AlbumPhoto{
+				FileID:      a.photos[i].FileID,
+				FileURL:     a.photos[i].FileURL,
+				FileSize:    a.photos[i].FileSize,
+				FileContent: fileContent,
+			})
+		}
+
+		return easyjson.Marshal(pubsub.AlbumEvent{
+			Caption:      caption,
+			Photos:       photos,
+			Destinations: destinations,
+			Source:       a.source,
+		})
+	})
+	if err != nil {
+		eb, _ := easyjson.Marshal(pubsub.ErrorEvent{Err: err.Error()})
+		_ = b.q.Publish(pubsub.ErrorTopic.String(), message.NewMessage(watermill.NewUUID(), eb))
+	}
+}
+
+func (b *Bot) publishMedia(
+	to, caption string,
+	topic pubsub.TopicName,
+	edited bool,
+	event func(string, pubsub.Destinations) ([]byte, error),
+) error {
+	caption = strings.TrimSpace(caption)
 	if caption == "" {
 		return nil
 	}
 
-	fileReader, err := b.bot.GetFile(m.Photo.FileID)
+	destinations, caption := parseDestinations(caption)
+
+	var (
+		when      string
+		scheduled = strings.HasPrefix(caption, scheduleCommand+" ")
+	)
+
+	if scheduled {
+		var ok bool
+		if when, caption, ok = parseSchedule(strings.TrimPrefix(caption, scheduleCommand)); !ok {
+			return b.bot.Send(to, scheduleUsage)
+		}
+	}
+
+	if caption == "" {
+		return nil
+	}
+
+	mb, err := event(caption, destinations)
 	if err != nil {
 		return err
 	}
 
+	if scheduled && !edited {
+		return b.schedule(to, when, topic, caption, mb)
+	}
+
+	return b.q.Publish(topic.String(), message.NewMessage(watermill.NewUUID(), mb))
+}
+
+func (b *Bot) downloadFile(fileID string) ([]byte, error) {
+	fileReader, err := b.bot.GetFile(fileID)
+	if err != nil {
+		return nil, err
+	}
+
 	fileContent := new(bytes.Buffer)
 	_, _ = fileContent.ReadFrom(fileReader)
 
-	mb, _ := easyjson.Marshal(pubsub.PhotoEvent{
-		Caption:     caption,
-		FileID:      m.Photo.FileID,
-		FileURL:     m.Photo.FileURL,
-		FileSize:    m.Photo.FileSize,
-		FileContent: fileContent.Bytes(),
-	})
-
-	return b.q.Publish(pubsub.PhotoTopic.String(), message.NewMessage(watermill.NewUUID(), mb))
+	return fileContent.Bytes(), nil
 }
 
 func (b *Bot) handleText(m TelegramMessage) error {
@@ -71,7 +561,221 @@ func (b *Bot) handleText(m TelegramMessage) error {
 		return nil
 	}
 
-	mb, _ := easyjson.Marshal(pubsub.TextEvent{Text: msg})
+	return b.sendText(m.SenderID, draft{text: msg, entities: textEntities(m, msg), source: source(m.SenderID, m.ID)})
+}
+
+func (b *Bot) handleEdited(m TelegramMessage) error {
+	switch {
+	case m.Photo.AlbumID != "":
+		return b.bot.Send(m.SenderID, "Albums can't be edited, use /delete to remove them")
+	case m.Photo.FileID != "":
+		return b.handlePhoto(m)
+	case m.Video.FileID != "":
+		return b.handleVideo(m)
+	case m.Document.FileID != "":
+		return b.handleDocument(m)
+	}
+
+	destinations, text := parseDestinations(strings.TrimSpace(m.Text))
+	if strings.HasPrefix(text, scheduleCommand+" ") {
+		_, text, _ = parseSchedule(strings.TrimPrefix(text, scheduleCommand))
+	}
+
+	if text == "" || strings.HasPrefix(text, "/") {
+		return nil
+	}
+
+	return b.sendText(m.SenderID, draft{
+		text:         text,
+		entities:     textEntities(m, text),
+		destinations: destinations,
+		source:       source(m.SenderID, m.ID),
+		edit:         true,
+	})
+}
+
+func (b *Bot) sendText(to string, d draft) error {
+	if b.cfg.ConfirmPosts {
+		return b.previewText(to, d)
+	}
+
+	return b.publishText(d)
+}
+
+func (b *Bot) publishText(d draft) error {
+	mb, _ := easyjson.Marshal(textEvent(d))
 
 	return b.q.Publish(pubsub.TextTopic.String(), message.NewMessage(watermill.NewUUID(), mb))
 }
+
+func textEvent(d draft) pubsub.TextEvent {
+	e := pubsub.TextEvent{Text: d.text, Destinations: d.destinations, Source: d.source, Edit: d.edit}
+
+	for _, v := range d.entities {
+		e.Entities = append(e.Entities, pubsub.Entity{Type: v.Type, Offset: v.Offset, Length: v.Length, URL: v.URL})
+	}
+
+	return e
+}
+
+func textEntities(m TelegramMessage, text string) []TelegramEntity {
+	start := strings.LastIndex(m.Text, text)
+	if start < 0 {
+		return nil
+	}
+
+	from := len(utf16.Encode([]rune(m.Text[:start])))
+	to := from + len(utf16.Encode([]rune(text)))
+
+	var entities []TelegramEntity
+
+	for _, e := range m.Entities {
+		offset, end := max(e.Offset, from), min(e.Offset+e.Length, to)
+		if offset >= end {
+			continue
+		}
+
+		e.Offset, e.Length = offset-from, end-offset
+		entities = append(entities, e)
+	}
+
+	return entities
+}
+
+func (b *Bot) previewText(to string, d draft) error {
+	id := watermill.NewShortUUID()
+	d.createdAt = b.clk.Now()
+
+	b.mu.Lock()
+	b.expireDrafts()
+	b.drafts[id] = d
+	b.mu.Unlock()
+
+	var preview strings.Builder
+	if d.destinations.Includes(telegramHandler) {
+		writePreview(&preview, "Telegram", b.bot.Chunks(d.text))
+	}
+
+	if d.destinations.Includes(twitterHandler) {
+		writePreview(&preview, "Twitter", b.tc.Chunks(d.text))
+	}
+
+	if err := b.bot.Send(to, preview.String()); err != nil {
+		return err
+	}
+
+	buttons := TelegramButtons{{Unique: publishButton, Text: "Publish", Data: id}}
+	if len(d.destinations) == 0 {
+		buttons = append(
+			buttons,
+			TelegramButton{Unique: telegramButton, Text: "Telegram only", Data: id},
+			TelegramButton{Unique: twitterButton, Text: "Twitter only", Data: id},
+		)
+	}
+
+	question := "Do you want to publish this post?"
+	if d.edit {
+		question = "Do you want to publish this edit?"
+	}
+
+	return b.bot.Send(to, question, append(
+		buttons,
+		TelegramButton{Unique: editButton, Text: "Edit", Data: id},
+		TelegramButton{Unique: cancelButton, Text: "Cancel", Data: id},
+	))
+}
+
+func writePreview(sb *strings.Builder, destination string, chunks []string) {
+	_, _ = fmt.Fprintf(sb, "%s (%d messages):\n", destination, len(chunks))
+
+	for i := range chunks {
+		_, _ = fmt.Fprintf(sb, "[%d/%d] %s\n", i+1, len(chunks), chunks[i])
+	}
+}
+
+func (b *Bot) handlePublishCallback(c TelegramCallback) error {
+	return b.publishDraft(c, nil)
+}
+
+func (b *Bot) handlePublishTelegramCallback(c TelegramCallback) error {
+	return b.publishDraft(c, pubsub.Destinations{telegramHandler})
+}
+
+func (b *Bot) handlePublishTwitterCallback(c TelegramCallback) error {
+	return b.publishDraft(c, pubsub.Destinations{twitterHandler})
+}
+
+func (b *Bot) publishDraft(c TelegramCallback, destinations pubsub.Destinations) error {
+	d, ok := b.takeDraft(c.Data)
+	if !ok {
+		return b.closeDraft(c, "Post not found")
+	}
+
+	if len(destinations) > 0 {
+		d.destinations = destinations
+	}
+
+	if err := b.publishText(d); err != nil {
+		return err
+	}
+
+	return b.closeDraft(c, "Post published")
+}
+
+func (b *Bot) handleEditCallback(c TelegramCallback) error {
+	d, ok := b.takeDraft(c.Data)
+	if !ok {
+		return b.closeDraft(c, "Post not found")
+	}
+
+	if err := b.closeDraft(c, "Send the new text of the post"); err != nil {
+		return err
+	}
+
+	if len(d.entities) > 0 {
+		return b.bot.Send(c.SenderID, TelegramText{Text: d.text, Entities: d.entities})
+	}
+
+	return b.bot.Send(c.SenderID, d.text)
+}
+
+func (b *Bot) handleCancelCallback(c TelegramCallback) error {
+	if _, ok := b.takeDraft(c.Data); !ok {
+		return b.closeDraft(c, "Post not found")
+	}
+
+	return b.closeDraft(c, "Post cancelled")
+}
+
+// closeDraft answers the callback and replaces the question of the preview, removing its buttons.
+func (b *Bot) closeDraft(c TelegramCallback, text string) error {
+	if c.MessageID != "" {
+		if _, err := b.bot.Edit(c.SenderID, []string{c.MessageID}, text); err != nil {
+			return err
+		}
+	}
+
+	return b.bot.Respond(c.ID, text)
+}
+
+func (b *Bot) takeDraft(id string) (draft, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expireDrafts()
+
+	d, ok := b.drafts[id]
+	delete(b.drafts, id)
+
+	return d, ok
+}
+
+func (b *Bot) expireDrafts() {
+	now := b.clk.Now()
+
+	for id, d := range b.drafts {
+		if now.Sub(d.createdAt) > draftTTL {
+			delete(b.drafts, id)
+		}
+	}
+}
@@ -1,13 +1,22 @@
 package bot_test
 
 import (
+	"io"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/quintodown/quintodownbot/internal/posts"
 	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/scheduler"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	"github.com/quintodown/quintodownbot/internal/webhook"
+	"github.com/quintodown/quintodownbot/mocks/clock"
 	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/require"
 
 	"github.com/quintodown/quintodownbot/internal/bot"
 	"github.com/quintodown/quintodownbot/internal/config"
@@ -34,6 +43,12 @@ func (m downloadImageError) Error() string {
 	return "error downloading image"
 }
 
+type historyError struct{}
+
+func (h historyError) Error() string {
+	return "error reading history"
+}
+
 func TestHandlerStartAndHelpCommand(t *testing.T) {
 	commands := []struct {
 		command  string
@@ -81,8 +96,19 @@ func TestHandlerStartAndHelpCommand(t *testing.T) {
 	t.Run("it should send admin commands when user admin", func(t *testing.T) {
 		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/help", config.AppConfig{Admins: []int{1234}})
 		m := bot.TelegramMessage{IsPrivate: true, SenderID: "1234"}
-		expected := "/help - Show help\n/start - Start a conversation with the bot\n/stop - Stop notifications" +
-			" for all handlers or specific handler\n"
+		expected := "/deadletters - List messages that couldn't be delivered after retrying\n" +
+			"/delete - Delete a published post replying to it\n" +
+			"/discard - Discard a message that couldn't be delivered\n/help - Show help\n" +
+			"/history - List the latest publications, 10 unless a number is given\n" +
+			"/replay - Send again a message that couldn't be delivered\n" +
+			"/resume - Resume notifications for all handlers or specific handler\n" +
+			"/schedule - Schedule a post to be published later\n" +
+			"/scheduled - List posts pending to be published\n" +
+			"/start - Start a conversation with the bot\n/status - Show which handlers are paused\n" +
+			"/stop - Stop notifications for all handlers or specific handler\n" +
+			"/telegram - Publish a post only in the Telegram channel\n/twitter - Publish a post only in Twitter\n" +
+			"/unschedule - Remove a post pending to be published\n" +
+			"/webhook - Add, list or remove the webhooks notified of every event\n"
 		mockedBot.On("Send", m.SenderID, expected).Once().Return(nil, nil)
 
 		_ = handler(m)
@@ -203,6 +229,418 @@ func TestHandlerPhoto(t *testing.T) {
 	})
 }
 
+func TestHandlerAlbum(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}, AlbumWindow: 10 * time.Millisecond}
+	sender := strconv.Itoa(adminID)
+	photos := []bot.TelegramMessage{
+		{
+			IsPrivate: true,
+			SenderID:  sender,
+			Photo:     bot.TelegramPhoto{Caption: "/twitter testing", FileID: "1", AlbumID: "album"},
+		},
+		{IsPrivate: true, SenderID: sender, Photo: bot.TelegramPhoto{FileID: "2", AlbumID: "album"}},
+	}
+
+	t.Run("it should publish album photos in a single event", func(t *testing.T) {
+		handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, tb.OnPhoto, cfg)
+		published := make(chan struct{})
+
+		mockedBot.On("GetFile", "1").Once().Return(io.NopCloser(strings.NewReader("photo1")), nil)
+		mockedBot.On("GetFile", "2").Once().Return(io.NopCloser(strings.NewReader("photo2")), nil)
+		mockedQueue.On("Publish", pubsub.AlbumTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"caption\":\"testing\",\"photos\":["+
+				"{\"fileId\":\"1\",\"fileUrl\":\"\",\"fileSize\":0,\"fileContent\":\"cGhvdG8x\"},"+
+				"{\"fileId\":\"2\",\"fileUrl\":\"\",\"fileSize\":0,\"fileContent\":\"cGhvdG8y\"}],"+
+				"\"destinations\":[\"twitter\"]}"
+		})).Once().Return(nil).Run(func(mock.Arguments) { close(published) })
+
+		for _, m := range photos {
+			require.NoError(t, handler(m))
+		}
+
+		require.Eventually(t, func() bool {
+			<-published
+
+			return true
+		}, time.Second, time.Millisecond)
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should send an error when album photos couldn't be downloaded", func(t *testing.T) {
+		handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, tb.OnPhoto, cfg)
+		published := make(chan struct{})
+
+		mockedBot.On("GetFile", "1").Once().Return(nil, downloadImageError{})
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.Anything).
+			Once().
+			Return(nil).
+			Run(func(mock.Arguments) { close(published) })
+
+		for _, m := range photos {
+			require.NoError(t, handler(m))
+		}
+
+		require.Eventually(t, func() bool {
+			<-published
+
+			return true
+		}, time.Second, time.Millisecond)
+		mockedQueue.AssertNotCalled(t, "Publish", pubsub.AlbumTopic.String(), mock.Anything)
+	})
+}
+
+func TestHandlerVideo(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	m := bot.TelegramMessage{
+		IsPrivate: true,
+		SenderID:  strconv.Itoa(adminID),
+		Video: bot.TelegramVideo{
+			Caption:   "goal",
+			FileID:    "video",
+			FileSize:  1234,
+			MimeType:  "video/mp4",
+			Animation: true,
+		},
+	}
+
+	t.Run("it should fail when video couldn't be downloaded", func(t *testing.T) {
+		handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, tb.OnAnimation, cfg)
+		mockedBot.On("GetFile", "video").Once().Return(nil, downloadImageError{})
+
+		require.ErrorIs(t, handler(m), downloadImageError{})
+
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should publish video event", func(t *testing.T) {
+		handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, tb.OnAnimation, cfg)
+		mockedBot.On("GetFile", "video").Once().Return(io.NopCloser(strings.NewReader("video")), nil)
+		mockedQueue.On("Publish", pubsub.VideoTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"caption\":\"goal\",\"fileId\":\"video\",\"fileUrl\":\"\","+
+				"\"fileSize\":1234,\"fileContent\":\"dmlkZW8=\",\"mimeType\":\"video/mp4\",\"animation\":true}"
+		})).Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertExpectations(t)
+	})
+}
+
+func TestHandlerDocument(t *testing.T) {
+	handler, _, mockedQueue := generateHandlerAndMockedBot(t, tb.OnDocument, config.AppConfig{Admins: []int{adminID}})
+
+	mockedQueue.On("Publish", pubsub.DocumentTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+		return string(m.Payload) == "{\"caption\":\"rules\",\"fileId\":\"document\",\"fileUrl\":\"\","+
+			"\"fileSize\":1234,\"fileName\":\"rules.pdf\",\"mimeType\":\"application/pdf\","+
+			"\"destinations\":[\"telegram\"]}"
+	})).Once().Return(nil)
+
+	require.NoError(t, handler(bot.TelegramMessage{
+		IsPrivate: true,
+		SenderID:  strconv.Itoa(adminID),
+		Document: bot.TelegramDocument{
+			Caption:  "/telegram rules",
+			FileID:   "document",
+			FileSize: 1234,
+			FileName: "rules.pdf",
+			MimeType: "application/pdf",
+		},
+	}))
+
+	mockedQueue.AssertExpectations(t)
+}
+
+func TestHandlerScheduledPhoto(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	m := bot.TelegramMessage{
+		IsPrivate: true,
+		SenderID:  strconv.Itoa(adminID),
+		Photo: bot.TelegramPhoto{
+			Caption:  "/schedule +1h testing",
+			FileID:   "blablabla",
+			FileURL:  "https://myimage.com/test.jpg",
+			FileSize: 1234,
+		},
+	}
+
+	t.Run("it should show usage when scheduled photo has no caption", func(t *testing.T) {
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, tb.OnPhoto, cfg, bot.WithScheduler(sc))
+		mockedBot.On("Send", m.SenderID, "Usage: /schedule <RFC3339 time|+duration> <text>").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			IsPrivate: true,
+			SenderID:  strconv.Itoa(adminID),
+			Photo:     bot.TelegramPhoto{Caption: "/schedule +1h"},
+		}))
+
+		mockedBot.AssertExpectations(t)
+		sc.AssertNotCalled(t, "Schedule", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should schedule photo when caption starts with schedule command", func(t *testing.T) {
+		file, _ := os.Open("testdata/test.png")
+		defer func() { _ = file.Close() }()
+
+		sc := new(mb.Scheduler)
+		handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, tb.OnPhoto, cfg, bot.WithScheduler(sc))
+		mockedBot.On("GetFile", m.Photo.FileID).Once().Return(file, nil)
+		sc.On("Schedule", "+1h", pubsub.PhotoTopic, "testing", []byte(imagePayload)).
+			Once().
+			Return(scheduler.Job{ID: "3", PublishAt: time.Date(2021, 10, 5, 21, 0, 0, 0, time.UTC)}, nil)
+		mockedBot.On("Send", m.SenderID, "Post 3 scheduled for 2021-10-05T21:00:00Z").Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		sc.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should schedule photo only for telegram when caption starts with telegram command", func(t *testing.T) {
+		file, _ := os.Open("testdata/test.png")
+		defer func() { _ = file.Close() }()
+
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, tb.OnPhoto, cfg, bot.WithScheduler(sc))
+		mockedBot.On("GetFile", m.Photo.FileID).Once().Return(file, nil)
+		sc.On(
+			"Schedule",
+			"+1h",
+			pubsub.PhotoTopic,
+			"testing",
+			[]byte(strings.TrimSuffix(imagePayload, "}")+",\"destinations\":[\"telegram\"]}"),
+		).Once().Return(scheduler.Job{ID: "4", PublishAt: time.Date(2021, 10, 5, 21, 0, 0, 0, time.UTC)}, nil)
+		mockedBot.On("Send", m.SenderID, "Post 4 scheduled for 2021-10-05T21:00:00Z").Once().Return(nil)
+
+		photo := m
+		photo.Photo.Caption = "/telegram /schedule +1h testing"
+
+		require.NoError(t, handler(photo))
+
+		sc.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+}
+
+func TestHandleSchedule(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	m := bot.TelegramMessage{IsPrivate: true, SenderID: strconv.Itoa(adminID), Payload: "+30m testing message"}
+
+	t.Run("it should show usage when text is missing", func(t *testing.T) {
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/schedule", cfg, bot.WithScheduler(sc))
+		mockedBot.On("Send", m.SenderID, "Usage: /schedule <RFC3339 time|+duration> <text>").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: m.SenderID, Payload: "+30m"}))
+
+		mockedBot.AssertExpectations(t)
+		sc.AssertNotCalled(t, "Schedule", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should notify when time is not valid", func(t *testing.T) {
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/schedule", cfg, bot.WithScheduler(sc))
+		sc.On("Schedule", "+30m", pubsub.TextTopic, "testing message", mock.Anything).
+			Once().
+			Return(scheduler.Job{}, scheduler.ErrPastTime)
+		mockedBot.On("Send", m.SenderID, "Couldn't schedule post: time must be in the future").Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		sc.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should fail when post couldn't be scheduled", func(t *testing.T) {
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/schedule", cfg, bot.WithScheduler(sc))
+		sc.On("Schedule", "+30m", pubsub.TextTopic, "testing message", mock.Anything).
+			Once().
+			Return(scheduler.Job{}, storage.ErrNotFound)
+
+		require.ErrorIs(t, handler(m), storage.ErrNotFound)
+
+		sc.AssertExpectations(t)
+		mockedBot.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should schedule text post only for twitter", func(t *testing.T) {
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/schedule", cfg, bot.WithScheduler(sc))
+		sc.On(
+			"Schedule",
+			"+30m",
+			pubsub.TextTopic,
+			"testing message",
+			[]byte("{\"text\":\"testing message\",\"destinations\":[\"twitter\"]}"),
+		).Once().Return(scheduler.Job{ID: "2", PublishAt: time.Date(2021, 10, 5, 20, 30, 0, 0, time.UTC)}, nil)
+		mockedBot.On("Send", m.SenderID, "Post 2 scheduled for 2021-10-05T20:30:00Z").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			IsPrivate: true,
+			SenderID:  m.SenderID,
+			Payload:   "+30m /twitter testing message",
+		}))
+
+		sc.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should schedule text post", func(t *testing.T) {
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/schedule", cfg, bot.WithScheduler(sc))
+		sc.On("Schedule", "+30m", pubsub.TextTopic, "testing message", []byte("{\"text\":\"testing message\"}")).
+			Once().
+			Return(scheduler.Job{ID: "1", PublishAt: time.Date(2021, 10, 5, 20, 30, 0, 0, time.UTC)}, nil)
+		mockedBot.On("Send", m.SenderID, "Post 1 scheduled for 2021-10-05T20:30:00Z").Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		sc.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+}
+
+func TestHandleDestinationCommands(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	sender := strconv.Itoa(adminID)
+
+	t.Run("it should show usage when text is missing", func(t *testing.T) {
+		handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, "/twitter", cfg)
+		mockedBot.On("Send", sender, "Usage: /twitter <text>").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, Payload: " "}))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should keep entities of the text after the command", func(t *testing.T) {
+		handler, _, mockedQueue := generateHandlerAndMockedBot(t, "/telegram", cfg)
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"text\":\"😀 goal\",\"entities\":[{\"type\":\"bold\",\"offset\":3,"+
+				"\"length\":4}],\"destinations\":[\"telegram\"]}"
+		})).Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			IsPrivate: true,
+			SenderID:  sender,
+			Text:      "/telegram 😀 goal",
+			Entities: []bot.TelegramEntity{
+				{Type: "bot_command", Offset: 0, Length: 9},
+				{Type: "bold", Offset: 13, Length: 4},
+			},
+			Payload: "😀 goal",
+		}))
+
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should publish text only in telegram", func(t *testing.T) {
+		handler, _, mockedQueue := generateHandlerAndMockedBot(t, "/telegram", cfg)
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"text\":\"testing message\",\"destinations\":[\"telegram\"]}"
+		})).Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, Payload: "testing message"}))
+
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should preview text only for twitter when confirmation is enabled", func(t *testing.T) {
+		tc := new(mb.TwitterClient)
+		tc.On("Chunks", "testing message").Once().Return([]string{"testing message"})
+
+		handler, callbacks, mockedBot, mockedQueue := generateConfirmationBot(t, "/twitter", bot.WithTwitterClient(tc))
+
+		var draftID string
+
+		mockedBot.On("Send", sender, "Twitter (1 messages):\n[1/1] testing message\n").Once().Return(nil)
+		mockedBot.On("Send", sender, "Do you want to publish this post?", mock.MatchedBy(func(b bot.TelegramButtons) bool {
+			draftID = b[0].Data
+
+			return len(b) == 3 && b[0].Unique == "publish" && b[1].Unique == "edit" && b[2].Unique == "cancel"
+		})).Once().Return(nil)
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"text\":\"testing message\",\"destinations\":[\"twitter\"]}"
+		})).Once().Return(nil)
+		mockedBot.On("Respond", "1", "Post published").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, Payload: "testing message"}))
+		require.NoError(t, callbacks["publish"](bot.TelegramCallback{ID: "1", SenderID: sender, Data: draftID}))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertNotCalled(t, "Chunks", mock.Anything)
+	})
+}
+
+func TestHandleScheduled(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	m := bot.TelegramMessage{IsPrivate: true, SenderID: strconv.Itoa(adminID)}
+
+	t.Run("it should notify when there are no scheduled posts", func(t *testing.T) {
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/scheduled", cfg, bot.WithScheduler(sc))
+		sc.On("List").Once().Return(nil, nil)
+		mockedBot.On("Send", m.SenderID, "There are no scheduled posts").Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should list scheduled posts", func(t *testing.T) {
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/scheduled", cfg, bot.WithScheduler(sc))
+		sc.On("List").Once().Return([]scheduler.Job{
+			{ID: "1", Topic: "TextTopic", Text: "first", PublishAt: time.Date(2021, 10, 5, 20, 30, 0, 0, time.UTC)},
+			{ID: "2", Topic: "PhotoTopic", Text: "second", PublishAt: time.Date(2021, 10, 6, 9, 0, 0, 0, time.UTC)},
+		}, nil)
+		mockedBot.On(
+			"Send",
+			m.SenderID,
+			"1 - 2021-10-05T20:30:00Z TextTopic: first\n2 - 2021-10-06T09:00:00Z PhotoTopic: second\n",
+		).Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		mockedBot.AssertExpectations(t)
+	})
+}
+
+func TestHandleUnschedule(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	m := bot.TelegramMessage{IsPrivate: true, SenderID: strconv.Itoa(adminID), Payload: "4"}
+
+	t.Run("it should notify when scheduled post is not found", func(t *testing.T) {
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/unschedule", cfg, bot.WithScheduler(sc))
+		sc.On("Unschedule", "4").Once().Return(storage.ErrNotFound)
+		mockedBot.On("Send", m.SenderID, "Scheduled post 4 not found").Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		sc.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should unschedule post", func(t *testing.T) {
+		sc := new(mb.Scheduler)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/unschedule", cfg, bot.WithScheduler(sc))
+		sc.On("Unschedule", "4").Once().Return(nil)
+		mockedBot.On("Send", m.SenderID, "Scheduled post 4 unscheduled").Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		sc.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+}
+
 func TestHandlerText(t *testing.T) {
 	handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, tb.OnText, config.AppConfig{
 		Admins:           []int{adminID},
@@ -240,6 +678,421 @@ func TestHandlerText(t *testing.T) {
 		mockedBot.AssertExpectations(t)
 		mockedQueue.AssertExpectations(t)
 	})
+
+	t.Run("it should send the source of the text", func(t *testing.T) {
+		mockedQueue.On(
+			"Publish",
+			pubsub.TextTopic.String(),
+			mock.MatchedBy(func(message *message.Message) bool {
+				return string(message.Payload) == "{\"text\":\"testing\",\"source\":\"12345:7\"}"
+			}),
+		).Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			ID:        "7",
+			IsPrivate: true,
+			SenderID:  strconv.Itoa(adminID),
+			Text:      "testing",
+		}))
+
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should send text entities when present", func(t *testing.T) {
+		m := bot.TelegramMessage{
+			IsPrivate: true,
+			SenderID:  strconv.Itoa(adminID),
+			Text:      "testing link",
+			Entities:  []bot.TelegramEntity{{Type: "text_link", Offset: 8, Length: 4, URL: "https://quintodown.com"}},
+		}
+		mockedQueue.On(
+			"Publish",
+			pubsub.TextTopic.String(),
+			mock.MatchedBy(func(message *message.Message) bool {
+				return string(message.Payload) == "{\"text\":\"testing link\",\"entities\":[{\"type\":\"text_link\","+
+					"\"offset\":8,\"length\":4,\"url\":\"https://quintodown.com\"}]}"
+			}),
+		).Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		mockedQueue.AssertExpectations(t)
+	})
+}
+
+func TestHandleEdited(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	sender := strconv.Itoa(adminID)
+
+	t.Run("it should publish the new text of the post", func(t *testing.T) {
+		handler, _, mockedQueue := generateHandlerAndMockedBot(t, tb.OnEdited, cfg)
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"text\":\"new text\",\"entities\":[{\"type\":\"bold\",\"offset\":0,"+
+				"\"length\":3}],\"destinations\":[\"twitter\"],\"source\":\"12345:7\",\"edit\":true}"
+		})).Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			ID:        "7",
+			IsPrivate: true,
+			SenderID:  sender,
+			Text:      "/twitter new text",
+			Entities:  []bot.TelegramEntity{{Type: "bold", Offset: 9, Length: 3}},
+			Edited:    true,
+		}))
+
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should publish the post right away when a scheduled post is edited", func(t *testing.T) {
+		handler, _, mockedQueue := generateHandlerAndMockedBot(t, tb.OnEdited, cfg)
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"text\":\"new text\",\"source\":\"12345:7\",\"edit\":true}"
+		})).Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			ID:        "7",
+			IsPrivate: true,
+			SenderID:  sender,
+			Text:      "/schedule +1h new text",
+			Edited:    true,
+		}))
+
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should preview the new text when confirmation is enabled", func(t *testing.T) {
+		tc := new(mb.TwitterClient)
+		tc.On("Chunks", "new text").Once().Return([]string{"new text"})
+
+		handler, callbacks, mockedBot, mockedQueue := generateConfirmationBot(t, tb.OnEdited, bot.WithTwitterClient(tc))
+
+		var draftID string
+
+		mockedBot.On("Send", sender, "Twitter (1 messages):\n[1/1] new text\n").Once().Return(nil)
+		mockedBot.On("Send", sender, "Do you want to publish this edit?", mock.MatchedBy(func(b bot.TelegramButtons) bool {
+			draftID = b[0].Data
+
+			return len(b) == 3
+		})).Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			ID:        "7",
+			IsPrivate: true,
+			SenderID:  sender,
+			Text:      "/twitter new text",
+			Edited:    true,
+		}))
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"text\":\"new text\",\"destinations\":[\"twitter\"],"+
+				"\"source\":\"12345:7\",\"edit\":true}"
+		})).Once().Return(nil)
+		mockedBot.On("Respond", "1", "Post published").Once().Return(nil)
+
+		require.NoError(t, callbacks["publish"](bot.TelegramCallback{ID: "1", SenderID: sender, Data: draftID}))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should ignore edited commands", func(t *testing.T) {
+		handler, _, mockedQueue := generateHandlerAndMockedBot(t, tb.OnEdited, cfg)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			ID:        "7",
+			IsPrivate: true,
+			SenderID:  sender,
+			Text:      "/status",
+			Edited:    true,
+		}))
+
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should publish the new caption of a document", func(t *testing.T) {
+		handler, _, mockedQueue := generateHandlerAndMockedBot(t, tb.OnEdited, cfg)
+		mockedQueue.On("Publish", pubsub.DocumentTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"caption\":\"rules\",\"fileId\":\"document\",\"fileUrl\":\"\","+
+				"\"fileSize\":1234,\"fileName\":\"rules.pdf\",\"mimeType\":\"application/pdf\","+
+				"\"source\":\"12345:8\",\"edit\":true}"
+		})).Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			ID:        "8",
+			IsPrivate: true,
+			SenderID:  sender,
+			Document: bot.TelegramDocument{
+				Caption:  "/schedule +1h rules",
+				FileID:   "document",
+				FileSize: 1234,
+				FileName: "rules.pdf",
+				MimeType: "application/pdf",
+			},
+			Edited: true,
+		}))
+
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should not edit albums", func(t *testing.T) {
+		handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, tb.OnEdited, cfg)
+		mockedBot.On("Send", sender, "Albums can't be edited, use /delete to remove them").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			ID:        "9",
+			IsPrivate: true,
+			SenderID:  sender,
+			Photo:     bot.TelegramPhoto{Caption: "goal", FileID: "photo", AlbumID: "album"},
+			Edited:    true,
+		}))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandleDelete(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	sender := strconv.Itoa(adminID)
+
+	t.Run("it should show usage when not replying to a post", func(t *testing.T) {
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/delete", cfg)
+		mockedBot.On("Send", sender, "Usage: reply to the message of a post with /delete").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender}))
+
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should tell when post is not found", func(t *testing.T) {
+		ps := new(mb.Posts)
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+
+		handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, "/delete", cfg, bot.WithPosts(ps))
+		mockedBot.On("Send", sender, "Post not found").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, ReplyTo: "7"}))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should delete the post", func(t *testing.T) {
+		ps := new(mb.Posts)
+		ps.On("Get", "12345:7").Once().Return(posts.Post{Source: "12345:7"}, nil)
+
+		handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, "/delete", cfg, bot.WithPosts(ps))
+		mockedQueue.On("Publish", pubsub.DeleteTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"source\":\"12345:7\"}"
+		})).Once().Return(nil)
+		mockedBot.On("Send", sender, "Post deleted").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, ReplyTo: "7"}))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertExpectations(t)
+	})
+}
+
+func TestHandleHistory(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	sender := strconv.Itoa(adminID)
+
+	t.Run("it should show usage when the number is not valid", func(t *testing.T) {
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/history", cfg)
+		mockedBot.On("Send", sender, "Usage: /history [n]").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, Payload: "-1"}))
+
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should fail when history couldn't be read", func(t *testing.T) {
+		ps := new(mb.Posts)
+		ps.On("History", 10).Once().Return(nil, historyError{})
+
+		handler, _, _ := generateHandlerAndMockedBot(t, "/history", cfg, bot.WithPosts(ps))
+
+		require.ErrorIs(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender}), historyError{})
+	})
+
+	t.Run("it should tell when nothing was published", func(t *testing.T) {
+		ps := new(mb.Posts)
+		ps.On("History", 10).Once().Return(nil, nil)
+
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/history", cfg, bot.WithPosts(ps))
+		mockedBot.On("Send", sender, "There are no publications").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender}))
+
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should list the latest publications", func(t *testing.T) {
+		at := time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC)
+		ps := new(mb.Posts)
+		ps.On("History", 2).Once().Return([]posts.Publication{
+			{ID: "4", Source: "12345:7", Destination: "twitter", Action: posts.Edited, Messages: []string{"1445"}, At: at},
+			{
+				ID:          "3",
+				Source:      "12345:7",
+				Destination: "telegram",
+				Action:      posts.Published,
+				Messages:    []string{"10", "11"},
+				At:          at,
+			},
+		}, nil)
+
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/history", cfg, bot.WithPosts(ps))
+		mockedBot.On("Send", sender, "4 - 2021-10-05T20:00:00Z twitter edited 12345:7: 1445\n"+
+			"3 - 2021-10-05T20:00:00Z telegram published 12345:7: 10, 11\n").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, Payload: "2"}))
+
+		mockedBot.AssertExpectations(t)
+	})
+}
+
+func TestHandlerTextConfirmation(t *testing.T) {
+	sender := strconv.Itoa(adminID)
+	m := bot.TelegramMessage{IsPrivate: true, SenderID: sender, Text: "testing message"}
+	preview := "Telegram (1 messages):\n[1/1] testing message\nTwitter (2 messages):\n[1/2] testing...\n[2/2] message\n"
+
+	previewPost := func(t *testing.T) (map[string]bot.TelegramCallbackHandler, *mb.TelegramBot, *mq.Queue, string) {
+		t.Helper()
+
+		var draftID string
+
+		tc := new(mb.TwitterClient)
+		tc.On("Chunks", "testing message").Once().Return([]string{"testing...", "message"})
+
+		handler, callbacks, mockedBot, mockedQueue := generateConfirmationBot(t, tb.OnText, bot.WithTwitterClient(tc))
+		mockedBot.On("Chunks", "testing message").Once().Return([]string{"testing message"})
+		mockedBot.On("Send", sender, preview).Once().Return(nil)
+		mockedBot.On("Send", sender, "Do you want to publish this post?", mock.MatchedBy(func(b bot.TelegramButtons) bool {
+			draftID = b[0].Data
+
+			return len(b) == 5 && b[0].Unique == "publish" && b[1].Unique == "publishtelegram" &&
+				b[2].Unique == "publishtwitter" && b[3].Unique == "edit" && b[4].Unique == "cancel" &&
+				b[1].Data == draftID && b[4].Data == draftID
+		})).Once().Return(nil)
+
+		require.NoError(t, handler(m))
+		require.NotEmpty(t, draftID)
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+
+		return callbacks, mockedBot, mockedQueue, draftID
+	}
+
+	t.Run("it should publish post after confirmation", func(t *testing.T) {
+		callbacks, mockedBot, mockedQueue, draftID := previewPost(t)
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"text\":\"testing message\"}"
+		})).Once().Return(nil)
+		mockedBot.On("Respond", "1", "Post published").Once().Return(nil)
+
+		require.NoError(t, callbacks["publish"](bot.TelegramCallback{ID: "1", SenderID: sender, Data: draftID}))
+
+		mockedBot.On("Respond", "2", "Post not found").Once().Return(nil)
+		require.NoError(t, callbacks["publish"](bot.TelegramCallback{ID: "2", SenderID: sender, Data: draftID}))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should publish post only in twitter after confirmation", func(t *testing.T) {
+		callbacks, mockedBot, mockedQueue, draftID := previewPost(t)
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"text\":\"testing message\",\"destinations\":[\"twitter\"]}"
+		})).Once().Return(nil)
+		mockedBot.On("Respond", "1", "Post published").Once().Return(nil)
+
+		require.NoError(t, callbacks["publishtwitter"](bot.TelegramCallback{ID: "1", SenderID: sender, Data: draftID}))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should send back the text when editing post", func(t *testing.T) {
+		callbacks, mockedBot, mockedQueue, draftID := previewPost(t)
+		mockedBot.On("Respond", "1", "Send the new text of the post").Once().Return(nil)
+		mockedBot.On("Send", sender, "testing message").Once().Return(nil)
+
+		require.NoError(t, callbacks["edit"](bot.TelegramCallback{ID: "1", SenderID: sender, Data: draftID}))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should cancel post", func(t *testing.T) {
+		callbacks, mockedBot, mockedQueue, draftID := previewPost(t)
+		mockedBot.On("Respond", "1", "Post cancelled").Once().Return(nil)
+
+		require.NoError(t, callbacks["cancel"](bot.TelegramCallback{ID: "1", SenderID: sender, Data: draftID}))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should remove the buttons of the preview once it's handled", func(t *testing.T) {
+		callbacks, mockedBot, mockedQueue, draftID := previewPost(t)
+		mockedBot.On("Edit", sender, []string{"56"}, "Post cancelled").Once().Return([]string{"56"}, nil)
+		mockedBot.On("Respond", "1", "Post cancelled").Once().Return(nil)
+
+		c := bot.TelegramCallback{ID: "1", SenderID: sender, MessageID: "56", Data: draftID}
+		require.NoError(t, callbacks["cancel"](c))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should expire abandoned previews", func(t *testing.T) {
+		now := time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC)
+		clk := new(clock.Clock)
+		clk.On("Now").Twice().Return(now)
+
+		tc := new(mb.TwitterClient)
+		tc.On("Chunks", "testing message").Once().Return([]string{"testing...", "message"})
+
+		handler, callbacks, mockedBot, mockedQueue := generateConfirmationBot(
+			t,
+			tb.OnText,
+			bot.WithTwitterClient(tc),
+			bot.WithClock(clk),
+		)
+
+		var draftID string
+
+		mockedBot.On("Chunks", "testing message").Once().Return([]string{"testing message"})
+		mockedBot.On("Send", sender, preview).Once().Return(nil)
+		mockedBot.On("Send", sender, "Do you want to publish this post?", mock.MatchedBy(func(b bot.TelegramButtons) bool {
+			draftID = b[0].Data
+
+			return true
+		})).Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		clk.On("Now").Once().Return(now.Add(time.Hour + time.Minute))
+		mockedBot.On("Edit", sender, []string{"56"}, "Post not found").Once().Return([]string{"56"}, nil)
+		mockedBot.On("Respond", "1", "Post not found").Once().Return(nil)
+
+		c := bot.TelegramCallback{ID: "1", SenderID: sender, MessageID: "56", Data: draftID}
+		require.NoError(t, callbacks["publish"](c))
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should ignore callbacks from non admin users", func(t *testing.T) {
+		callbacks, mockedBot, mockedQueue, draftID := previewPost(t)
+
+		require.NoError(t, callbacks["publish"](bot.TelegramCallback{ID: "1", SenderID: "1", Data: draftID}))
+
+		mockedBot.AssertNotCalled(t, "Respond", mock.Anything, mock.Anything)
+		mockedQueue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
 }
 
 func TestHandleStopNotifications(t *testing.T) {
@@ -278,12 +1131,306 @@ func TestHandleStopNotifications(t *testing.T) {
 	})
 }
 
+func TestHandleResumeNotifications(t *testing.T) {
+	handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, "/resume", config.AppConfig{
+		Admins:           []int{adminID},
+		BroadcastChannel: broadcastChannel,
+	})
+
+	t.Run("it should send a start command event", func(t *testing.T) {
+		mockedQueue.On("Publish", pubsub.CommandTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"command\":1,\"handler\":\"\"}"
+		})).Once().Return(nil)
+		_ = handler(bot.TelegramMessage{
+			IsPrivate: true,
+			SenderID:  strconv.Itoa(adminID),
+			Text:      "/resume",
+		})
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should send a start command event to particular handle", func(t *testing.T) {
+		mockedQueue.On("Publish", pubsub.CommandTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"command\":1,\"handler\":\"twitter\"}"
+		})).Once().Return(nil)
+		_ = handler(bot.TelegramMessage{
+			IsPrivate: true,
+			SenderID:  strconv.Itoa(adminID),
+			Text:      "/resume twitter",
+			Payload:   "twitter",
+		})
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertExpectations(t)
+	})
+}
+
+func TestHandleStatus(t *testing.T) {
+	handler, mockedBot, mockedQueue := generateHandlerAndMockedBot(t, "/status", config.AppConfig{
+		Admins:           []int{adminID},
+		BroadcastChannel: broadcastChannel,
+	})
+
+	t.Run("it should send a status command event replying to sender", func(t *testing.T) {
+		mockedQueue.On("Publish", pubsub.CommandTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"command\":2,\"handler\":\"\",\"replyTo\":\""+strconv.Itoa(adminID)+"\"}"
+		})).Once().Return(nil)
+		_ = handler(bot.TelegramMessage{
+			IsPrivate: true,
+			SenderID:  strconv.Itoa(adminID),
+			Text:      "/status",
+		})
+
+		mockedBot.AssertExpectations(t)
+		mockedQueue.AssertExpectations(t)
+	})
+}
+
+func TestHandleDeadLetters(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	m := bot.TelegramMessage{IsPrivate: true, SenderID: strconv.Itoa(adminID)}
+
+	t.Run("it should fail when dead letters couldn't be listed", func(t *testing.T) {
+		dl := new(mb.DeadLetters)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/deadletters", cfg, bot.WithDeadLetters(dl))
+		dl.On("List").Once().Return(nil, storage.ErrNotFound)
+
+		require.ErrorIs(t, handler(m), storage.ErrNotFound)
+
+		dl.AssertExpectations(t)
+		mockedBot.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should notify when there are no dead letters", func(t *testing.T) {
+		dl := new(mb.DeadLetters)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/deadletters", cfg, bot.WithDeadLetters(dl))
+		dl.On("List").Once().Return([]pubsub.DeadLetterEvent{}, nil)
+		mockedBot.On("Send", m.SenderID, "There are no dead letters").Once().Return(nil)
+
+		require.NoError(t, handler(m))
+
+		dl.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should list dead letters", func(t *testing.T) {
+		dl := new(mb.DeadLetters)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/deadletters", cfg, bot.WithDeadLetters(dl))
+		dl.On("List").Once().Return([]pubsub.DeadLetterEvent{
+			{
+				ID:       "1",
+				Handler:  "twitter",
+				Topic:    pubsub.TextTopic.String(),
+				Error:    "rate limited",
+				Attempts: 5,
+				FailedAt: time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC),
+			},
+		}, nil)
+		mockedBot.On("Send", m.SenderID, "1 - twitter TextTopic after 5 attempts (2021-10-05T20:00:00Z): rate limited\n").
+			Once().
+			Return(nil)
+
+		require.NoError(t, handler(m))
+
+		dl.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+}
+
+func TestHandleReplayAndDiscard(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	commands := map[string]struct {
+		method string
+		done   string
+	}{
+		"/replay":  {method: "Replay", done: "replayed"},
+		"/discard": {method: "Discard", done: "discarded"},
+	}
+
+	for command, data := range commands {
+		command, data := command, data
+
+		t.Run("it should show usage when "+command+" has no id", func(t *testing.T) {
+			dl := new(mb.DeadLetters)
+			handler, mockedBot, _ := generateHandlerAndMockedBot(t, command, cfg, bot.WithDeadLetters(dl))
+			mockedBot.On("Send", strconv.Itoa(adminID), "Usage: "+command+" <id>").Once().Return(nil)
+
+			require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: strconv.Itoa(adminID)}))
+
+			mockedBot.AssertExpectations(t)
+			dl.AssertNotCalled(t, data.method, mock.Anything)
+		})
+
+		t.Run("it should notify when "+command+" id is not found", func(t *testing.T) {
+			dl := new(mb.DeadLetters)
+			handler, mockedBot, _ := generateHandlerAndMockedBot(t, command, cfg, bot.WithDeadLetters(dl))
+			dl.On(data.method, "7").Once().Return(storage.ErrNotFound)
+			mockedBot.On("Send", strconv.Itoa(adminID), "Dead letter 7 not found").Once().Return(nil)
+
+			require.NoError(t, handler(bot.TelegramMessage{
+				IsPrivate: true,
+				SenderID:  strconv.Itoa(adminID),
+				Payload:   "7",
+			}))
+
+			dl.AssertExpectations(t)
+			mockedBot.AssertExpectations(t)
+		})
+
+		t.Run("it should execute "+command, func(t *testing.T) {
+			dl := new(mb.DeadLetters)
+			handler, mockedBot, _ := generateHandlerAndMockedBot(t, command, cfg, bot.WithDeadLetters(dl))
+			dl.On(data.method, "7").Once().Return(nil)
+			mockedBot.On("Send", strconv.Itoa(adminID), "Dead letter 7 "+data.done).Once().Return(nil)
+
+			require.NoError(t, handler(bot.TelegramMessage{
+				IsPrivate: true,
+				SenderID:  strconv.Itoa(adminID),
+				Payload:   "7",
+			}))
+
+			dl.AssertExpectations(t)
+			mockedBot.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleWebhook(t *testing.T) {
+	cfg := config.AppConfig{Admins: []int{adminID}}
+	sender := strconv.Itoa(adminID)
+
+	t.Run("it should show usage when action is not valid", func(t *testing.T) {
+		wh := new(mb.Webhooks)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/webhook", cfg, bot.WithWebhooks(wh))
+
+		for _, payload := range []string{"", "add", "remove", "list all", "update 1"} {
+			mockedBot.On("Send", sender, "Usage: /webhook add <url> [topic+topic] | list | remove <id>").
+				Once().
+				Return(nil)
+
+			require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, Payload: payload}))
+		}
+
+		mockedBot.AssertExpectations(t)
+		wh.AssertExpectations(t)
+	})
+
+	t.Run("it should not add webhook with unknown topics", func(t *testing.T) {
+		wh := new(mb.Webhooks)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/webhook", cfg, bot.WithWebhooks(wh))
+		mockedBot.On("Send", sender, "Couldn't add webhook: unknown topic: scores").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			IsPrivate: true,
+			SenderID:  sender,
+			Payload:   "add https://quintodown.com/hooks text+scores",
+		}))
+
+		mockedBot.AssertExpectations(t)
+		wh.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should add webhook", func(t *testing.T) {
+		wh := new(mb.Webhooks)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/webhook", cfg, bot.WithWebhooks(wh))
+		wh.On("Add", "https://quintodown.com/hooks", []string{"text", "games"}).
+			Once().
+			Return(webhook.Subscription{ID: "3", Secret: "s3cr3t"}, nil)
+		mockedBot.On("Send", sender, "Webhook 3 added, its requests are signed with the secret s3cr3t").
+			Once().
+			Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{
+			IsPrivate: true,
+			SenderID:  sender,
+			Payload:   "add https://quintodown.com/hooks text+games",
+		}))
+
+		mockedBot.AssertExpectations(t)
+		wh.AssertExpectations(t)
+	})
+
+	t.Run("it should list webhooks", func(t *testing.T) {
+		wh := new(mb.Webhooks)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/webhook", cfg, bot.WithWebhooks(wh))
+		wh.On("List").Once().Return([]webhook.Subscription{
+			{ID: "config1", URL: "https://quintodown.com/hooks"},
+			{ID: "1", URL: "https://hooks.slack.com/services/T0/B0/X", Topics: []string{"text", "games"}},
+		}, nil)
+		mockedBot.On(
+			"Send",
+			sender,
+			"config1 - https://quintodown.com/hooks: all\n1 - https://hooks.slack.com/services/T0/B0/X: text+games\n",
+		).Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, Payload: "list"}))
+
+		mockedBot.AssertExpectations(t)
+		wh.AssertExpectations(t)
+	})
+
+	t.Run("it should notify when there are no webhooks", func(t *testing.T) {
+		wh := new(mb.Webhooks)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/webhook", cfg, bot.WithWebhooks(wh))
+		wh.On("List").Once().Return(nil, nil)
+		mockedBot.On("Send", sender, "There are no webhooks").Once().Return(nil)
+
+		require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, Payload: "list"}))
+
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should remove webhooks", func(t *testing.T) {
+		wh := new(mb.Webhooks)
+		handler, mockedBot, _ := generateHandlerAndMockedBot(t, "/webhook", cfg, bot.WithWebhooks(wh))
+		wh.On("Remove", "1").Once().Return(nil)
+		wh.On("Remove", "7").Once().Return(storage.ErrNotFound)
+		wh.On("Remove", "config1").Once().Return(webhook.ErrConfigured)
+		mockedBot.On("Send", sender, "Webhook 1 removed").Once().Return(nil)
+		mockedBot.On("Send", sender, "Webhook 7 not found").Once().Return(nil)
+		mockedBot.On("Send", sender, "Webhook config1 is configured and can't be removed").Once().Return(nil)
+
+		for _, id := range []string{"1", "7", "config1"} {
+			require.NoError(t, handler(bot.TelegramMessage{IsPrivate: true, SenderID: sender, Payload: "remove " + id}))
+		}
+
+		mockedBot.AssertExpectations(t)
+		wh.AssertExpectations(t)
+	})
+}
+
 func generateHandlerAndMockedBot(
 	t *testing.T,
 	toHandle string,
 	cfg config.AppConfig,
+	options ...bot.Option,
 ) (bot.TelegramHandler, *mb.TelegramBot, *mq.Queue) {
-	allHandlers := []string{"/start", "/help", "/stop", tb.OnPhoto, tb.OnText}
+	allHandlers := []string{
+		"/start",
+		"/help",
+		"/stop",
+		"/resume",
+		"/status",
+		"/deadletters",
+		"/replay",
+		"/discard",
+		"/schedule",
+		"/scheduled",
+		"/delete",
+		"/history",
+		"/telegram",
+		"/twitter",
+		"/unschedule",
+		"/webhook",
+		tb.OnPhoto,
+		tb.OnVideo,
+		tb.OnAnimation,
+		tb.OnDocument,
+		tb.OnText,
+		tb.OnEdited,
+	}
 
 	var (
 		handler bot.TelegramHandler
@@ -294,6 +1441,7 @@ func generateHandlerAndMockedBot(
 
 	mockedBot := new(mb.TelegramBot)
 	mockedBot.On("SetCommands", mock.Anything).Once().Return(nil)
+	mockedBot.On("HandleCallback", mock.Anything, mock.Anything)
 
 	for _, v := range allHandlers {
 		if v == toHandle {
@@ -311,11 +1459,42 @@ func generateHandlerAndMockedBot(
 		}
 	}
 
-	_ = bot.NewBot(
+	_ = bot.NewBot(append([]bot.Option{
 		bot.WithTelegramBot(mockedBot),
 		bot.WithConfig(cfg),
 		bot.WithQueue(mockedQueue),
-	).Start(nil)
+	}, options...)...).Start(nil)
 
 	return handler, mockedBot, mockedQueue
 }
+
+func generateConfirmationBot(
+	t *testing.T,
+	toHandle string,
+	options ...bot.Option,
+) (bot.TelegramHandler, map[string]bot.TelegramCallbackHandler, *mb.TelegramBot, *mq.Queue) {
+	var (
+		handler   bot.TelegramHandler
+		callbacks = map[string]bot.TelegramCallbackHandler{}
+	)
+
+	mockedQueue := new(mq.Queue)
+
+	mockedBot := new(mb.TelegramBot)
+	mockedBot.On("SetCommands", mock.Anything).Once().Return(nil)
+	mockedBot.On("Handle", toHandle, mock.Anything).Once().Run(func(args mock.Arguments) {
+		handler = args.Get(1).(bot.TelegramHandler)
+	})
+	mockedBot.On("Handle", mock.Anything, mock.Anything)
+	mockedBot.On("HandleCallback", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		callbacks[args.String(0)] = args.Get(1).(bot.TelegramCallbackHandler)
+	})
+
+	_ = bot.NewBot(append([]bot.Option{
+		bot.WithTelegramBot(mockedBot),
+		bot.WithConfig(config.AppConfig{Admins: []int{adminID}, ConfirmPosts: true}),
+		bot.WithQueue(mockedQueue),
+	}, options...)...).Start(nil)
+
+	return handler, callbacks, mockedBot, mockedQueue
+}
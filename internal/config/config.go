@@ -1,20 +1,72 @@
 package config
 
 import (
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 )
 
+const (
+	DefaultDestination  = "default"
+	DefaultFeedTemplate = "{{.Title}} {{.Link}}"
+)
+
 type AppConfig struct {
-	BotToken            string `required:"true" split_words:"true"`
-	Admins              []int  `required:"true" split_words:"true"`
-	BroadcastChannel    int64  `required:"true" split_words:"true"`
-	TwitterAPIKey       string `required:"true" split_words:"true"`
-	TwitterAPISecret    string `required:"true" split_words:"true"`
-	TwitterBearerToken  string `required:"true" split_words:"true"`
-	TwitterAccessToken  string `required:"true" split_words:"true"`
-	TwitterAccessSecret string `required:"true" split_words:"true"`
-	Environment         string `required:"true" split_words:"true"`
-	LogFile             string `split_words:"true"`
+	BotToken               string            `required:"true" split_words:"true"`
+	Admins                 []int             `required:"true" split_words:"true"`
+	BroadcastChannel       int64             `required:"true" split_words:"true"`
+	TwitterAPIKey          string            `required:"true" split_words:"true"`
+	TwitterAPISecret       string            `required:"true" split_words:"true"`
+	TwitterBearerToken     string            `required:"true" split_words:"true"`
+	TwitterAccessToken     string            `required:"true" split_words:"true"`
+	TwitterAccessSecret    string            `required:"true" split_words:"true"`
+	Environment            string            `required:"true" split_words:"true"`
+	LogFile                string            `split_words:"true"`
+	QueueBackend           string            `default:"memory" split_words:"true"`
+	QueueFile              string            `default:"queue.db" split_words:"true"`
+	StorageFile            string            `default:"storage.db" split_words:"true"`
+	HistoryRetention       int               `default:"1000" split_words:"true"`
+	RetryMaxAttempts       int               `default:"5" split_words:"true"`
+	RetryInitialBackoff    time.Duration     `default:"1s" split_words:"true"`
+	RetryMaxBackoff        time.Duration     `default:"1m" split_words:"true"`
+	ShutdownTimeout        time.Duration     `default:"30s" split_words:"true"`
+	ConfirmPosts           bool              `default:"false" split_words:"true"`
+	AlbumWindow            time.Duration     `default:"1s" split_words:"true"`
+	TwitterThreadNumbering bool              `default:"false" split_words:"true"`
+	BroadcastChannels      map[string]int64  `split_words:"true"`
+	BroadcastRoutes        map[string]string `split_words:"true"`
+	TwitterAccountTokens   map[string]string `split_words:"true"`
+	TwitterAccountSecrets  map[string]string `split_words:"true"`
+	TwitterRoutes          map[string]string `split_words:"true"`
+	TwitterAPIVersion      string            `default:"1.1" split_words:"true"`
+	MastodonInstanceURL    string            `split_words:"true"`
+	MastodonAccessToken    string            `split_words:"true"`
+	BlueskyHost            string            `default:"https://bsky.social" split_words:"true"`
+	BlueskyIdentifier      string            `split_words:"true"`
+	BlueskyPassword        string            `split_words:"true"`
+	DiscordWebhooks        []string          `split_words:"true"`
+	Webhooks               []string          `split_words:"true"`
+	WebhookSecret          string            `split_words:"true"`
+	WebhookSecrets         []string          `split_words:"true"`
+	Feeds                  []string          `split_words:"true"`
+	FeedTemplates          []string          `split_words:"true"`
+	FeedPollInterval       time.Duration     `default:"5m" split_words:"true"`
+	APIAddress             string            `default:":8080" split_words:"true"`
+	APIToken               string            `split_words:"true"`
+	MetricsAddress         string            `split_words:"true"`
+}
+
+type FeedSource struct {
+	Name     string
+	URL      string
+	Template string
+}
+
+type TwitterCredentials struct {
+	AccessToken  string
+	AccessSecret string
 }
 
 func NewAppConfig() (AppConfig, error) {
@@ -41,3 +93,124 @@ func (ec AppConfig) IsAdmin(userID int) bool {
 func (ec AppConfig) IsProd() bool {
 	return ec.Environment == "PROD"
 }
+
+func (ec AppConfig) IsDurableQueue() bool {
+	return ec.QueueBackend == "bolt"
+}
+
+func (ec AppConfig) IsTwitterAPIV2() bool {
+	return ec.TwitterAPIVersion == "2"
+}
+
+func (ec AppConfig) IsMastodonEnabled() bool {
+	return ec.MastodonInstanceURL != "" && ec.MastodonAccessToken != ""
+}
+
+func (ec AppConfig) IsBlueskyEnabled() bool {
+	return ec.BlueskyIdentifier != "" && ec.BlueskyPassword != ""
+}
+
+func (ec AppConfig) IsDiscordEnabled() bool {
+	return len(ec.DiscordWebhooks) > 0
+}
+
+func (ec AppConfig) IsAPIEnabled() bool {
+	return ec.APIToken != ""
+}
+
+func (ec AppConfig) IsMetricsEnabled() bool {
+	return ec.MetricsAddress != ""
+}
+
+func (ec AppConfig) Channels() map[string]int64 {
+	channels := map[string]int64{DefaultDestination: ec.BroadcastChannel}
+
+	for name, id := range ec.BroadcastChannels {
+		channels[name] = id
+	}
+
+	return channels
+}
+
+func (ec AppConfig) BroadcastRoute(event string, hashtags []string) []string {
+	return route(ec.BroadcastRoutes, event, hashtags)
+}
+
+func (ec AppConfig) TwitterCredentials() map[string]TwitterCredentials {
+	credentials := map[string]TwitterCredentials{
+		DefaultDestination: {AccessToken: ec.TwitterAccessToken, AccessSecret: ec.TwitterAccessSecret},
+	}
+
+	for name, token := range ec.TwitterAccountTokens {
+		credentials[name] = TwitterCredentials{AccessToken: token, AccessSecret: ec.TwitterAccountSecrets[name]}
+	}
+
+	return credentials
+}
+
+func (ec AppConfig) TwitterRoute(event string, hashtags []string) []string {
+	return route(ec.TwitterRoutes, event, hashtags)
+}
+
+func (ec AppConfig) FeedSources() []FeedSource {
+	templates := map[string]string{}
+
+	for _, t := range ec.FeedTemplates {
+		name, tpl, _ := strings.Cut(strings.TrimSpace(t), " ")
+		templates[name] = strings.TrimSpace(tpl)
+	}
+
+	sources := make([]FeedSource, 0, len(ec.Feeds))
+
+	for _, f := range ec.Feeds {
+		name, url, _ := strings.Cut(strings.TrimSpace(f), " ")
+
+		tpl := templates[name]
+		if tpl == "" {
+			tpl = DefaultFeedTemplate
+		}
+
+		sources = append(sources, FeedSource{Name: name, URL: strings.TrimSpace(url), Template: tpl})
+	}
+
+	return sources
+}
+
+func route(routes map[string]string, event string, hashtags []string) []string {
+	rules := make([]string, 0, len(routes))
+	for rule := range routes {
+		rules = append(rules, rule)
+	}
+
+	sort.Strings(rules)
+
+	var destinations []string
+
+	seen := map[string]bool{}
+	match := func(key string) {
+		for _, rule := range rules {
+			if !strings.EqualFold(rule, key) {
+				continue
+			}
+
+			for _, name := range strings.Split(routes[rule], "+") {
+				if name = strings.TrimSpace(name); name != "" && !seen[name] {
+					seen[name] = true
+					destinations = append(destinations, name)
+				}
+			}
+		}
+	}
+
+	match(event)
+
+	for _, h := range hashtags {
+		match(h)
+	}
+
+	if len(destinations) == 0 {
+		return []string{DefaultDestination}
+	}
+
+	return destinations
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/quintodown/quintodownbot/internal/config"
 	"github.com/stretchr/testify/require"
@@ -33,19 +34,140 @@ func TestNewEnvConfig(t *testing.T) {
 
 		require.NoError(t, err)
 		require.Equal(t, config.AppConfig{
-			BotToken:            "asdfg",
-			Admins:              []int{12345},
-			BroadcastChannel:    9876543,
-			TwitterAPIKey:       "asdfg1234",
-			TwitterAPISecret:    "poiuyt",
-			TwitterBearerToken:  "qwertyui",
-			TwitterAccessToken:  "zxcvbnm",
-			TwitterAccessSecret: "lkjhgfd",
-			Environment:         "testing",
-			LogFile:             "",
+			BotToken:               "asdfg",
+			Admins:                 []int{12345},
+			BroadcastChannel:       9876543,
+			TwitterAPIKey:          "asdfg1234",
+			TwitterAPISecret:       "poiuyt",
+			TwitterBearerToken:     "qwertyui",
+			TwitterAccessToken:     "zxcvbnm",
+			TwitterAccessSecret:    "lkjhgfd",
+			Environment:            "testing",
+			LogFile:                "",
+			QueueBackend:           "memory",
+			QueueFile:              "queue.db",
+			StorageFile:            "storage.db",
+			HistoryRetention:       1000,
+			RetryMaxAttempts:       5,
+			RetryInitialBackoff:    time.Second,
+			RetryMaxBackoff:        time.Minute,
+			ShutdownTimeout:        30 * time.Second,
+			ConfirmPosts:           false,
+			AlbumWindow:            time.Second,
+			TwitterThreadNumbering: false,
+			TwitterAPIVersion:      "1.1",
+			BlueskyHost:            "https://bsky.social",
+			FeedPollInterval:       5 * time.Minute,
+			APIAddress:             ":8080",
 		}, c)
 	})
 
+	t.Run("it should get the broadcast channels and routes", func(t *testing.T) {
+		_ = os.Setenv("BROADCAST_CHANNELS", "games:-1001,test:-1002")
+		_ = os.Setenv("BROADCAST_ROUTES", "game:games,#NFL:games+default")
+
+		defer func() {
+			_ = os.Unsetenv("BROADCAST_CHANNELS")
+			_ = os.Unsetenv("BROADCAST_ROUTES")
+		}()
+
+		c, err := config.NewAppConfig()
+
+		require.NoError(t, err)
+		require.Equal(t, map[string]int64{"games": -1001, "test": -1002}, c.BroadcastChannels)
+		require.Equal(t, map[string]string{"game": "games", "#NFL": "games+default"}, c.BroadcastRoutes)
+	})
+
+	t.Run("it should get the twitter accounts and routes", func(t *testing.T) {
+		_ = os.Setenv("TWITTER_ACCOUNT_TOKENS", "scores:1234-token")
+		_ = os.Setenv("TWITTER_ACCOUNT_SECRETS", "scores:secret")
+		_ = os.Setenv("TWITTER_ROUTES", "game:scores")
+
+		defer func() {
+			_ = os.Unsetenv("TWITTER_ACCOUNT_TOKENS")
+			_ = os.Unsetenv("TWITTER_ACCOUNT_SECRETS")
+			_ = os.Unsetenv("TWITTER_ROUTES")
+		}()
+
+		c, err := config.NewAppConfig()
+
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"scores": "1234-token"}, c.TwitterAccountTokens)
+		require.Equal(t, map[string]string{"scores": "secret"}, c.TwitterAccountSecrets)
+		require.Equal(t, map[string]string{"game": "scores"}, c.TwitterRoutes)
+	})
+
+	t.Run("it should get the webhooks", func(t *testing.T) {
+		_ = os.Setenv("WEBHOOKS", "https://quintodown.com/hooks,https://hooks.slack.com/services/T0/B0/X games")
+		_ = os.Setenv("WEBHOOK_SECRET", "secret")
+		_ = os.Setenv("WEBHOOK_SECRETS", ",slack")
+
+		defer func() {
+			_ = os.Unsetenv("WEBHOOKS")
+			_ = os.Unsetenv("WEBHOOK_SECRET")
+			_ = os.Unsetenv("WEBHOOK_SECRETS")
+		}()
+
+		c, err := config.NewAppConfig()
+
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			[]string{"https://quintodown.com/hooks", "https://hooks.slack.com/services/T0/B0/X games"},
+			c.Webhooks,
+		)
+		require.Equal(t, "secret", c.WebhookSecret)
+		require.Equal(t, []string{"", "slack"}, c.WebhookSecrets)
+	})
+
+	t.Run("it should get the feeds", func(t *testing.T) {
+		_ = os.Setenv("FEEDS", "blog https://quintodown.com/feed")
+		_ = os.Setenv("FEED_TEMPLATES", "blog Nuevo artículo: {{.Title}} {{.Link}}")
+		_ = os.Setenv("FEED_POLL_INTERVAL", "1m")
+
+		defer func() {
+			_ = os.Unsetenv("FEEDS")
+			_ = os.Unsetenv("FEED_TEMPLATES")
+			_ = os.Unsetenv("FEED_POLL_INTERVAL")
+		}()
+
+		c, err := config.NewAppConfig()
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"blog https://quintodown.com/feed"}, c.Feeds)
+		require.Equal(t, []string{"blog Nuevo artículo: {{.Title}} {{.Link}}"}, c.FeedTemplates)
+		require.Equal(t, time.Minute, c.FeedPollInterval)
+	})
+
+	t.Run("it should get the api configuration", func(t *testing.T) {
+		_ = os.Setenv("API_ADDRESS", "127.0.0.1:9090")
+		_ = os.Setenv("API_TOKEN", "s3cr3t")
+
+		defer func() {
+			_ = os.Unsetenv("API_ADDRESS")
+			_ = os.Unsetenv("API_TOKEN")
+		}()
+
+		c, err := config.NewAppConfig()
+
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1:9090", c.APIAddress)
+		require.Equal(t, "s3cr3t", c.APIToken)
+	})
+
+	t.Run("it should get the metrics address", func(t *testing.T) {
+		_ = os.Setenv("METRICS_ADDRESS", "127.0.0.1:9100")
+
+		defer func() {
+			_ = os.Unsetenv("METRICS_ADDRESS")
+		}()
+
+		c, err := config.NewAppConfig()
+
+		require.NoError(t, err)
+		require.Equal(t, "127.0.0.1:9100", c.MetricsAddress)
+	})
+
 	for k := range mocked {
 		k := k
 		t.Run(fmt.Sprintf("it should fail when %s not present", k), func(t *testing.T) {
@@ -133,3 +255,155 @@ func TestEnvConfig_IsProd(t *testing.T) {
 		_ = os.Setenv(k, v)
 	}
 }
+
+func TestEnvConfig_IsDurableQueue(t *testing.T) {
+	t.Run("it should return true when queue backend is bolt", func(t *testing.T) {
+		require.True(t, config.AppConfig{QueueBackend: "bolt"}.IsDurableQueue())
+	})
+
+	t.Run("it should return false when queue backend is memory", func(t *testing.T) {
+		require.False(t, config.AppConfig{QueueBackend: "memory"}.IsDurableQueue())
+	})
+}
+
+func TestEnvConfig_IsTwitterAPIV2(t *testing.T) {
+	t.Run("it should return true when twitter api version is 2", func(t *testing.T) {
+		require.True(t, config.AppConfig{TwitterAPIVersion: "2"}.IsTwitterAPIV2())
+	})
+
+	t.Run("it should return false when twitter api version is 1.1", func(t *testing.T) {
+		require.False(t, config.AppConfig{TwitterAPIVersion: "1.1"}.IsTwitterAPIV2())
+	})
+}
+
+func TestEnvConfig_IsMastodonEnabled(t *testing.T) {
+	t.Run("it should return true when instance and access token are configured", func(t *testing.T) {
+		c := config.AppConfig{MastodonInstanceURL: "https://mastodon.social", MastodonAccessToken: "token"}
+
+		require.True(t, c.IsMastodonEnabled())
+	})
+
+	t.Run("it should return false when access token is missing", func(t *testing.T) {
+		require.False(t, config.AppConfig{MastodonInstanceURL: "https://mastodon.social"}.IsMastodonEnabled())
+	})
+}
+
+func TestEnvConfig_IsBlueskyEnabled(t *testing.T) {
+	t.Run("it should return true when identifier and password are configured", func(t *testing.T) {
+		require.True(t, config.AppConfig{BlueskyIdentifier: "quintodown.com", BlueskyPassword: "pass"}.IsBlueskyEnabled())
+	})
+
+	t.Run("it should return false when password is missing", func(t *testing.T) {
+		require.False(t, config.AppConfig{BlueskyIdentifier: "quintodown.com"}.IsBlueskyEnabled())
+	})
+}
+
+func TestEnvConfig_IsDiscordEnabled(t *testing.T) {
+	t.Run("it should return true when webhooks are configured", func(t *testing.T) {
+		c := config.AppConfig{DiscordWebhooks: []string{"https://discord.com/api/webhooks/1234/token"}}
+
+		require.True(t, c.IsDiscordEnabled())
+	})
+
+	t.Run("it should return false when there are no webhooks", func(t *testing.T) {
+		require.False(t, config.AppConfig{}.IsDiscordEnabled())
+	})
+}
+
+func TestEnvConfig_IsAPIEnabled(t *testing.T) {
+	t.Run("it should return true when token is configured", func(t *testing.T) {
+		require.True(t, config.AppConfig{APIToken: "s3cr3t"}.IsAPIEnabled())
+	})
+
+	t.Run("it should return false when there is no token", func(t *testing.T) {
+		require.False(t, config.AppConfig{APIAddress: ":8080"}.IsAPIEnabled())
+	})
+}
+
+func TestEnvConfig_IsMetricsEnabled(t *testing.T) {
+	t.Run("it should return true when address is configured", func(t *testing.T) {
+		require.True(t, config.AppConfig{MetricsAddress: ":9090"}.IsMetricsEnabled())
+	})
+
+	t.Run("it should return false when there is no address", func(t *testing.T) {
+		require.False(t, config.AppConfig{APIAddress: ":8080"}.IsMetricsEnabled())
+	})
+}
+
+func TestEnvConfig_Channels(t *testing.T) {
+	t.Run("it should name the broadcast channel as default", func(t *testing.T) {
+		c := config.AppConfig{BroadcastChannel: 1234}
+
+		require.Equal(t, map[string]int64{config.DefaultDestination: 1234}, c.Channels())
+	})
+
+	t.Run("it should add the named channels", func(t *testing.T) {
+		c := config.AppConfig{BroadcastChannel: 1234, BroadcastChannels: map[string]int64{"games": 5678}}
+
+		require.Equal(t, map[string]int64{config.DefaultDestination: 1234, "games": 5678}, c.Channels())
+	})
+}
+
+func TestEnvConfig_BroadcastRoute(t *testing.T) {
+	c := config.AppConfig{
+		BroadcastRoutes: map[string]string{
+			"game":   "games",
+			"#NFL":   "games+default",
+			"#tests": "test",
+		},
+	}
+
+	t.Run("it should route to the default channel when no rule matches", func(t *testing.T) {
+		require.Equal(t, []string{config.DefaultDestination}, c.BroadcastRoute("text", []string{"#NBA"}))
+	})
+
+	t.Run("it should route by event", func(t *testing.T) {
+		require.Equal(t, []string{"games"}, c.BroadcastRoute("game", nil))
+	})
+
+	t.Run("it should route by hashtag ignoring case", func(t *testing.T) {
+		require.Equal(t, []string{"games", config.DefaultDestination}, c.BroadcastRoute("text", []string{"#nfl"}))
+	})
+
+	t.Run("it should fan out to every matching channel once", func(t *testing.T) {
+		require.Equal(
+			t,
+			[]string{"games", config.DefaultDestination, "test"},
+			c.BroadcastRoute("game", []string{"#NFL", "#Tests"}),
+		)
+	})
+}
+
+func TestEnvConfig_TwitterCredentials(t *testing.T) {
+	c := config.AppConfig{
+		TwitterAccessToken:    "zxcvbnm",
+		TwitterAccessSecret:   "lkjhgfd",
+		TwitterAccountTokens:  map[string]string{"scores": "1234-token"},
+		TwitterAccountSecrets: map[string]string{"scores": "secret"},
+	}
+
+	require.Equal(t, map[string]config.TwitterCredentials{
+		config.DefaultDestination: {AccessToken: "zxcvbnm", AccessSecret: "lkjhgfd"},
+		"scores":                  {AccessToken: "1234-token", AccessSecret: "secret"},
+	}, c.TwitterCredentials())
+}
+
+func TestEnvConfig_TwitterRoute(t *testing.T) {
+	c := config.AppConfig{TwitterRoutes: map[string]string{"game": "scores", "#NFL": "scores+default"}}
+
+	require.Equal(t, []string{config.DefaultDestination}, c.TwitterRoute("text", nil))
+	require.Equal(t, []string{"scores"}, c.TwitterRoute("game", nil))
+	require.Equal(t, []string{"scores", config.DefaultDestination}, c.TwitterRoute("photo", []string{"#NFL"}))
+}
+
+func TestEnvConfig_FeedSources(t *testing.T) {
+	c := config.AppConfig{
+		Feeds:         []string{"blog https://quintodown.com/feed", " podcast  https://quintodown.com/podcast.xml"},
+		FeedTemplates: []string{"podcast Nuevo episodio: {{.Title}} {{.Link}}"},
+	}
+
+	require.Equal(t, []config.FeedSource{
+		{Name: "blog", URL: "https://quintodown.com/feed", Template: config.DefaultFeedTemplate},
+		{Name: "podcast", URL: "https://quintodown.com/podcast.xml", Template: "Nuevo episodio: {{.Title}} {{.Link}}"},
+	}, c.FeedSources())
+}
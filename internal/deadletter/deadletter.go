@@ -0,0 +1,91 @@
+package deadletter
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/clock"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
+)
+
+const bucket = "deadletters"
+
+type Repository struct {
+	s   storage.Store
+	q   pubsub.Queue
+	clk clock.Clock
+}
+
+func NewRepository(s storage.Store, q pubsub.Queue, clk clock.Clock) *Repository {
+	return &Repository{s: s, q: q, clk: clk}
+}
+
+func (r *Repository) Save(e pubsub.DeadLetterEvent) error {
+	id, err := r.s.NextID(bucket)
+	if err != nil {
+		return err
+	}
+
+	e.ID = id
+	e.FailedAt = r.clk.Now()
+
+	eb, _ := easyjson.Marshal(e)
+
+	return r.s.Put(bucket, id, eb)
+}
+
+func (r *Repository) List() ([]pubsub.DeadLetterEvent, error) {
+	items, err := r.s.List(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]pubsub.DeadLetterEvent, 0, len(items))
+
+	for i := range items {
+		var e pubsub.DeadLetterEvent
+		if err := easyjson.Unmarshal(items[i].Value, &e); err != nil {
+			return nil, err
+		}
+
+		letters = append(letters, e)
+	}
+
+	sort.Slice(letters, func(i, j int) bool {
+		a, _ := strconv.Atoi(letters[i].ID)
+		b, _ := strconv.Atoi(letters[j].ID)
+
+		return a < b
+	})
+
+	return letters, nil
+}
+
+func (r *Repository) Replay(id string) error {
+	v, err := r.s.Get(bucket, id)
+	if err != nil {
+		return err
+	}
+
+	var e pubsub.DeadLetterEvent
+	if err := easyjson.Unmarshal(v, &e); err != nil {
+		return err
+	}
+
+	msg := message.NewMessage(watermill.NewUUID(), e.Payload)
+	msg.Metadata.Set(pubsub.TargetHandlerMetadata, e.Handler)
+
+	if err := r.q.Publish(e.Topic, msg); err != nil {
+		return err
+	}
+
+	return r.s.Delete(bucket, id)
+}
+
+func (r *Repository) Discard(id string) error {
+	return r.s.Delete(bucket, id)
+}
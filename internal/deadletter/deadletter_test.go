@@ -0,0 +1,96 @@
+package deadletter_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/quintodown/quintodownbot/internal/deadletter"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	"github.com/quintodown/quintodownbot/mocks/clock"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type publishError struct{}
+
+func (p publishError) Error() string {
+	return "error publishing message"
+}
+
+func TestRepository(t *testing.T) {
+	now := time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC)
+
+	s, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	defer func() { _ = s.Close() }()
+
+	clk := new(clock.Clock)
+	clk.On("Now").Return(now)
+
+	q := new(mq.Queue)
+	r := deadletter.NewRepository(s, q, clk)
+
+	t.Run("it should return an empty list when there are no dead letters", func(t *testing.T) {
+		letters, err := r.List()
+
+		require.NoError(t, err)
+		require.Empty(t, letters)
+	})
+
+	t.Run("it should save and list dead letters", func(t *testing.T) {
+		require.NoError(t, r.Save(pubsub.DeadLetterEvent{Handler: "twitter", Topic: "TextTopic", Payload: []byte("1")}))
+		require.NoError(t, r.Save(pubsub.DeadLetterEvent{Handler: "telegram", Topic: "TextTopic", Payload: []byte("2")}))
+
+		letters, err := r.List()
+
+		require.NoError(t, err)
+		require.Equal(t, []pubsub.DeadLetterEvent{
+			{ID: "1", Handler: "twitter", Topic: "TextTopic", Payload: []byte("1"), FailedAt: now},
+			{ID: "2", Handler: "telegram", Topic: "TextTopic", Payload: []byte("2"), FailedAt: now},
+		}, letters)
+	})
+
+	t.Run("it should fail replaying a missing dead letter", func(t *testing.T) {
+		require.ErrorIs(t, r.Replay("100"), storage.ErrNotFound)
+	})
+
+	t.Run("it should keep dead letter when it couldn't be replayed", func(t *testing.T) {
+		q.On("Publish", "TextTopic", mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "1"
+		})).Once().Return(publishError{})
+
+		require.EqualError(t, r.Replay("1"), "error publishing message")
+
+		letters, _ := r.List()
+		require.Len(t, letters, 2)
+		q.AssertExpectations(t)
+	})
+
+	t.Run("it should replay dead letter to the handler that failed", func(t *testing.T) {
+		q.On("Publish", "TextTopic", mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "1" && m.Metadata.Get(pubsub.TargetHandlerMetadata) == "twitter"
+		})).Once().Return(nil)
+
+		require.NoError(t, r.Replay("1"))
+
+		letters, _ := r.List()
+		require.Len(t, letters, 1)
+		q.AssertExpectations(t)
+	})
+
+	t.Run("it should discard dead letter", func(t *testing.T) {
+		require.NoError(t, r.Discard("2"))
+
+		letters, _ := r.List()
+		require.Empty(t, letters)
+	})
+
+	t.Run("it should fail discarding a missing dead letter", func(t *testing.T) {
+		require.ErrorIs(t, r.Discard("2"), storage.ErrNotFound)
+	})
+}
@@ -0,0 +1,284 @@
+package discord
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/textsplit"
+)
+
+const (
+	messageMaxLength     = 2000
+	messageMaxFiles      = 10
+	maxRateLimitRetries  = 3
+	remainingHeader      = "X-RateLimit-Remaining"
+	resetAfterHeader     = "X-RateLimit-Reset-After"
+	applicationJSONValue = "application/json"
+)
+
+var errUnexpectedStatus = errors.New("unexpected response status")
+
+//easyjson:json
+type webhookMessage struct {
+	Content         string          `json:"content,omitempty"`
+	Embeds          []Embed         `json:"embeds,omitempty"`
+	Attachments     []attachment    `json:"attachments,omitempty"`
+	AllowedMentions allowedMentions `json:"allowed_mentions"`
+}
+
+type attachment struct {
+	ID       int    `json:"id"`
+	Filename string `json:"filename"`
+}
+
+type allowedMentions struct {
+	Parse []string `json:"parse"`
+}
+
+//easyjson:json
+type messageResponse struct {
+	ID string `json:"id"`
+}
+
+//easyjson:json
+type rateLimitResponse struct {
+	RetryAfter float64 `json:"retry_after"`
+}
+
+type Embed struct {
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Color       int          `json:"color,omitempty"`
+	Timestamp   string       `json:"timestamp,omitempty"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+	Thumbnail   *EmbedImage  `json:"thumbnail,omitempty"`
+}
+
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type EmbedImage struct {
+	URL string `json:"url"`
+}
+
+type Client struct {
+	hc       *http.Client
+	splitter *textsplit.Splitter
+	mu       sync.Mutex
+	resets   map[string]time.Time
+}
+
+func NewDiscordClient(hc *http.Client) *Client {
+	return &Client{
+		hc:       hc,
+		splitter: textsplit.NewSplitter(textsplit.WithMaxLength(messageMaxLength)),
+		resets:   map[string]time.Time{},
+	}
+}
+
+func (c *Client) Send(webhook, text string) ([]string, error) {
+	return c.SendFiles(webhook, text)
+}
+
+func (c *Client) SendFiles(webhook, text string, files ...[]byte) ([]string, error) {
+	if text == "" && len(files) == 0 {
+		return nil, nil
+	}
+
+	if len(files) > messageMaxFiles {
+		files = files[:messageMaxFiles]
+	}
+
+	return c.sendChunks(webhook, c.splitter.Split(text), files, nil)
+}
+
+// Continue sends the chunks of text missing after the messages sent before a previous attempt failed.
+func (c *Client) Continue(webhook, text string, sent []string) ([]string, error) {
+	return c.sendChunks(webhook, textsplit.Remaining(c.splitter.Split(text), len(sent)), nil, sent)
+}
+
+func (c *Client) SendEmbed(webhook string, e Embed) ([]string, error) {
+	id, err := c.execute(webhook, webhookMessage{Embeds: []Embed{e}}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{id}, nil
+}
+
+func (c *Client) sendChunks(webhook string, chunks []string, files [][]byte, sent []string) ([]string, error) {
+	ids := append([]string(nil), sent...)
+
+	for i, chunk := range chunks {
+		msg := webhookMessage{Content: chunk}
+
+		if i > 0 {
+			files = nil
+		}
+
+		id, err := c.execute(webhook, msg, files)
+		if err != nil {
+			return ids, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (c *Client) execute(webhook string, msg webhookMessage, files [][]byte) (string, error) {
+	msg.AllowedMentions = allowedMentions{Parse: []string{}}
+
+	for attempt := 0; ; attempt++ {
+		c.waitRateLimit(webhook)
+
+		contentType, body := messageBody(msg, files)
+
+		resp, err := c.hc.Post(webhook+"?wait=true", contentType, body)
+		if err != nil {
+			return "", fmt.Errorf("error sending discord message: %w", err)
+		}
+
+		c.updateRateLimit(webhook, resp.Header)
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxRateLimitRetries {
+			c.rateLimited(webhook, resp)
+
+			continue
+		}
+
+		return readMessage(resp)
+	}
+}
+
+func messageBody(msg webhookMessage, files [][]byte) (string, io.Reader) {
+	if len(files) == 0 {
+		payload, _ := easyjson.Marshal(msg)
+
+		return applicationJSONValue, bytes.NewReader(payload)
+	}
+
+	for i := range files {
+		msg.Attachments = append(msg.Attachments, attachment{ID: i, Filename: filename(i, files[i])})
+	}
+
+	payload, _ := easyjson.Marshal(msg)
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	_ = mw.WriteField("payload_json", string(payload))
+
+	for i := range files {
+		header := make(textproto.MIMEHeader)
+		header.Set(
+			"Content-Disposition",
+			fmt.Sprintf(`form-data; name="files[%d]"; filename="%s"`, i, filename(i, files[i])),
+		)
+		header.Set("Content-Type", http.DetectContentType(files[i]))
+
+		fw, _ := mw.CreatePart(header)
+		_, _ = fw.Write(files[i])
+	}
+
+	_ = mw.Close()
+
+	return mw.FormDataContentType(), body
+}
+
+func filename(i int, file []byte) string {
+	extension := "bin"
+
+	switch http.DetectContentType(file) {
+	case "image/jpeg":
+		extension = "jpg"
+	case "image/png":
+		extension = "png"
+	case "image/gif":
+		extension = "gif"
+	case "image/webp":
+		extension = "webp"
+	}
+
+	return "file" + strconv.Itoa(i) + "." + extension
+}
+
+func readMessage(resp *http.Response) (string, error) {
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		buf := new(strings.Builder)
+		_, _ = io.Copy(buf, resp.Body)
+
+		return "", fmt.Errorf(
+			"error sending discord message: %w. Response status code: %v and body: %s",
+			errUnexpectedStatus,
+			resp.StatusCode,
+			buf.String(),
+		)
+	}
+
+	var msg messageResponse
+	if err := easyjson.UnmarshalFromReader(resp.Body, &msg); err != nil {
+		return "", fmt.Errorf("error sending discord message: %w", err)
+	}
+
+	return msg.ID, nil
+}
+
+func (c *Client) waitRateLimit(webhook string) {
+	c.mu.Lock()
+	reset := c.resets[webhook]
+	c.mu.Unlock()
+
+	if wait := time.Until(reset); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+func (c *Client) updateRateLimit(webhook string, header http.Header) {
+	if header.Get(remainingHeader) != "0" {
+		return
+	}
+
+	c.setReset(webhook, header.Get(resetAfterHeader))
+}
+
+func (c *Client) rateLimited(webhook string, resp *http.Response) {
+	defer func() { _ = resp.Body.Close() }()
+
+	var rl rateLimitResponse
+	if err := easyjson.UnmarshalFromReader(resp.Body, &rl); err == nil && rl.RetryAfter > 0 {
+		c.setReset(webhook, strconv.FormatFloat(rl.RetryAfter, 'f', -1, 64))
+
+		return
+	}
+
+	c.setReset(webhook, resp.Header.Get("Retry-After"))
+}
+
+func (c *Client) setReset(webhook, seconds string) {
+	after, err := strconv.ParseFloat(seconds, 64)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resets[webhook] = time.Now().Add(time.Duration(after * float64(time.Second)))
+}
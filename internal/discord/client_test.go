@@ -0,0 +1,231 @@
+package discord_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quintodown/quintodownbot/internal/discord"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookMessage struct {
+	Content     string          `json:"content"`
+	Embeds      []discord.Embed `json:"embeds"`
+	Attachments []struct {
+		ID       int    `json:"id"`
+		Filename string `json:"filename"`
+	} `json:"attachments"`
+	AllowedMentions struct {
+		Parse []string `json:"parse"`
+	} `json:"allowed_mentions"`
+}
+
+type webhook struct {
+	mu          sync.Mutex
+	messages    []webhookMessage
+	files       [][]string
+	requests    []time.Time
+	rateLimited int
+	exhausted   bool
+}
+
+func (wh *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	wh.requests = append(wh.requests, time.Now())
+
+	if r.URL.Path != "/api/webhooks/1234/token" || r.URL.Query().Get("wait") != "true" {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message":"Unknown Webhook","code":10015}`)
+
+		return
+	}
+
+	if wh.rateLimited > 0 {
+		wh.rateLimited--
+
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"message":"You are being rate limited.","retry_after":0.05,"global":false}`)
+
+		return
+	}
+
+	msg, files := wh.read(r)
+	wh.messages = append(wh.messages, msg)
+	wh.files = append(wh.files, files)
+
+	if wh.exhausted {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset-After", "0.05")
+	}
+
+	_, _ = io.WriteString(w, `{"id":"`+strconv.Itoa(len(wh.messages))+`"}`)
+}
+
+func (wh *webhook) read(r *http.Request) (webhookMessage, []string) {
+	var msg webhookMessage
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+
+		return msg, nil
+	}
+
+	_ = r.ParseMultipartForm(1 << 20)
+	_ = json.Unmarshal([]byte(r.FormValue("payload_json")), &msg)
+
+	var files []string
+
+	for i := 0; ; i++ {
+		fhs := r.MultipartForm.File["files["+strconv.Itoa(i)+"]"]
+		if len(fhs) == 0 {
+			return msg, files
+		}
+
+		files = append(files, fhs[0].Filename)
+	}
+}
+
+func TestClient_Send(t *testing.T) {
+	t.Run("it should fail when webhook doesn't exist", func(t *testing.T) {
+		client, server, _ := getClient(t)
+
+		_, err := client.Send(server.URL+"/api/webhooks/4321/token", "testing")
+
+		require.EqualError(
+			t,
+			err,
+			"error sending discord message: unexpected response status. Response status code: 404 and body: "+
+				`{"message":"Unknown Webhook","code":10015}`,
+		)
+	})
+
+	t.Run("it should not send empty messages", func(t *testing.T) {
+		client, server, wh := getClient(t)
+
+		ids, err := client.Send(server.URL+"/api/webhooks/1234/token", "")
+
+		require.NoError(t, err)
+		require.Empty(t, ids)
+		require.Empty(t, wh.requests)
+	})
+
+	t.Run("it should send message without pinging anyone", func(t *testing.T) {
+		client, server, wh := getClient(t)
+
+		ids, err := client.Send(server.URL+"/api/webhooks/1234/token", "testing @everyone")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1"}, ids)
+		require.Equal(t, "testing @everyone", wh.messages[0].Content)
+		require.Equal(t, []string{}, wh.messages[0].AllowedMentions.Parse)
+	})
+
+	t.Run("it should split messages longer than 2000 characters", func(t *testing.T) {
+		client, server, wh := getClient(t)
+
+		ids, err := client.Send(server.URL+"/api/webhooks/1234/token", strings.Repeat("touchdown ", 300))
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1", "2"}, ids)
+
+		for _, msg := range wh.messages {
+			require.LessOrEqual(t, len([]rune(msg.Content)), 2000)
+		}
+	})
+
+	t.Run("it should retry after being rate limited", func(t *testing.T) {
+		client, server, wh := getClient(t)
+		wh.rateLimited = 1
+
+		ids, err := client.Send(server.URL+"/api/webhooks/1234/token", "testing")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1"}, ids)
+		require.Len(t, wh.requests, 2)
+		require.GreaterOrEqual(t, wh.requests[1].Sub(wh.requests[0]), 50*time.Millisecond)
+	})
+
+	t.Run("it should give up when rate limit persists", func(t *testing.T) {
+		client, server, wh := getClient(t)
+		wh.rateLimited = 10
+
+		_, err := client.Send(server.URL+"/api/webhooks/1234/token", "testing")
+
+		require.ErrorContains(t, err, "Response status code: 429")
+		require.Len(t, wh.requests, 4)
+	})
+
+	t.Run("it should wait until the rate limit resets when no requests remain", func(t *testing.T) {
+		client, server, wh := getClient(t)
+		wh.exhausted = true
+
+		_, err := client.Send(server.URL+"/api/webhooks/1234/token", "first")
+		require.NoError(t, err)
+
+		_, err = client.Send(server.URL+"/api/webhooks/1234/token", "second")
+		require.NoError(t, err)
+
+		require.Len(t, wh.requests, 2)
+		require.GreaterOrEqual(t, wh.requests[1].Sub(wh.requests[0]), 50*time.Millisecond)
+	})
+}
+
+func TestClient_SendFiles(t *testing.T) {
+	client, server, wh := getClient(t)
+	png := []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+	ids, err := client.SendFiles(server.URL+"/api/webhooks/1234/token", strings.Repeat("touchdown ", 300), png, png)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2"}, ids)
+	require.Equal(t, []string{"file0.png", "file1.png"}, wh.files[0])
+	require.Len(t, wh.messages[0].Attachments, 2)
+	require.Equal(t, "file1.png", wh.messages[0].Attachments[1].Filename)
+	require.Empty(t, wh.files[1])
+	require.Empty(t, wh.messages[1].Attachments)
+}
+
+func TestClient_Continue(t *testing.T) {
+	client, server, wh := getClient(t)
+
+	ids, err := client.Continue(server.URL+"/api/webhooks/1234/token", strings.Repeat("touchdown ", 300), []string{"7"})
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"7", "1"}, ids)
+	require.Len(t, wh.messages, 1)
+}
+
+func TestClient_SendEmbed(t *testing.T) {
+	client, server, wh := getClient(t)
+	embed := discord.Embed{
+		Title:     "Tampa Bay Buccaneers vs Dallas Cowboys",
+		Fields:    []discord.EmbedField{{Name: "Venue", Value: "Raymond James Stadium"}},
+		Thumbnail: &discord.EmbedImage{URL: "https://a.espncdn.com/i/teamlogos/nfl/500/tb.png"},
+	}
+
+	ids, err := client.SendEmbed(server.URL+"/api/webhooks/1234/token", embed)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"1"}, ids)
+	require.Empty(t, wh.messages[0].Content)
+	require.Equal(t, []discord.Embed{embed}, wh.messages[0].Embeds)
+}
+
+func getClient(t *testing.T) (*discord.Client, *httptest.Server, *webhook) {
+	t.Helper()
+
+	wh := &webhook{}
+	server := httptest.NewServer(wh)
+	t.Cleanup(server.Close)
+
+	return discord.NewDiscordClient(server.Client()), server, wh
+}
@@ -0,0 +1,77 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const maxErrorBodyLength = 512
+
+var errUnexpectedStatus = errors.New("unexpected response status")
+
+type Client struct {
+	hc *http.Client
+}
+
+func NewFeedsClient(hc *http.Client) *Client {
+	return &Client{hc: hc}
+}
+
+func (c *Client) Fetch(ctx context.Context, url string) ([]Item, error) {
+	body, err := c.get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching feed %s: %w", url, err)
+	}
+
+	defer func() { _ = body.Close() }()
+
+	items, err := Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching feed %s: %w", url, err)
+	}
+
+	return items, nil
+}
+
+func (c *Client) Download(ctx context.Context, url string) ([]byte, error) {
+	body, err := c.get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading image: %w", err)
+	}
+
+	defer func() { _ = body.Close() }()
+
+	return io.ReadAll(body)
+}
+
+func (c *Client) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+
+		buf := new(strings.Builder)
+		_, _ = io.Copy(buf, io.LimitReader(resp.Body, maxErrorBodyLength))
+
+		return nil, fmt.Errorf(
+			"%w. Response status code: %v and body: %s",
+			errUnexpectedStatus,
+			resp.StatusCode,
+			buf.String(),
+		)
+	}
+
+	return resp.Body, nil
+}
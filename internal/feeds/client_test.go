@@ -0,0 +1,96 @@
+package feeds_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/quintodown/quintodownbot/internal/feeds"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/rss.xml")
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("\x89PNG\x0D\x0A\x1A\x0A"))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := feeds.NewFeedsClient(server.Client())
+
+	t.Run("it should fetch feed items", func(t *testing.T) {
+		items, err := client.Fetch(context.Background(), server.URL+"/feed")
+
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, "podcast-42", items[0].ID)
+	})
+
+	t.Run("it should fail when feed is not found", func(t *testing.T) {
+		_, err := client.Fetch(context.Background(), server.URL+"/missing")
+
+		require.EqualError(
+			t,
+			err,
+			"error fetching feed "+server.URL+"/missing: unexpected response status. Response status code: 404 and body: "+
+				"404 page not found\n",
+		)
+	})
+
+	t.Run("it should stop fetching when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := client.Fetch(ctx, server.URL+"/feed")
+
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("it should download images", func(t *testing.T) {
+		image, err := client.Download(context.Background(), server.URL+"/image.png")
+
+		require.NoError(t, err)
+		require.Equal(t, []byte("\x89PNG\x0D\x0A\x1A\x0A"), image)
+	})
+}
+
+func TestRepository(t *testing.T) {
+	s, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	defer func() { _ = s.Close() }()
+
+	r := feeds.NewRepository(s)
+
+	t.Run("it should remember polled feeds", func(t *testing.T) {
+		polled, err := r.Polled("blog")
+		require.NoError(t, err)
+		require.False(t, polled)
+
+		require.NoError(t, r.MarkPolled("blog"))
+
+		polled, err = r.Polled("blog")
+		require.NoError(t, err)
+		require.True(t, polled)
+	})
+
+	t.Run("it should remember seen items per feed", func(t *testing.T) {
+		require.NoError(t, r.MarkSeen("blog", "post-1"))
+
+		seen, err := r.Seen("blog", "post-1")
+		require.NoError(t, err)
+		require.True(t, seen)
+
+		seen, err = r.Seen("podcast", "post-1")
+		require.NoError(t, err)
+		require.False(t, seen)
+	})
+}
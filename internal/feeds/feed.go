@@ -0,0 +1,161 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"errors"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown feed format")
+
+	tags = regexp.MustCompile(`<[^>]*>`)
+
+	dateLayouts = []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		time.RFC3339,
+	}
+)
+
+type Item struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Published   time.Time
+	ImageURL    string
+}
+
+type document struct {
+	XMLName xml.Name
+	Items   []rssItem   `xml:"channel>item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	GUID        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Enclosures  []struct {
+		URL  string `xml:"url,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"enclosure"`
+}
+
+type atomEntry struct {
+	ID        string `xml:"id"`
+	Title     string `xml:"title"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Links     []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"link"`
+}
+
+// Parse reads the items of an RSS 2.0 or Atom feed in the order they appear in the document.
+func Parse(r io.Reader) ([]Item, error) {
+	var d document
+	if err := xml.NewDecoder(r).Decode(&d); err != nil {
+		return nil, err
+	}
+
+	switch d.XMLName.Local {
+	case "rss":
+		return rssItems(d.Items), nil
+	case "feed":
+		return atomItems(d.Entries), nil
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+func rssItems(rss []rssItem) []Item {
+	items := make([]Item, 0, len(rss))
+
+	for i := range rss {
+		item := Item{
+			ID:          firstNonEmpty(rss[i].GUID, rss[i].Link, rss[i].Title),
+			Title:       strings.TrimSpace(rss[i].Title),
+			Link:        strings.TrimSpace(rss[i].Link),
+			Description: plainText(rss[i].Description),
+			Published:   parseDate(rss[i].PubDate),
+		}
+
+		for _, e := range rss[i].Enclosures {
+			if strings.HasPrefix(e.Type, "image/") {
+				item.ImageURL = e.URL
+
+				break
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func atomItems(entries []atomEntry) []Item {
+	items := make([]Item, 0, len(entries))
+
+	for i := range entries {
+		item := Item{
+			Title:       strings.TrimSpace(entries[i].Title),
+			Description: plainText(firstNonEmpty(entries[i].Summary, entries[i].Content)),
+			Published:   parseDate(firstNonEmpty(entries[i].Published, entries[i].Updated)),
+		}
+
+		for _, l := range entries[i].Links {
+			switch {
+			case (l.Rel == "" || l.Rel == "alternate") && item.Link == "":
+				item.Link = l.Href
+			case l.Rel == "enclosure" && strings.HasPrefix(l.Type, "image/") && item.ImageURL == "":
+				item.ImageURL = l.Href
+			}
+		}
+
+		item.ID = firstNonEmpty(entries[i].ID, item.Link, item.Title)
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func plainText(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(tags.ReplaceAllString(s, " "))), " ")
+}
+
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,89 @@
+package feeds_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quintodown/quintodownbot/internal/feeds"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("it should parse rss items", func(t *testing.T) {
+		f, err := os.Open("testdata/rss.xml")
+		require.NoError(t, err)
+
+		defer func() { _ = f.Close() }()
+
+		items, err := feeds.Parse(f)
+
+		require.NoError(t, err)
+		require.Equal(t, []feeds.Item{
+			{
+				ID:          "podcast-42",
+				Title:       "Episodio 42: Semana 5",
+				Link:        "https://quintodown.com/podcast/42",
+				Description: "Repasamos la semana 5 & más",
+				Published:   time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC),
+				ImageURL:    "https://quintodown.com/podcast/42.png",
+			},
+			{
+				ID:          "https://quintodown.com/blog/previa-semana-5",
+				Title:       "Previa de la semana 5",
+				Link:        "https://quintodown.com/blog/previa-semana-5",
+				Description: "Lo que hay que ver",
+				Published:   time.Date(2021, 10, 4, 9, 30, 0, 0, time.UTC),
+			},
+		}, utc(items))
+	})
+
+	t.Run("it should parse atom entries", func(t *testing.T) {
+		f, err := os.Open("testdata/atom.xml")
+		require.NoError(t, err)
+
+		defer func() { _ = f.Close() }()
+
+		items, err := feeds.Parse(f)
+
+		require.NoError(t, err)
+		require.Equal(t, []feeds.Item{
+			{
+				ID:          "tag:quintodown.com,2021:resumen-semana-5",
+				Title:       "Resumen de la semana 5",
+				Link:        "https://quintodown.com/blog/resumen-semana-5",
+				Description: "Todos los resultados",
+				Published:   time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC),
+				ImageURL:    "https://quintodown.com/blog/resumen.jpg",
+			},
+			{
+				ID:          "https://quintodown.com/blog/sin-fecha",
+				Title:       "Sin fecha",
+				Link:        "https://quintodown.com/blog/sin-fecha",
+				Description: "Contenido",
+				Published:   time.Date(2021, 10, 4, 8, 0, 0, 0, time.UTC),
+			},
+		}, utc(items))
+	})
+
+	t.Run("it should fail with other documents", func(t *testing.T) {
+		_, err := feeds.Parse(strings.NewReader("<html><body></body></html>"))
+
+		require.ErrorIs(t, err, feeds.ErrUnknownFormat)
+	})
+
+	t.Run("it should fail with invalid xml", func(t *testing.T) {
+		_, err := feeds.Parse(strings.NewReader("<rss><channel>"))
+
+		require.Error(t, err)
+	})
+}
+
+func utc(items []feeds.Item) []feeds.Item {
+	for i := range items {
+		items[i].Published = items[i].Published.UTC()
+	}
+
+	return items
+}
@@ -0,0 +1,49 @@
+package feeds
+
+import (
+	"errors"
+
+	"github.com/quintodown/quintodownbot/internal/storage"
+)
+
+const (
+	polledBucket = "feeds"
+	itemsBucket  = "feeditems"
+)
+
+type Repository struct {
+	s storage.Store
+}
+
+func NewRepository(s storage.Store) *Repository {
+	return &Repository{s: s}
+}
+
+func (r *Repository) Polled(feed string) (bool, error) {
+	return r.exists(polledBucket, feed)
+}
+
+func (r *Repository) MarkPolled(feed string) error {
+	return r.s.Put(polledBucket, feed, []byte{1})
+}
+
+func (r *Repository) Seen(feed, id string) (bool, error) {
+	return r.exists(itemsBucket, itemKey(feed, id))
+}
+
+func (r *Repository) MarkSeen(feed, id string) error {
+	return r.s.Put(itemsBucket, itemKey(feed, id), []byte{1})
+}
+
+func (r *Repository) exists(bucket, key string) (bool, error) {
+	_, err := r.s.Get(bucket, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func itemKey(feed, id string) string {
+	return feed + " " + id
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/metrics"
 	"github.com/quintodown/quintodownbot/internal/pubsub"
 )
 
@@ -138,6 +139,7 @@ func (gh *GameHandler) UpdateGamesInformation(onlyPlaying bool) {
 		}
 
 		gh.gameList.Store(competition.String(), gameList)
+		metrics.GamesTracked(competition.String(), len(gameList))
 	}
 }
 
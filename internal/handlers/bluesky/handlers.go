@@ -0,0 +1,53 @@
+package handlersbluesky
+
+import (
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+type Bluesky struct {
+	*handlers.Social
+
+	bc handlers.SocialClient
+	q  pubsub.Queue
+	ps handlers.Posts
+	rp handlers.RetryPolicy
+}
+
+type Option func(b *Bluesky)
+
+func WithBlueskyClient(bc handlers.SocialClient) Option {
+	return func(b *Bluesky) {
+		b.bc = bc
+	}
+}
+
+func WithQueue(q pubsub.Queue) Option {
+	return func(b *Bluesky) {
+		b.q = q
+	}
+}
+
+func WithPosts(ps handlers.Posts) Option {
+	return func(b *Bluesky) {
+		b.ps = ps
+	}
+}
+
+func WithRetryPolicy(rp handlers.RetryPolicy) Option {
+	return func(b *Bluesky) {
+		b.rp = rp
+	}
+}
+
+func NewBluesky(options ...Option) *Bluesky {
+	b := &Bluesky{}
+
+	for _, o := range options {
+		o(b)
+	}
+
+	b.Social = handlers.NewSocial("bluesky", b.bc, b.q, b.ps, b.rp)
+
+	return b
+}
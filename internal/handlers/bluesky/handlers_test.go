@@ -0,0 +1,121 @@
+package handlersbluesky_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	hb "github.com/quintodown/quintodownbot/internal/handlers/bluesky"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	mh "github.com/quintodown/quintodownbot/mocks/handlers"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type blobTooLargeError struct{}
+
+func (blobTooLargeError) Error() string {
+	return "image too large"
+}
+
+func (blobTooLargeError) Permanent() bool {
+	return true
+}
+
+func TestBluesky_ID(t *testing.T) {
+	h := hb.NewBluesky(hb.WithBlueskyClient(new(mh.SocialClient)), hb.WithQueue(new(mq.Queue)))
+
+	require.Equal(t, "bluesky", h.ID())
+}
+
+func TestBluesky_ExecuteHandlers(t *testing.T) {
+	ctx := context.Background()
+	mockedClient := new(mh.SocialClient)
+	mockedQueue := new(mq.Queue)
+	channels := map[pubsub.TopicName]chan *message.Message{}
+
+	for _, topic := range []pubsub.TopicName{pubsub.TextTopic, pubsub.PhotoTopic, pubsub.DeleteTopic} {
+		channel := make(chan *message.Message)
+		channels[topic] = channel
+
+		mockedQueue.On("Subscribe", ctx, topic.String()).
+			Once().
+			Return(func(context.Context, string) <-chan *message.Message {
+				return channel
+			}, nil)
+	}
+
+	mockedClient.On("SendUpdate", "testing message").Once().Return([]string{"1"}, nil)
+
+	h := hb.NewBluesky(
+		hb.WithBlueskyClient(mockedClient),
+		hb.WithQueue(mockedQueue),
+		hb.WithRetryPolicy(handlers.RetryPolicy{MaxAttempts: 1}),
+	)
+	h.ExecuteHandlers(ctx)
+
+	payload := "{\"text\":\"testing message\",\"destinations\":[\"bluesky\"]}"
+	msg := message.NewMessage(watermill.NewUUID(), []byte(payload))
+	channels[pubsub.TextTopic] <- msg
+
+	require.Eventually(t, func() bool {
+		<-msg.Acked()
+
+		return true
+	}, time.Second, time.Millisecond)
+
+	mockedQueue.AssertExpectations(t)
+	mockedClient.AssertExpectations(t)
+}
+
+func TestBluesky_ExecuteHandlersPermanentError(t *testing.T) {
+	ctx := context.Background()
+	mockedClient := new(mh.SocialClient)
+	mockedQueue := new(mq.Queue)
+	channels := map[pubsub.TopicName]chan *message.Message{}
+
+	for _, topic := range []pubsub.TopicName{pubsub.TextTopic, pubsub.PhotoTopic, pubsub.DeleteTopic} {
+		channel := make(chan *message.Message)
+		channels[topic] = channel
+
+		mockedQueue.On("Subscribe", ctx, topic.String()).
+			Once().
+			Return(func(context.Context, string) <-chan *message.Message {
+				return channel
+			}, nil)
+	}
+
+	mockedClient.On("SendUpdateWithPhoto", "testing caption", []byte("photo")).Once().Return(nil, blobTooLargeError{})
+	mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.Anything).Once().Return(nil)
+	mockedQueue.On("Publish", pubsub.DeadLetterTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+		var e pubsub.DeadLetterEvent
+
+		return easyjson.Unmarshal(m.Payload, &e) == nil && e.Handler == "bluesky" && e.Attempts == 1
+	})).Once().
+		Return(nil)
+
+	h := hb.NewBluesky(
+		hb.WithBlueskyClient(mockedClient),
+		hb.WithQueue(mockedQueue),
+		hb.WithRetryPolicy(handlers.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+	)
+	h.ExecuteHandlers(ctx)
+
+	payload, _ := easyjson.Marshal(pubsub.PhotoEvent{Caption: "testing caption", FileContent: []byte("photo")})
+	msg := message.NewMessage(watermill.NewUUID(), payload)
+	channels[pubsub.PhotoTopic] <- msg
+
+	require.Eventually(t, func() bool {
+		<-msg.Acked()
+
+		return true
+	}, time.Second, time.Millisecond)
+
+	mockedQueue.AssertExpectations(t)
+	mockedClient.AssertExpectations(t)
+}
@@ -0,0 +1,83 @@
+package handlersdeadletter
+
+import (
+	"context"
+
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+type Repository interface {
+	Save(pubsub.DeadLetterEvent) error
+}
+
+type DeadLetter struct {
+	handlers.Workers
+
+	r Repository
+	q pubsub.Queue
+}
+
+type Option func(d *DeadLetter)
+
+func WithRepository(r Repository) Option {
+	return func(d *DeadLetter) {
+		d.r = r
+	}
+}
+
+func WithQueue(q pubsub.Queue) Option {
+	return func(d *DeadLetter) {
+		d.q = q
+	}
+}
+
+func NewDeadLetter(options ...Option) *DeadLetter {
+	d := &DeadLetter{}
+
+	for _, o := range options {
+		o(d)
+	}
+
+	return d
+}
+
+func (d *DeadLetter) ID() string {
+	return "deadletter"
+}
+
+func (d *DeadLetter) ExecuteHandlers(ctx context.Context) {
+	messages, err := d.q.Subscribe(ctx, pubsub.DeadLetterTopic.String())
+	if err != nil {
+		handlers.SendError(d.q, err)
+
+		return
+	}
+
+	d.Go(func() {
+		for msg := range messages {
+			var m pubsub.DeadLetterEvent
+			if err := easyjson.Unmarshal(msg.Payload, &m); err != nil {
+				handlers.SendError(d.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if err := d.r.Save(m); err != nil {
+				handlers.SendError(d.q, err)
+			}
+
+			msg.Ack()
+		}
+	})
+}
+
+func (d *DeadLetter) StopNotifications() {}
+
+func (d *DeadLetter) ResumeNotifications() {}
+
+func (d *DeadLetter) IsPaused() bool {
+	return false
+}
@@ -0,0 +1,97 @@
+package handlersdeadletter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	hdl "github.com/quintodown/quintodownbot/internal/handlers/deadletter"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	mdl "github.com/quintodown/quintodownbot/mocks/handlers/deadletter"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetter_ID(t *testing.T) {
+	require.Equal(t, "deadletter", hdl.NewDeadLetter().ID())
+}
+
+func TestDeadLetter_ExecuteHandlers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should fail getting channel for dead letters", func(t *testing.T) {
+		q := new(mq.Queue)
+		q.On("Subscribe", ctx, pubsub.DeadLetterTopic.String()).Once().Return(nil, errors.New("channel error"))
+		q.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"channel error\"}"
+		})).Once().Return(nil)
+
+		hdl.NewDeadLetter(hdl.WithQueue(q)).ExecuteHandlers(ctx)
+
+		q.AssertExpectations(t)
+	})
+
+	t.Run("it should fail unmarshaling dead letter event", func(t *testing.T) {
+		d, q, r, c := generateHandlerAndMocks(ctx)
+		q.On("Publish", pubsub.ErrorTopic.String(), mock.Anything).Once().Return(nil)
+
+		d.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, c, []byte("{\"asd\":\"qwer"))
+
+		q.AssertExpectations(t)
+		r.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("it should notify error when dead letter couldn't be saved", func(t *testing.T) {
+		d, q, r, c := generateHandlerAndMocks(ctx)
+		r.On("Save", pubsub.DeadLetterEvent{Handler: "twitter"}).Once().Return(errors.New("storage error"))
+		q.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"storage error\"}"
+		})).Once().Return(nil)
+
+		d.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, c, []byte("{\"handler\":\"twitter\"}"))
+
+		q.AssertExpectations(t)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("it should save dead letter", func(t *testing.T) {
+		d, q, r, c := generateHandlerAndMocks(ctx)
+		r.On("Save", pubsub.DeadLetterEvent{Handler: "twitter", Topic: "TextTopic"}).Once().Return(nil)
+
+		d.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, c, []byte("{\"handler\":\"twitter\",\"topic\":\"TextTopic\"}"))
+
+		q.AssertExpectations(t)
+		r.AssertExpectations(t)
+	})
+}
+
+func generateHandlerAndMocks(ctx context.Context) (*hdl.DeadLetter, *mq.Queue, *mdl.Repository, chan *message.Message) {
+	q := new(mq.Queue)
+	r := new(mdl.Repository)
+	c := make(chan *message.Message)
+
+	q.On("Subscribe", ctx, pubsub.DeadLetterTopic.String()).Once().
+		Return(func(context.Context, string) <-chan *message.Message {
+			return c
+		}, nil)
+
+	return hdl.NewDeadLetter(hdl.WithQueue(q), hdl.WithRepository(r)), q, r, c
+}
+
+func sendMessageToChannel(t *testing.T, channel chan *message.Message, eventMsg []byte) {
+	newMessage := message.NewMessage(watermill.NewUUID(), eventMsg)
+	channel <- newMessage
+
+	require.Eventually(t, func() bool {
+		<-newMessage.Acked()
+
+		return true
+	}, time.Second, time.Millisecond)
+}
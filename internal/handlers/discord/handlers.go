@@ -0,0 +1,311 @@
+package handlersdiscord
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/discord"
+	"github.com/quintodown/quintodownbot/internal/games"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+const (
+	startedGameColor  = 0x2ecc71
+	finishedGameColor = 0x95a5a6
+)
+
+type Client interface {
+	Send(string, string) ([]string, error)
+	SendFiles(string, string, ...[]byte) ([]string, error)
+	SendEmbed(string, discord.Embed) ([]string, error)
+	Continue(string, string, []string) ([]string, error)
+}
+
+type Discord struct {
+	handlers.NotificationState
+	handlers.Workers
+
+	dc       Client
+	webhooks []string
+	q        pubsub.Queue
+	ps       handlers.Posts
+	rp       handlers.RetryPolicy
+	r        *handlers.Retrier
+}
+
+type Option func(d *Discord)
+
+func WithDiscordClient(dc Client) Option {
+	return func(d *Discord) {
+		d.dc = dc
+	}
+}
+
+func WithWebhooks(webhooks []string) Option {
+	return func(d *Discord) {
+		d.webhooks = webhooks
+	}
+}
+
+func WithQueue(q pubsub.Queue) Option {
+	return func(d *Discord) {
+		d.q = q
+	}
+}
+
+func WithPosts(ps handlers.Posts) Option {
+	return func(d *Discord) {
+		d.ps = ps
+	}
+}
+
+func WithRetryPolicy(rp handlers.RetryPolicy) Option {
+	return func(d *Discord) {
+		d.rp = rp
+	}
+}
+
+func NewDiscord(options ...Option) *Discord {
+	d := &Discord{}
+
+	for _, o := range options {
+		o(d)
+	}
+
+	d.r = handlers.NewRetrier(d.q, d.ID(), d.rp)
+
+	return d
+}
+
+func (d *Discord) ID() string {
+	return "discord"
+}
+
+func (d *Discord) ExecuteHandlers(ctx context.Context) {
+	d.handleText(ctx)
+	d.handlePhoto(ctx)
+	d.handleGames(ctx)
+}
+
+func (d *Discord) handleText(ctx context.Context) {
+	messages, err := d.q.Subscribe(ctx, pubsub.TextTopic.String())
+	if err != nil {
+		handlers.SendError(d.q, err)
+
+		return
+	}
+
+	d.Go(func() {
+		for msg := range messages {
+			if d.IsPaused() || !handlers.IsAddressedTo(msg, d.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			var e pubsub.TextEvent
+			if err := easyjson.Unmarshal(msg.Payload, &e); err != nil {
+				handlers.SendError(d.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if !e.Destinations.Includes(d.ID()) || e.Edit || e.Kind == pubsub.GameKind {
+				msg.Ack()
+
+				continue
+			}
+
+			text := handlers.PlainText(e)
+
+			if err := d.publish(e.Source, text, func(webhook string) ([]string, error) {
+				return d.dc.Send(webhook, text)
+			}); err != nil {
+				d.r.Fail(msg, pubsub.TextTopic, err)
+
+				continue
+			}
+
+			d.r.Ack(msg)
+		}
+	})
+}
+
+func (d *Discord) handlePhoto(ctx context.Context) {
+	messages, err := d.q.Subscribe(ctx, pubsub.PhotoTopic.String())
+	if err != nil {
+		handlers.SendError(d.q, err)
+
+		return
+	}
+
+	d.Go(func() {
+		for msg := range messages {
+			if d.IsPaused() || !handlers.IsAddressedTo(msg, d.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			var e pubsub.PhotoEvent
+			if err := easyjson.Unmarshal(msg.Payload, &e); err != nil {
+				handlers.SendError(d.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if !e.Destinations.Includes(d.ID()) || e.Edit {
+				msg.Ack()
+
+				continue
+			}
+
+			if err := d.publish(e.Source, e.Caption, func(webhook string) ([]string, error) {
+				return d.dc.SendFiles(webhook, e.Caption, e.FileContent)
+			}); err != nil {
+				d.r.Fail(msg, pubsub.PhotoTopic, err)
+
+				continue
+			}
+
+			d.r.Ack(msg)
+		}
+	})
+}
+
+func (d *Discord) handleGames(ctx context.Context) {
+	messages, err := d.q.Subscribe(ctx, pubsub.GamesTopic.String())
+	if err != nil {
+		handlers.SendError(d.q, err)
+
+		return
+	}
+
+	d.Go(func() {
+		for msg := range messages {
+			if d.IsPaused() || !handlers.IsAddressedTo(msg, d.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			var e pubsub.GameEvent
+			if err := easyjson.Unmarshal(msg.Payload, &e); err != nil {
+				handlers.SendError(d.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			embed, ok := gameEmbed(e)
+			if !ok {
+				msg.Ack()
+
+				continue
+			}
+
+			if err := d.publish(gameSource(e), "", func(webhook string) ([]string, error) {
+				return d.dc.SendEmbed(webhook, embed)
+			}); err != nil {
+				d.r.Fail(msg, pubsub.GamesTopic, err)
+
+				continue
+			}
+
+			d.r.Ack(msg)
+		}
+	})
+}
+
+// publish sends a post to every webhook it wasn't published in yet. Messages that failed partway are completed with the
+// missing chunks of text on retry instead of being published again.
+func (d *Discord) publish(source, text string, send func(webhook string) ([]string, error)) error {
+	for i, webhook := range d.webhooks {
+		key := handlers.PostKey(d.ID(), webhookID(i, webhook))
+
+		ids, published, err := handlers.PublishedMessages(d.ps, source, key)
+		if err != nil {
+			return err
+		}
+
+		if published {
+			continue
+		}
+
+		if len(ids) > 0 {
+			ids, err = d.dc.Continue(webhook, text, ids)
+		} else {
+			ids, err = send(webhook)
+		}
+
+		if err != nil {
+			handlers.SaveUnfinished(d.q, d.ps, source, key, ids)
+
+			return err
+		}
+
+		handlers.SavePost(d.q, d.ps, source, key, ids)
+	}
+
+	return nil
+}
+
+func webhookID(i int, webhook string) string {
+	u, err := url.Parse(webhook)
+	if err == nil {
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		for j := 0; j < len(parts)-1; j++ {
+			if parts[j] == "webhooks" {
+				return parts[j+1]
+			}
+		}
+	}
+
+	return strconv.Itoa(i)
+}
+
+// gameSource keys the embeds of a game change, so a retry skips the webhooks that already got it.
+func gameSource(m pubsub.GameEvent) string {
+	return "game:" + m.Id + ":" + m.LastGameChange
+}
+
+func gameEmbed(m pubsub.GameEvent) (discord.Embed, bool) {
+	embed := discord.Embed{
+		Title:     fmt.Sprintf("%s vs %s", m.AwayTeam.Name, m.HomeTeam.Name),
+		Timestamp: m.Start.Format(time.RFC3339),
+		Fields: []discord.EmbedField{
+			{Name: m.AwayTeam.Name, Value: teamScore(m.AwayTeam), Inline: true},
+			{Name: m.HomeTeam.Name, Value: teamScore(m.HomeTeam), Inline: true},
+			{Name: "Estadio", Value: fmt.Sprintf("%s (%s, %s)", m.Venue.FullName, m.Venue.City, m.Venue.State)},
+		},
+	}
+
+	if m.HomeTeam.Logo != "" {
+		embed.Thumbnail = &discord.EmbedImage{URL: m.HomeTeam.Logo}
+	}
+
+	switch m.LastGameChange {
+	case games.Started.String():
+		embed.Description = fmt.Sprintf("#%s El partido ha iniciado", m.Competition)
+		embed.Color = startedGameColor
+	case games.Finished.String():
+		embed.Description = fmt.Sprintf("#%s El partido ha finalizado", m.Competition)
+		embed.Color = finishedGameColor
+	default:
+		return discord.Embed{}, false
+	}
+
+	return embed, true
+}
+
+func teamScore(t pubsub.TeamScore) string {
+	return fmt.Sprintf("%d (%s)", t.Score, t.Record)
+}
@@ -0,0 +1,320 @@
+package handlersdiscord_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/discord"
+	"github.com/quintodown/quintodownbot/internal/games"
+	hd "github.com/quintodown/quintodownbot/internal/handlers/discord"
+	"github.com/quintodown/quintodownbot/internal/posts"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	mh "github.com/quintodown/quintodownbot/mocks/handlers"
+	md "github.com/quintodown/quintodownbot/mocks/handlers/discord"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	firstWebhook  = "https://discord.com/api/webhooks/1234/token"
+	secondWebhook = "https://discord.com/api/webhooks/5678/token"
+)
+
+type messageNotSendError struct{}
+
+func (m messageNotSendError) Error() string {
+	return "couldn't send message to discord"
+}
+
+type channelError struct{}
+
+func (c channelError) Error() string {
+	return "error getting channel error"
+}
+
+func TestDiscord_ID(t *testing.T) {
+	dh := hd.NewDiscord(hd.WithDiscordClient(new(md.Client)), hd.WithQueue(new(mq.Queue)))
+
+	require.Equal(t, "discord", dh.ID())
+}
+
+func TestDiscord_ExecuteHandlers(t *testing.T) {
+	ctx := context.Background()
+
+	dh, mockedQueue, _, _ := getDiscordHandlerAndMocks(ctx, false)
+
+	for _, topic := range []pubsub.TopicName{pubsub.TextTopic, pubsub.PhotoTopic, pubsub.GamesTopic} {
+		mockedQueue.On("Subscribe", ctx, topic.String()).Once().Return(nil, channelError{})
+	}
+
+	mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+		return string(m.Payload) == "{\"error\":\"error getting channel error\"}"
+	})).Times(3).
+		Return(nil)
+
+	dh.ExecuteHandlers(ctx)
+	dh.Wait()
+
+	mockedQueue.AssertExpectations(t)
+}
+
+func TestDiscord_ExecuteHandlersText(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should fail unmarshaling text event", func(t *testing.T) {
+		dh, mockedQueue, _, channels := getDiscordHandlerAndMocks(ctx, true)
+
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) ==
+				"{\"error\":\"parse error: unterminated string literal near offset 12 of '{\\\"asd\\\":\\\"qwer'\"}"
+		})).Once().
+			Return(nil)
+
+		dh.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"asd\":\"qwer"))
+
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should fail sending text message to discord", func(t *testing.T) {
+		dh, mockedQueue, mockedDiscord, channels := getDiscordHandlerAndMocks(ctx, true)
+
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"couldn't send message to discord\"}"
+		})).Once().
+			Return(nil)
+		mockedQueue.On("Publish", pubsub.DeadLetterTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			var e pubsub.DeadLetterEvent
+
+			return easyjson.Unmarshal(m.Payload, &e) == nil &&
+				e.Handler == "discord" &&
+				e.Topic == pubsub.TextTopic.String()
+		})).Once().
+			Return(nil)
+		mockedDiscord.On("Send", firstWebhook, "testing message").Once().Return(nil, messageNotSendError{})
+
+		dh.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedDiscord.AssertExpectations(t)
+	})
+
+	t.Run("it should skip game texts and edits", func(t *testing.T) {
+		dh, mockedQueue, mockedDiscord, channels := getDiscordHandlerAndMocks(ctx, true)
+
+		dh.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\",\"kind\":\"game\"}"))
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\",\"edit\":true}"))
+		sendMessageToChannel(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"testing message\",\"destinations\":[\"telegram\"]}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedDiscord.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should send text message to every webhook", func(t *testing.T) {
+		dh, mockedQueue, mockedDiscord, channels := getDiscordHandlerAndMocks(ctx, true)
+
+		mockedDiscord.On("Send", firstWebhook, "testing message (https://quintodown.com)").Once().Return([]string{"1"}, nil)
+		mockedDiscord.On("Send", secondWebhook, "testing message (https://quintodown.com)").Once().Return([]string{"2"}, nil)
+
+		dh.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\","+
+			"\"entities\":[{\"type\":\"text_link\",\"offset\":8,\"length\":7,\"url\":\"https://quintodown.com\"}]}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedDiscord.AssertExpectations(t)
+	})
+
+	t.Run("it should record the messages and skip webhooks already published", func(t *testing.T) {
+		ps := new(mh.Posts)
+		dh, mockedQueue, mockedDiscord, channels := getDiscordHandlerAndMocks(ctx, true, hd.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{Messages: map[string][]string{"discord:1234": {"1"}}}, nil)
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+		mockedDiscord.On("Send", secondWebhook, "testing message").Once().Return([]string{"2"}, nil)
+		ps.On("Save", "12345:7", "discord:5678", []string{"2"}).Once().Return(nil)
+
+		dh.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedDiscord.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should keep the messages sent before failing and continue them on retry", func(t *testing.T) {
+		ps := new(mh.Posts)
+		dh, mockedQueue, mockedDiscord, channels := getDiscordHandlerAndMocks(ctx, true, hd.WithPosts(ps))
+		unfinished := posts.Post{Messages: map[string][]string{"discord:1234": {"1"}}, Unfinished: []string{"discord:1234"}}
+
+		ps.On("Get", "12345:7").Once().Return(unfinished, nil)
+		mockedDiscord.On("Continue", firstWebhook, "testing message", []string{"1"}).Once().Return([]string{"1", "3"}, nil)
+		ps.On("Save", "12345:7", "discord:1234", []string{"1", "3"}).Once().Return(nil)
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+		mockedDiscord.On("Send", secondWebhook, "testing message").Once().Return([]string{"2"}, messageNotSendError{})
+		ps.On("SaveUnfinished", "12345:7", "discord:5678", []string{"2"}).Once().Return(nil)
+		mockedQueue.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+		dh.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"))
+
+		mockedDiscord.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+}
+
+func TestDiscord_ExecuteHandlersPhoto(t *testing.T) {
+	ctx := context.Background()
+	photoContent := []byte("\x89PNG\x0D\x0A\x1A\x0A")
+	bytes, _ := easyjson.Marshal(pubsub.PhotoEvent{Caption: "testing caption", FileContent: photoContent})
+
+	dh, mockedQueue, mockedDiscord, channels := getDiscordHandlerAndMocks(ctx, true)
+
+	mockedDiscord.On("SendFiles", firstWebhook, "testing caption", photoContent).Once().Return([]string{"1"}, nil)
+	mockedDiscord.On("SendFiles", secondWebhook, "testing caption", photoContent).Once().Return([]string{"2"}, nil)
+
+	dh.ExecuteHandlers(ctx)
+
+	sendMessageToChannel(t, channels[pubsub.PhotoTopic], bytes)
+
+	mockedQueue.AssertExpectations(t)
+	mockedDiscord.AssertExpectations(t)
+}
+
+func TestDiscord_ExecuteHandlersGames(t *testing.T) {
+	ctx := context.Background()
+	game := pubsub.GameEvent{
+		Id:          "401326315",
+		Start:       time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC),
+		Venue:       pubsub.GameVenue{FullName: "Raymond James Stadium", City: "Tampa", State: "FL"},
+		HomeTeam:    pubsub.TeamScore{Name: "Tampa Bay Buccaneers", Record: "3-1", Score: 31, Logo: "https://tb.png"},
+		AwayTeam:    pubsub.TeamScore{Name: "Dallas Cowboys", Record: "2-2", Score: 29},
+		Competition: "NFL",
+	}
+
+	t.Run("it should skip game changes other than start and end", func(t *testing.T) {
+		dh, mockedQueue, mockedDiscord, channels := getDiscordHandlerAndMocks(ctx, true)
+
+		game.LastGameChange = games.HomeScore.String()
+		bytes, _ := easyjson.Marshal(game)
+
+		dh.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.GamesTopic], bytes)
+
+		mockedQueue.AssertExpectations(t)
+		mockedDiscord.AssertNotCalled(t, "SendEmbed", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should send an embed when the game finishes", func(t *testing.T) {
+		dh, mockedQueue, mockedDiscord, channels := getDiscordHandlerAndMocks(ctx, true)
+
+		game.LastGameChange = games.Finished.String()
+		bytes, _ := easyjson.Marshal(game)
+		embed := discord.Embed{
+			Title:       "Dallas Cowboys vs Tampa Bay Buccaneers",
+			Description: "#NFL El partido ha finalizado",
+			Color:       0x95a5a6,
+			Timestamp:   "2021-10-05T20:00:00Z",
+			Fields: []discord.EmbedField{
+				{Name: "Dallas Cowboys", Value: "29 (2-2)", Inline: true},
+				{Name: "Tampa Bay Buccaneers", Value: "31 (3-1)", Inline: true},
+				{Name: "Estadio", Value: "Raymond James Stadium (Tampa, FL)"},
+			},
+			Thumbnail: &discord.EmbedImage{URL: "https://tb.png"},
+		}
+
+		mockedDiscord.On("SendEmbed", firstWebhook, embed).Once().Return([]string{"1"}, nil)
+		mockedDiscord.On("SendEmbed", secondWebhook, embed).Once().Return([]string{"2"}, nil)
+
+		dh.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.GamesTopic], bytes)
+
+		mockedQueue.AssertExpectations(t)
+		mockedDiscord.AssertExpectations(t)
+	})
+
+	t.Run("it should only send the embed to the webhooks that didn't get it on retry", func(t *testing.T) {
+		ps := new(mh.Posts)
+		dh, mockedQueue, mockedDiscord, channels := getDiscordHandlerAndMocks(ctx, true, hd.WithPosts(ps))
+
+		game.LastGameChange = games.Started.String()
+		bytes, _ := easyjson.Marshal(game)
+		source := "game:401326315:Started"
+
+		ps.On("Get", source).Once().Return(posts.Post{Messages: map[string][]string{"discord:1234": {"1"}}}, nil)
+		ps.On("Get", source).Once().Return(posts.Post{}, storage.ErrNotFound)
+		mockedDiscord.On("SendEmbed", secondWebhook, mock.Anything).Once().Return([]string{"2"}, nil)
+		ps.On("Save", source, "discord:5678", []string{"2"}).Once().Return(nil)
+
+		dh.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.GamesTopic], bytes)
+
+		mockedQueue.AssertExpectations(t)
+		mockedDiscord.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+}
+
+func getDiscordHandlerAndMocks(ctx context.Context, returnChannels bool, options ...hd.Option) (
+	*hd.Discord,
+	*mq.Queue,
+	*md.Client,
+	map[pubsub.TopicName]chan *message.Message,
+) {
+	mockedDiscord := new(md.Client)
+	mockedQueue := new(mq.Queue)
+
+	dh := hd.NewDiscord(append([]hd.Option{
+		hd.WithDiscordClient(mockedDiscord),
+		hd.WithWebhooks([]string{firstWebhook, secondWebhook}),
+		hd.WithQueue(mockedQueue),
+	}, options...)...)
+
+	channels := map[pubsub.TopicName]chan *message.Message{}
+
+	for _, topic := range []pubsub.TopicName{pubsub.TextTopic, pubsub.PhotoTopic, pubsub.GamesTopic} {
+		channel := make(chan *message.Message)
+		channels[topic] = channel
+
+		if returnChannels {
+			mockedQueue.On("Subscribe", ctx, topic.String()).
+				Once().
+				Return(func(context.Context, string) <-chan *message.Message {
+					return channel
+				}, nil)
+		}
+	}
+
+	return dh, mockedQueue, mockedDiscord, channels
+}
+
+func sendMessageToChannel(t *testing.T, channel chan *message.Message, eventMsg []byte) {
+	newMessage := message.NewMessage(watermill.NewUUID(), eventMsg)
+	channel <- newMessage
+
+	require.Eventually(t, func() bool {
+		<-newMessage.Acked()
+
+		return true
+	}, time.Second, time.Millisecond)
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 
 	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/handlers"
 	"github.com/quintodown/quintodownbot/internal/pubsub"
 	"github.com/sirupsen/logrus"
 )
 
 type ErrorHandler struct {
+	handlers.Workers
+
 	log *logrus.Logger
 	q   pubsub.Queue
 }
@@ -25,9 +28,11 @@ func (eh *ErrorHandler) ExecuteHandlers(ctx context.Context) {
 	messages, err := eh.q.Subscribe(ctx, pubsub.ErrorTopic.String())
 	if err != nil {
 		eh.log.Error(err)
+
+		return
 	}
 
-	go func() {
+	eh.Go(func() {
 		for msg := range messages {
 			var m pubsub.ErrorEvent
 
@@ -41,9 +46,17 @@ func (eh *ErrorHandler) ExecuteHandlers(ctx context.Context) {
 			eh.log.Error(m.Err)
 			msg.Ack()
 		}
-	}()
+	})
 }
 
 func (eh *ErrorHandler) StopNotifications() {
 	return
 }
+
+func (eh *ErrorHandler) ResumeNotifications() {
+	return
+}
+
+func (eh *ErrorHandler) IsPaused() bool {
+	return false
+}
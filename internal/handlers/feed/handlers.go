@@ -0,0 +1,194 @@
+package handlersfeed
+
+import (
+	"context"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/feeds"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/metrics"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+type Client interface {
+	Fetch(context.Context, string) ([]feeds.Item, error)
+	Download(context.Context, string) ([]byte, error)
+}
+
+type Repository interface {
+	Polled(string) (bool, error)
+	MarkPolled(string) error
+	Seen(string, string) (bool, error)
+	MarkSeen(string, string) error
+}
+
+type Config struct {
+	Name         string
+	URL          string
+	Template     *template.Template
+	PollInterval time.Duration
+}
+
+type Feed struct {
+	handlers.NotificationState
+	handlers.Workers
+
+	fc Client
+	r  Repository
+	c  Config
+	q  pubsub.Queue
+}
+
+type Option func(f *Feed)
+
+func WithFeedsClient(fc Client) Option {
+	return func(f *Feed) {
+		f.fc = fc
+	}
+}
+
+func WithRepository(r Repository) Option {
+	return func(f *Feed) {
+		f.r = r
+	}
+}
+
+func WithConfig(c Config) Option {
+	return func(f *Feed) {
+		f.c = c
+	}
+}
+
+func WithQueue(q pubsub.Queue) Option {
+	return func(f *Feed) {
+		f.q = q
+	}
+}
+
+func NewFeed(options ...Option) *Feed {
+	f := &Feed{}
+
+	for _, o := range options {
+		o(f)
+	}
+
+	return f
+}
+
+func (f *Feed) ID() string {
+	return "feed-" + f.c.Name
+}
+
+func (f *Feed) ExecuteHandlers(ctx context.Context) {
+	f.Go(func() {
+		ticker := time.NewTicker(f.c.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			if !f.IsPaused() {
+				f.poll(ctx)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+func (f *Feed) poll(ctx context.Context) {
+	items, err := f.fc.Fetch(ctx, f.c.URL)
+	if err != nil {
+		f.fail(err)
+
+		return
+	}
+
+	polled, err := f.r.Polled(f.c.Name)
+	if err != nil {
+		f.fail(err)
+
+		return
+	}
+
+	for i := len(items) - 1; i >= 0; i-- {
+		if err := f.process(ctx, items[i], polled); err != nil {
+			f.fail(err)
+
+			return
+		}
+	}
+
+	if !polled {
+		if err := f.r.MarkPolled(f.c.Name); err != nil {
+			f.fail(err)
+		}
+	}
+}
+
+func (f *Feed) fail(err error) {
+	handlers.SendError(f.q, err)
+	metrics.HandlerFailed(f.ID())
+}
+
+func (f *Feed) process(ctx context.Context, item feeds.Item, publish bool) error {
+	seen, err := f.r.Seen(f.c.Name, item.ID)
+	if err != nil || seen {
+		return err
+	}
+
+	if publish {
+		if err := f.publish(ctx, item); err != nil {
+			return err
+		}
+	}
+
+	return f.r.MarkSeen(f.c.Name, item.ID)
+}
+
+func (f *Feed) publish(ctx context.Context, item feeds.Item) error {
+	var text strings.Builder
+	if err := f.c.Template.Execute(&text, templateData{Feed: f.c.Name, Item: item}); err != nil {
+		return err
+	}
+
+	source := f.ID() + ":" + item.ID
+
+	if item.ImageURL == "" {
+		mb, _ := easyjson.Marshal(pubsub.TextEvent{
+			Text:   strings.TrimSpace(text.String()),
+			Source: source,
+			Kind:   pubsub.FeedKind,
+		})
+
+		return f.q.Publish(pubsub.TextTopic.String(), message.NewMessage(watermill.NewUUID(), mb))
+	}
+
+	image, err := f.fc.Download(ctx, item.ImageURL)
+	if err != nil {
+		return err
+	}
+
+	mb, _ := easyjson.Marshal(pubsub.PhotoEvent{
+		Caption:     strings.TrimSpace(text.String()),
+		FileURL:     item.ImageURL,
+		FileSize:    int64(len(image)),
+		FileContent: image,
+		Source:      source,
+		Kind:        pubsub.FeedKind,
+	})
+
+	return f.q.Publish(pubsub.PhotoTopic.String(), message.NewMessage(watermill.NewUUID(), mb))
+}
+
+type templateData struct {
+	feeds.Item
+	Feed string
+}
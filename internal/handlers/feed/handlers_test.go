@@ -0,0 +1,213 @@
+package handlersfeed_test
+
+import (
+	"context"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/feeds"
+	hf "github.com/quintodown/quintodownbot/internal/handlers/feed"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	mf "github.com/quintodown/quintodownbot/mocks/handlers/feed"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const feedURL = "https://quintodown.com/feed"
+
+type fetchError struct{}
+
+func (f fetchError) Error() string {
+	return "error fetching feed"
+}
+
+type downloadError struct{}
+
+func (d downloadError) Error() string {
+	return "error downloading image"
+}
+
+var (
+	oldItem = feeds.Item{ID: "1", Title: "Previa de la semana 5", Link: "https://quintodown.com/blog/previa"}
+	newItem = feeds.Item{ID: "2", Title: "Resumen de la semana 5", Link: "https://quintodown.com/blog/resumen"}
+)
+
+func TestFeed_ID(t *testing.T) {
+	fh := hf.NewFeed(hf.WithConfig(hf.Config{Name: "blog"}))
+
+	require.Equal(t, "feed-blog", fh.ID())
+}
+
+func TestFeed_ExecuteHandlers(t *testing.T) {
+	t.Run("it should remember existing items on first poll", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		fh, mockedQueue, mockedClient, mockedRepository := getFeedHandlerAndMocks()
+
+		mockedClient.On("Fetch", ctx, feedURL).Once().Return([]feeds.Item{newItem, oldItem}, nil)
+		mockedRepository.On("Polled", "blog").Once().Return(false, nil)
+		mockedRepository.On("Seen", "blog", "1").Once().Return(false, nil)
+		mockedRepository.On("MarkSeen", "blog", "1").Once().Return(nil)
+		mockedRepository.On("Seen", "blog", "2").Once().Return(false, nil)
+		mockedRepository.On("MarkSeen", "blog", "2").Once().Return(nil)
+		mockedRepository.On("MarkPolled", "blog").Once().Return(nil).Run(func(mock.Arguments) { cancel() })
+
+		fh.ExecuteHandlers(ctx)
+		fh.Wait()
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+		mockedRepository.AssertExpectations(t)
+	})
+
+	t.Run("it should publish new items as texts", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		fh, mockedQueue, mockedClient, mockedRepository := getFeedHandlerAndMocks()
+
+		mockedClient.On("Fetch", ctx, feedURL).Once().Return([]feeds.Item{newItem, oldItem}, nil)
+		mockedRepository.On("Polled", "blog").Once().Return(true, nil)
+		mockedRepository.On("Seen", "blog", "1").Once().Return(true, nil)
+		mockedRepository.On("Seen", "blog", "2").Once().Return(false, nil)
+		mockedQueue.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			var e pubsub.TextEvent
+
+			return easyjson.Unmarshal(m.Payload, &e) == nil &&
+				e.Text == "blog: Resumen de la semana 5 https://quintodown.com/blog/resumen" &&
+				e.Source == "feed-blog:2" &&
+				e.Kind == pubsub.FeedKind
+		})).Once().
+			Return(nil)
+		mockedRepository.On("MarkSeen", "blog", "2").Once().Return(nil).Run(func(mock.Arguments) { cancel() })
+
+		fh.ExecuteHandlers(ctx)
+		fh.Wait()
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+		mockedRepository.AssertExpectations(t)
+	})
+
+	t.Run("it should publish items with images as photos", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		fh, mockedQueue, mockedClient, mockedRepository := getFeedHandlerAndMocks()
+		photoContent := []byte("\x89PNG\x0D\x0A\x1A\x0A")
+		item := newItem
+		item.ImageURL = "https://quintodown.com/blog/resumen.png"
+
+		mockedClient.On("Fetch", ctx, feedURL).Once().Return([]feeds.Item{item}, nil)
+		mockedClient.On("Download", ctx, item.ImageURL).Once().Return(photoContent, nil)
+		mockedRepository.On("Polled", "blog").Once().Return(true, nil)
+		mockedRepository.On("Seen", "blog", "2").Once().Return(false, nil)
+		mockedQueue.On("Publish", pubsub.PhotoTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			var e pubsub.PhotoEvent
+
+			return easyjson.Unmarshal(m.Payload, &e) == nil &&
+				e.Caption == "blog: Resumen de la semana 5 https://quintodown.com/blog/resumen" &&
+				e.FileURL == item.ImageURL &&
+				e.FileSize == int64(len(photoContent)) &&
+				string(e.FileContent) == string(photoContent) &&
+				e.Source == "feed-blog:2" &&
+				e.Kind == pubsub.FeedKind
+		})).Once().
+			Return(nil)
+		mockedRepository.On("MarkSeen", "blog", "2").Once().Return(nil).Run(func(mock.Arguments) { cancel() })
+
+		fh.ExecuteHandlers(ctx)
+		fh.Wait()
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+		mockedRepository.AssertExpectations(t)
+	})
+
+	t.Run("it should not mark item as seen when image download fails", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		fh, mockedQueue, mockedClient, mockedRepository := getFeedHandlerAndMocks()
+		item := newItem
+		item.ImageURL = "https://quintodown.com/blog/resumen.png"
+
+		mockedClient.On("Fetch", ctx, feedURL).Once().Return([]feeds.Item{item}, nil)
+		mockedClient.On("Download", ctx, item.ImageURL).Once().Return(nil, downloadError{})
+		mockedRepository.On("Polled", "blog").Once().Return(true, nil)
+		mockedRepository.On("Seen", "blog", "2").Once().Return(false, nil)
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"error downloading image\"}"
+		})).Once().
+			Return(nil).
+			Run(func(mock.Arguments) { cancel() })
+
+		fh.ExecuteHandlers(ctx)
+		fh.Wait()
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+		mockedRepository.AssertExpectations(t)
+		mockedRepository.AssertNotCalled(t, "MarkSeen", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should send error when feed can't be fetched", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		fh, mockedQueue, mockedClient, mockedRepository := getFeedHandlerAndMocks()
+
+		mockedClient.On("Fetch", ctx, feedURL).Once().Return(nil, fetchError{})
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"error fetching feed\"}"
+		})).Once().
+			Return(nil).
+			Run(func(mock.Arguments) { cancel() })
+
+		fh.ExecuteHandlers(ctx)
+		fh.Wait()
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+		mockedRepository.AssertNotCalled(t, "Polled", mock.Anything)
+	})
+
+	t.Run("it should not poll while paused", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		fh, mockedQueue, mockedClient, _ := getFeedHandlerAndMocks()
+
+		fh.StopNotifications()
+		fh.ExecuteHandlers(ctx)
+		fh.Wait()
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertNotCalled(t, "Fetch", mock.Anything, mock.Anything)
+	})
+}
+
+func getFeedHandlerAndMocks() (*hf.Feed, *mq.Queue, *mf.Client, *mf.Repository) {
+	mockedQueue := new(mq.Queue)
+	mockedClient := new(mf.Client)
+	mockedRepository := new(mf.Repository)
+
+	fh := hf.NewFeed(
+		hf.WithQueue(mockedQueue),
+		hf.WithFeedsClient(mockedClient),
+		hf.WithRepository(mockedRepository),
+		hf.WithConfig(hf.Config{
+			Name:         "blog",
+			URL:          feedURL,
+			Template:     template.Must(template.New("blog").Parse("{{.Feed}}: {{.Title}} {{.Link}}")),
+			PollInterval: time.Hour,
+		}),
+	)
+
+	return fh, mockedQueue, mockedClient, mockedRepository
+}
@@ -14,10 +14,12 @@ import (
 )
 
 type Games struct {
-	gh           games.Handler
-	c            Config
-	q            pubsub.Queue
-	shouldNotify bool
+	handlers.NotificationState
+	handlers.Workers
+
+	gh games.Handler
+	c  Config
+	q  pubsub.Queue
 }
 
 type Config struct {
@@ -46,7 +48,7 @@ func WithQueue(q pubsub.Queue) Option {
 }
 
 func NewGames(options ...Option) *Games {
-	g := &Games{shouldNotify: true}
+	g := &Games{}
 
 	for _, o := range options {
 		o(g)
@@ -59,10 +61,6 @@ func (g *Games) ID() string {
 	return "games"
 }
 
-func (g *Games) StopNotifications() {
-	g.shouldNotify = false
-}
-
 func (g *Games) ExecuteHandlers(ctx context.Context) {
 	g.updateGamesInformation(ctx)
 	g.updateGameList(ctx)
@@ -76,36 +74,44 @@ func (g *Games) updateGamesInformation(ctx context.Context) {
 		return
 	}
 
-	go func() {
+	g.Go(func() {
+		ticker := time.NewTicker(g.c.UpdateGamesInformationTicker)
+		defer ticker.Stop()
+
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-time.Tick(g.c.UpdateGamesInformationTicker):
+			case <-ticker.C:
 				g.gh.UpdateGamesInformation(true)
 			}
 		}
-	}()
+	})
 
-	go g.sendGameUpdate(messages)
+	g.Go(func() {
+		g.sendGameUpdate(messages)
+	})
 }
 
 func (g *Games) updateGameList(ctx context.Context) {
-	go func() {
+	g.Go(func() {
+		ticker := time.NewTicker(g.c.UpdateGamesListTicker)
+		defer ticker.Stop()
+
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-time.Tick(g.c.UpdateGamesListTicker):
+			case <-ticker.C:
 				g.gh.UpdateGamesList()
 			}
 		}
-	}()
+	})
 }
 
 func (g *Games) sendGameUpdate(messages <-chan *message.Message) {
 	for msg := range messages {
-		if !g.shouldNotify {
+		if g.IsPaused() {
 			msg.Ack()
 
 			continue
@@ -133,7 +139,7 @@ func (g *Games) sendGameUpdate(messages <-chan *message.Message) {
 			continue
 		}
 
-		mb, _ := easyjson.Marshal(pubsub.TextEvent{Text: gameText})
+		mb, _ := easyjson.Marshal(pubsub.TextEvent{Text: gameText, Kind: pubsub.GameKind})
 
 		if err := g.q.Publish(pubsub.TextTopic.String(), message.NewMessage(watermill.NewUUID(), mb)); err != nil {
 			handlers.SendError(g.q, err)
@@ -167,7 +167,7 @@ func TestGames_ExecuteHandlersGamesFails(t *testing.T) {
 		gh.On("UpdateGamesList").Once().Run(func(mock.Arguments) { called <- true })
 		q.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(message *message.Message) bool {
 			return string(message.Payload) == "{\"text\":\"#NFL El partido entre Away Team (2-1) vs Home Team (1-2) ha "+
-				"iniciado. Se juega en  (TestCity, TestState)\"}"
+				"iniciado. Se juega en  (TestCity, TestState)\",\"kind\":\"game\"}"
 		})).Once().Return(errors.New("error sending message to queue"))
 		q.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(message *message.Message) bool {
 			return string(message.Payload) == "{\"error\":\"error sending message to queue\"}"
@@ -197,7 +197,7 @@ func TestGames_ExecuteHandlersGames(t *testing.T) {
 				},
 			},
 			payload: "{\"text\":\"#NFL El partido entre Away Team (2-1) vs Home Team (1-2) ha " +
-				"iniciado. Se juega en  (TestCity, TestState)\"}",
+				"iniciado. Se juega en  (TestCity, TestState)\",\"kind\":\"game\"}",
 		},
 		"it sends game message when game finished": {
 			gameEvent: pubsub.GameEvent{
@@ -207,7 +207,7 @@ func TestGames_ExecuteHandlersGames(t *testing.T) {
 				AwayTeam:       pubsub.TeamScore{Name: "Away Team", Score: 2, Record: "2-1"},
 			},
 			payload: "{\"text\":\"#NFL El partido entre Away Team (2-1) vs Home Team (1-2) ha " +
-				"finalizado con el resultado de 2 - 1\"}",
+				"finalizado con el resultado de 2 - 1\",\"kind\":\"game\"}",
 		},
 	}
 
@@ -2,43 +2,77 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/bot"
 	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
 )
 
+const pausedHandlersBucket = "pausedhandlers"
+
+var errNotRunning = errors.New("handlers not running")
+
 type EventHandler interface {
 	ID() string
 	ExecuteHandlers(context.Context)
 	StopNotifications()
+	ResumeNotifications()
+	IsPaused() bool
+	Running() bool
+	Wait()
+}
+
+type HandlerStatus struct {
+	ID     string
+	Paused bool
 }
 
 type Manager struct {
-	q  pubsub.Queue
-	hs []EventHandler
+	Workers
+
+	q      pubsub.Queue
+	b      bot.TelegramBot
+	s      storage.Store
+	hs     []EventHandler
+	cancel context.CancelFunc
 }
 
-func NewHandlersManager(q pubsub.Queue, hs ...EventHandler) *Manager {
-	return &Manager{q: q, hs: hs}
+func NewHandlersManager(q pubsub.Queue, b bot.TelegramBot, s storage.Store, hs ...EventHandler) *Manager {
+	return &Manager{q: q, b: b, s: s, hs: hs}
 }
 
 func (hm *Manager) StartHandlers(ctx context.Context) {
+	ctx, hm.cancel = context.WithCancel(ctx)
+
+	hm.restoreNotifications()
+
 	for _, v := range hm.hs {
-		v.ExecuteHandlers(ctx)
+		v.ExecuteHandlers(pubsub.WithSubscriber(ctx, v.ID()))
 	}
 
 	hm.StopNotifications(ctx)
+
+	if s, ok := hm.q.(pubsub.Starter); ok {
+		if err := s.Started(); err != nil {
+			SendError(hm.q, err)
+		}
+	}
 }
 
 func (hm *Manager) StopNotifications(ctx context.Context) {
-	messages, err := hm.q.Subscribe(ctx, pubsub.CommandTopic.String())
+	messages, err := hm.q.Subscribe(pubsub.WithSubscriber(ctx, "manager"), pubsub.CommandTopic.String())
 	if err != nil {
 		return
 	}
 
-	go func() {
+	hm.Go(func() {
 		for msg := range messages {
 			var m pubsub.CommandEvent
 			if err := easyjson.Unmarshal(msg.Payload, &m); err != nil {
@@ -48,17 +82,140 @@ func (hm *Manager) StopNotifications(ctx context.Context) {
 				continue
 			}
 
-			if m.Command == pubsub.StopCommand {
-				for i := range hm.hs {
-					if m.Handler == hm.hs[i].ID() || m.Handler == "" {
-						hm.hs[i].StopNotifications()
-					}
+			switch m.Command {
+			case pubsub.StopCommand:
+				hm.forHandler(m.Handler, EventHandler.StopNotifications)
+			case pubsub.StartCommand:
+				hm.forHandler(m.Handler, EventHandler.ResumeNotifications)
+			case pubsub.StatusCommand:
+				if err := hm.b.Send(m.ReplyTo, hm.statusText()); err != nil {
+					SendError(hm.q, err)
 				}
 			}
 
 			msg.Ack()
 		}
+	})
+}
+
+func (hm *Manager) Stop(ctx context.Context) error {
+	if hm.cancel != nil {
+		hm.cancel()
+	}
+
+	var errs []error
+
+	if err := waitHandler(ctx, hm); err != nil {
+		errs = append(errs, fmt.Errorf("error draining commands: %w", err))
+	}
+
+	for _, h := range hm.hs {
+		if err := waitHandler(ctx, h); err != nil {
+			errs = append(errs, fmt.Errorf("error draining %s handler: %w", h.ID(), err))
+		}
+	}
+
+	if err := hm.q.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("error closing queue: %w", err))
+	}
+
+	if err := hm.s.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("error closing storage: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (hm *Manager) Status() []HandlerStatus {
+	status := make([]HandlerStatus, 0, len(hm.hs))
+
+	for i := range hm.hs {
+		status = append(status, HandlerStatus{ID: hm.hs[i].ID(), Paused: hm.hs[i].IsPaused()})
+	}
+
+	return status
+}
+
+func (hm *Manager) Check() error {
+	var stopped []string
+
+	for i := range hm.hs {
+		if !hm.hs[i].Running() {
+			stopped = append(stopped, hm.hs[i].ID())
+		}
+	}
+
+	if len(stopped) > 0 {
+		return fmt.Errorf("%w: %s", errNotRunning, strings.Join(stopped, ", "))
+	}
+
+	return nil
+}
+
+func (hm *Manager) forHandler(handler string, f func(EventHandler)) {
+	for i := range hm.hs {
+		if handler == hm.hs[i].ID() || handler == "" {
+			f(hm.hs[i])
+			hm.saveNotifications(hm.hs[i])
+		}
+	}
+}
+
+func (hm *Manager) saveNotifications(h EventHandler) {
+	if err := hm.s.Put(pausedHandlersBucket, h.ID(), []byte(strconv.FormatBool(h.IsPaused()))); err != nil {
+		SendError(hm.q, err)
+	}
+}
+
+func (hm *Manager) restoreNotifications() {
+	for i := range hm.hs {
+		v, err := hm.s.Get(pausedHandlersBucket, hm.hs[i].ID())
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+
+		if err != nil {
+			SendError(hm.q, err)
+
+			continue
+		}
+
+		if paused, _ := strconv.ParseBool(string(v)); paused {
+			hm.hs[i].StopNotifications()
+		}
+	}
+}
+
+func (hm *Manager) statusText() string {
+	var sb strings.Builder
+
+	for _, s := range hm.Status() {
+		state := "active"
+		if s.Paused {
+			state = "paused"
+		}
+
+		sb.WriteString(s.ID + ": " + state + "\n")
+	}
+
+	return sb.String()
+}
+
+func waitHandler(ctx context.Context, h interface{ Wait() }) error {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		h.Wait()
 	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func SendError(q pubsub.Queue, err error) {
@@ -0,0 +1,269 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	mb "github.com/quintodown/quintodownbot/mocks/bot"
+	mh "github.com/quintodown/quintodownbot/mocks/handlers"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	ms "github.com/quintodown/quintodownbot/mocks/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type storeClosedError struct{}
+
+func (m storeClosedError) Error() string {
+	return "store closed"
+}
+
+type managerMocks struct {
+	queue    *mq.Queue
+	bot      *mb.TelegramBot
+	store    *ms.Store
+	telegram *mh.EventHandler
+	twitter  *mh.EventHandler
+	commands chan *message.Message
+}
+
+func TestManager_StartHandlers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should restore paused handlers", func(t *testing.T) {
+		hm, mocks := generateManagerAndMocks(ctx, false)
+
+		mocks.store.On("Get", "pausedhandlers", "telegram").Once().Return(nil, storage.ErrNotFound)
+		mocks.store.On("Get", "pausedhandlers", "twitter").Once().Return([]byte("true"), nil)
+		mocks.twitter.On("StopNotifications").Once()
+
+		hm.StartHandlers(ctx)
+
+		mocks.store.AssertExpectations(t)
+		mocks.telegram.AssertNotCalled(t, "StopNotifications")
+		mocks.twitter.AssertExpectations(t)
+	})
+
+	t.Run("it should keep active handlers resumed before restarting", func(t *testing.T) {
+		hm, mocks := generateManagerAndMocks(ctx, false)
+
+		mocks.store.On("Get", "pausedhandlers", "telegram").Once().Return([]byte("false"), nil)
+		mocks.store.On("Get", "pausedhandlers", "twitter").Once().Return([]byte("false"), nil)
+
+		hm.StartHandlers(ctx)
+
+		mocks.store.AssertExpectations(t)
+		mocks.telegram.AssertNotCalled(t, "StopNotifications")
+		mocks.twitter.AssertNotCalled(t, "StopNotifications")
+	})
+
+	t.Run("it should send an error when handler state couldn't be restored", func(t *testing.T) {
+		hm, mocks := generateManagerAndMocks(ctx, false)
+
+		mocks.store.On("Get", "pausedhandlers", "telegram").Once().Return(nil, storeClosedError{})
+		mocks.store.On("Get", "pausedhandlers", "twitter").Once().Return(nil, storage.ErrNotFound)
+		mocks.queue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"store closed\"}"
+		})).Once().Return(nil)
+
+		hm.StartHandlers(ctx)
+
+		mocks.queue.AssertExpectations(t)
+		mocks.telegram.AssertNotCalled(t, "StopNotifications")
+	})
+}
+
+func TestManager_Commands(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should stop notifications for all handlers", func(t *testing.T) {
+		hm, mocks := generateManagerAndMocks(ctx, true)
+
+		mocks.telegram.On("StopNotifications").Once()
+		mocks.telegram.On("IsPaused").Once().Return(true)
+		mocks.twitter.On("StopNotifications").Once()
+		mocks.twitter.On("IsPaused").Once().Return(true)
+		mocks.store.On("Put", "pausedhandlers", "telegram", []byte("true")).Once().Return(nil)
+		mocks.store.On("Put", "pausedhandlers", "twitter", []byte("true")).Once().Return(nil)
+
+		hm.StartHandlers(ctx)
+		sendCommand(t, mocks.commands, pubsub.CommandEvent{Command: pubsub.StopCommand})
+
+		mocks.telegram.AssertExpectations(t)
+		mocks.twitter.AssertExpectations(t)
+		mocks.store.AssertExpectations(t)
+	})
+
+	t.Run("it should resume notifications for specific handler", func(t *testing.T) {
+		hm, mocks := generateManagerAndMocks(ctx, true)
+
+		mocks.twitter.On("ResumeNotifications").Once()
+		mocks.twitter.On("IsPaused").Once().Return(false)
+		mocks.store.On("Put", "pausedhandlers", "twitter", []byte("false")).Once().Return(nil)
+
+		hm.StartHandlers(ctx)
+		sendCommand(t, mocks.commands, pubsub.CommandEvent{Command: pubsub.StartCommand, Handler: "twitter"})
+
+		mocks.telegram.AssertNotCalled(t, "ResumeNotifications")
+		mocks.twitter.AssertExpectations(t)
+		mocks.store.AssertExpectations(t)
+	})
+
+	t.Run("it should send an error when handler state couldn't be saved", func(t *testing.T) {
+		hm, mocks := generateManagerAndMocks(ctx, true)
+
+		mocks.twitter.On("StopNotifications").Once()
+		mocks.twitter.On("IsPaused").Once().Return(true)
+		mocks.store.On("Put", "pausedhandlers", "twitter", []byte("true")).Once().Return(storeClosedError{})
+		mocks.queue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"store closed\"}"
+		})).Once().Return(nil)
+
+		hm.StartHandlers(ctx)
+		sendCommand(t, mocks.commands, pubsub.CommandEvent{Command: pubsub.StopCommand, Handler: "twitter"})
+
+		mocks.twitter.AssertExpectations(t)
+		mocks.store.AssertExpectations(t)
+		mocks.queue.AssertExpectations(t)
+	})
+
+	t.Run("it should reply with handlers status", func(t *testing.T) {
+		hm, mocks := generateManagerAndMocks(ctx, true)
+
+		mocks.telegram.On("IsPaused").Once().Return(false)
+		mocks.twitter.On("IsPaused").Once().Return(true)
+		mocks.bot.On("Send", "1234", "telegram: active\ntwitter: paused\n").Once().Return(nil)
+
+		hm.StartHandlers(ctx)
+		sendCommand(t, mocks.commands, pubsub.CommandEvent{Command: pubsub.StatusCommand, ReplyTo: "1234"})
+
+		mocks.bot.AssertExpectations(t)
+	})
+}
+
+func TestManager_Status(t *testing.T) {
+	telegram := new(mh.EventHandler)
+	telegram.On("ID").Return("telegram")
+	telegram.On("IsPaused").Once().Return(true)
+
+	hm := handlers.NewHandlersManager(nil, nil, nil, telegram)
+
+	require.Equal(t, []handlers.HandlerStatus{{ID: "telegram", Paused: true}}, hm.Status())
+}
+
+func TestManager_Check(t *testing.T) {
+	telegram := new(mh.EventHandler)
+	telegram.On("ID").Return("telegram")
+	twitter := new(mh.EventHandler)
+	twitter.On("ID").Return("twitter")
+	games := new(mh.EventHandler)
+	games.On("ID").Return("games")
+
+	hm := handlers.NewHandlersManager(nil, nil, nil, telegram, twitter, games)
+
+	t.Run("it should be ok when every handler is running", func(t *testing.T) {
+		telegram.On("Running").Once().Return(true)
+		twitter.On("Running").Once().Return(true)
+		games.On("Running").Once().Return(true)
+
+		require.NoError(t, hm.Check())
+	})
+
+	t.Run("it should fail with the handlers not running", func(t *testing.T) {
+		telegram.On("Running").Once().Return(true)
+		twitter.On("Running").Once().Return(false)
+		games.On("Running").Once().Return(false)
+
+		require.EqualError(t, hm.Check(), "handlers not running: twitter, games")
+	})
+}
+
+func TestManager_Stop(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should wait for handlers to finish and close queue and storage", func(t *testing.T) {
+		hm, mocks := generateManagerAndMocks(ctx, true)
+
+		mocks.telegram.On("Wait").Once()
+		mocks.twitter.On("Wait").Once()
+		mocks.queue.On("Close").Once().Return(nil)
+		mocks.store.On("Close").Once().Return(nil)
+
+		hm.StartHandlers(ctx)
+		close(mocks.commands)
+
+		require.NoError(t, hm.Stop(ctx))
+		mocks.telegram.AssertExpectations(t)
+		mocks.twitter.AssertExpectations(t)
+		mocks.queue.AssertExpectations(t)
+		mocks.store.AssertExpectations(t)
+	})
+
+	t.Run("it should fail when handlers don't finish in time", func(t *testing.T) {
+		hm, mocks := generateManagerAndMocks(ctx, true)
+
+		mocks.telegram.On("Wait").Once()
+		mocks.twitter.On("Wait").Once().WaitUntil(time.After(time.Second))
+		mocks.queue.On("Close").Once().Return(nil)
+		mocks.store.On("Close").Once().Return(storeClosedError{})
+
+		hm.StartHandlers(ctx)
+		close(mocks.commands)
+
+		stopCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		require.EqualError(
+			t,
+			hm.Stop(stopCtx),
+			"error draining twitter handler: context deadline exceeded\nerror closing storage: store closed",
+		)
+	})
+}
+
+func generateManagerAndMocks(ctx context.Context, emptyStore bool) (*handlers.Manager, managerMocks) {
+	mocks := managerMocks{
+		queue:    new(mq.Queue),
+		bot:      new(mb.TelegramBot),
+		store:    new(ms.Store),
+		telegram: new(mh.EventHandler),
+		twitter:  new(mh.EventHandler),
+		commands: make(chan *message.Message),
+	}
+
+	mocks.telegram.On("ID").Return("telegram")
+	mocks.telegram.On("ExecuteHandlers", mock.Anything).Once()
+	mocks.twitter.On("ID").Return("twitter")
+	mocks.twitter.On("ExecuteHandlers", mock.Anything).Once()
+
+	if emptyStore {
+		mocks.store.On("Get", "pausedhandlers", mock.Anything).Return(nil, storage.ErrNotFound)
+	}
+
+	mocks.queue.On("Subscribe", mock.Anything, pubsub.CommandTopic.String()).
+		Once().
+		Return(func(context.Context, string) <-chan *message.Message {
+			return mocks.commands
+		}, nil)
+
+	return handlers.NewHandlersManager(mocks.queue, mocks.bot, mocks.store, mocks.telegram, mocks.twitter), mocks
+}
+
+func sendCommand(t *testing.T, channel chan *message.Message, ce pubsub.CommandEvent) {
+	eb, _ := easyjson.Marshal(ce)
+	newMessage := message.NewMessage(watermill.NewUUID(), eb)
+	channel <- newMessage
+
+	require.Eventually(t, func() bool {
+		<-newMessage.Acked()
+
+		return true
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,53 @@
+package handlersmastodon
+
+import (
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+type Mastodon struct {
+	*handlers.Social
+
+	mc handlers.SocialClient
+	q  pubsub.Queue
+	ps handlers.Posts
+	rp handlers.RetryPolicy
+}
+
+type Option func(m *Mastodon)
+
+func WithMastodonClient(mc handlers.SocialClient) Option {
+	return func(m *Mastodon) {
+		m.mc = mc
+	}
+}
+
+func WithQueue(q pubsub.Queue) Option {
+	return func(m *Mastodon) {
+		m.q = q
+	}
+}
+
+func WithPosts(ps handlers.Posts) Option {
+	return func(m *Mastodon) {
+		m.ps = ps
+	}
+}
+
+func WithRetryPolicy(rp handlers.RetryPolicy) Option {
+	return func(m *Mastodon) {
+		m.rp = rp
+	}
+}
+
+func NewMastodon(options ...Option) *Mastodon {
+	m := &Mastodon{}
+
+	for _, o := range options {
+		o(m)
+	}
+
+	m.Social = handlers.NewSocial("mastodon", m.mc, m.q, m.ps, m.rp)
+
+	return m
+}
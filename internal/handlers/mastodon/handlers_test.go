@@ -0,0 +1,62 @@
+package handlersmastodon_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	hm "github.com/quintodown/quintodownbot/internal/handlers/mastodon"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	mh "github.com/quintodown/quintodownbot/mocks/handlers"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMastodon_ID(t *testing.T) {
+	h := hm.NewMastodon(hm.WithMastodonClient(new(mh.SocialClient)), hm.WithQueue(new(mq.Queue)))
+
+	require.Equal(t, "mastodon", h.ID())
+}
+
+func TestMastodon_ExecuteHandlers(t *testing.T) {
+	ctx := context.Background()
+	mockedClient := new(mh.SocialClient)
+	mockedQueue := new(mq.Queue)
+	channels := map[pubsub.TopicName]chan *message.Message{}
+
+	for _, topic := range []pubsub.TopicName{pubsub.TextTopic, pubsub.PhotoTopic, pubsub.DeleteTopic} {
+		channel := make(chan *message.Message)
+		channels[topic] = channel
+
+		mockedQueue.On("Subscribe", ctx, topic.String()).
+			Once().
+			Return(func(context.Context, string) <-chan *message.Message {
+				return channel
+			}, nil)
+	}
+
+	mockedClient.On("SendUpdate", "testing message").Once().Return([]string{"1"}, nil)
+
+	h := hm.NewMastodon(
+		hm.WithMastodonClient(mockedClient),
+		hm.WithQueue(mockedQueue),
+		hm.WithRetryPolicy(handlers.RetryPolicy{MaxAttempts: 1}),
+	)
+	h.ExecuteHandlers(ctx)
+
+	payload := "{\"text\":\"testing message\",\"destinations\":[\"mastodon\"]}"
+	msg := message.NewMessage(watermill.NewUUID(), []byte(payload))
+	channels[pubsub.TextTopic] <- msg
+
+	require.Eventually(t, func() bool {
+		<-msg.Acked()
+
+		return true
+	}, time.Second, time.Millisecond)
+
+	mockedQueue.AssertExpectations(t)
+	mockedClient.AssertExpectations(t)
+}
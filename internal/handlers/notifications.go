@@ -0,0 +1,19 @@
+package handlers
+
+import "sync/atomic"
+
+type NotificationState struct {
+	paused atomic.Bool
+}
+
+func (n *NotificationState) StopNotifications() {
+	n.paused.Store(true)
+}
+
+func (n *NotificationState) ResumeNotifications() {
+	n.paused.Store(false)
+}
+
+func (n *NotificationState) IsPaused() bool {
+	return n.paused.Load()
+}
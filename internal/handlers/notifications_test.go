@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationState(t *testing.T) {
+	t.Run("it should be active by default", func(t *testing.T) {
+		var n handlers.NotificationState
+
+		require.False(t, n.IsPaused())
+	})
+
+	t.Run("it should pause and resume notifications", func(t *testing.T) {
+		var n handlers.NotificationState
+
+		n.StopNotifications()
+		require.True(t, n.IsPaused())
+
+		n.ResumeNotifications()
+		require.False(t, n.IsPaused())
+	})
+
+	t.Run("it should toggle notifications while being read concurrently", func(t *testing.T) {
+		var (
+			n  handlers.NotificationState
+			wg sync.WaitGroup
+		)
+
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+
+			go func() {
+				defer wg.Done()
+
+				for j := 0; j < 1000; j++ {
+					_ = n.IsPaused()
+				}
+			}()
+
+			go func(i int) {
+				defer wg.Done()
+
+				for j := 0; j < 1000; j++ {
+					if (i+j)%2 == 0 {
+						n.StopNotifications()
+					} else {
+						n.ResumeNotifications()
+					}
+				}
+			}(i)
+		}
+
+		wg.Wait()
+
+		n.ResumeNotifications()
+		require.False(t, n.IsPaused())
+	})
+}
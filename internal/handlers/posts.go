@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/javiyt/twitter-text-go/extract"
+	"github.com/quintodown/quintodownbot/internal/config"
+	"github.com/quintodown/quintodownbot/internal/posts"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
+)
+
+type Posts interface {
+	Get(string) (posts.Post, error)
+	Save(string, string, []string) error
+	SaveUnfinished(string, string, []string) error
+	Remove(string, string) error
+}
+
+func SavePost(q pubsub.Queue, ps Posts, source, handler string, ids []string) {
+	if source == "" || ps == nil {
+		return
+	}
+
+	if err := ps.Save(source, handler, ids); err != nil {
+		SendError(q, err)
+	}
+}
+
+// SaveUnfinished keeps the messages sent before a thread failed, so retrying it continues from the last one.
+func SaveUnfinished(q pubsub.Queue, ps Posts, source, handler string, ids []string) {
+	if source == "" || ps == nil || len(ids) == 0 {
+		return
+	}
+
+	if err := ps.SaveUnfinished(source, handler, ids); err != nil {
+		SendError(q, err)
+	}
+}
+
+// PublishedMessages returns the messages of a handler for a post and whether it was completely published. Threads that
+// failed partway aren't published yet but still return the messages already sent.
+func PublishedMessages(ps Posts, source, handler string) ([]string, bool, error) {
+	if source == "" || ps == nil {
+		return nil, false, nil
+	}
+
+	p, err := ps.Get(source)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	ids, ok := p.Messages[handler]
+
+	return ids, ok && !p.IsUnfinished(handler), nil
+}
+
+type PublishedDestination struct {
+	Name string
+	Key  string
+	IDs  []string
+}
+
+func PostKey(handler, destination string) string {
+	if destination == config.DefaultDestination {
+		return handler
+	}
+
+	return handler + ":" + destination
+}
+
+func PublishedDestinations(ps Posts, source, handler string) ([]PublishedDestination, error) {
+	if source == "" || ps == nil {
+		return nil, nil
+	}
+
+	p, err := ps.Get(source)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	destinations := make([]PublishedDestination, 0, len(p.Messages))
+
+	for key, ids := range p.Messages {
+		switch {
+		case key == handler:
+			destinations = append(destinations, PublishedDestination{Name: config.DefaultDestination, Key: key, IDs: ids})
+		case strings.HasPrefix(key, handler+":"):
+			destinations = append(destinations, PublishedDestination{
+				Name: strings.TrimPrefix(key, handler+":"),
+				Key:  key,
+				IDs:  ids,
+			})
+		}
+	}
+
+	sort.Slice(destinations, func(i, j int) bool {
+		return destinations[i].Key < destinations[j].Key
+	})
+
+	return destinations, nil
+}
+
+func Hashtags(text string) []string {
+	entities := extract.ExtractHashtags(text)
+
+	hashtags := make([]string, 0, len(entities))
+	for _, e := range entities {
+		hashtags = append(hashtags, e.Text)
+	}
+
+	return hashtags
+}
@@ -0,0 +1,54 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/quintodown/quintodownbot/internal/config"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/posts"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	mh "github.com/quintodown/quintodownbot/mocks/handlers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostKey(t *testing.T) {
+	require.Equal(t, "telegram", handlers.PostKey("telegram", config.DefaultDestination))
+	require.Equal(t, "telegram:games", handlers.PostKey("telegram", "games"))
+}
+
+func TestPublishedDestinations(t *testing.T) {
+	t.Run("it should return nothing when the post is not found", func(t *testing.T) {
+		ps := new(mh.Posts)
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+
+		destinations, err := handlers.PublishedDestinations(ps, "12345:7", "twitter")
+
+		require.NoError(t, err)
+		require.Empty(t, destinations)
+	})
+
+	t.Run("it should return the destinations of the handler", func(t *testing.T) {
+		ps := new(mh.Posts)
+		ps.On("Get", "12345:7").Once().Return(posts.Post{
+			Source: "12345:7",
+			Messages: map[string][]string{
+				"twitter:scores": {"1"},
+				"twitter":        {"2"},
+				"telegram":       {"10"},
+				"twitterbot":     {"3"},
+			},
+		}, nil)
+
+		destinations, err := handlers.PublishedDestinations(ps, "12345:7", "twitter")
+
+		require.NoError(t, err)
+		require.Equal(t, []handlers.PublishedDestination{
+			{Name: config.DefaultDestination, Key: "twitter", IDs: []string{"2"}},
+			{Name: "scores", Key: "twitter:scores", IDs: []string{"1"}},
+		}, destinations)
+	})
+}
+
+func TestHashtags(t *testing.T) {
+	require.Equal(t, []string{"#NFL", "#Bucs"}, handlers.Hashtags("Touchdown #NFL #Bucs https://quintodown.com/#top"))
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/metrics"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+type Retrier struct {
+	q        pubsub.Queue
+	handler  string
+	policy   RetryPolicy
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func NewRetrier(q pubsub.Queue, handler string, policy RetryPolicy) *Retrier {
+	return &Retrier{q: q, handler: handler, policy: policy, attempts: map[string]int{}}
+}
+
+func (r *Retrier) Ack(msg *message.Message) {
+	r.mu.Lock()
+	delete(r.attempts, msg.UUID)
+	r.mu.Unlock()
+
+	msg.Ack()
+}
+
+// Fail retries the message after a backoff, or sends it to the dead letters once it's out of attempts. It reports
+// whether the message is going to be retried.
+func (r *Retrier) Fail(msg *message.Message, topic pubsub.TopicName, err error) bool {
+	SendError(r.q, err)
+	metrics.HandlerFailed(r.handler)
+
+	r.mu.Lock()
+	r.attempts[msg.UUID]++
+	attempts := r.attempts[msg.UUID]
+	retry := attempts < r.policy.MaxAttempts && !isPermanent(err)
+
+	if !retry {
+		delete(r.attempts, msg.UUID)
+	}
+	r.mu.Unlock()
+
+	if retry {
+		r.wait(msg.Context(), r.backoff(attempts))
+		msg.Nack()
+
+		return true
+	}
+
+	eb, _ := easyjson.Marshal(pubsub.DeadLetterEvent{
+		Handler:  r.handler,
+		Topic:    topic.String(),
+		Payload:  msg.Payload,
+		Error:    err.Error(),
+		Attempts: attempts,
+	})
+
+	if err := r.q.Publish(pubsub.DeadLetterTopic.String(), message.NewMessage(watermill.NewUUID(), eb)); err != nil {
+		SendError(r.q, err)
+	}
+
+	msg.Ack()
+
+	return false
+}
+
+func (r *Retrier) backoff(attempt int) time.Duration {
+	d := r.policy.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+
+		if r.policy.MaxBackoff > 0 && d >= r.policy.MaxBackoff {
+			return r.policy.MaxBackoff
+		}
+	}
+
+	return d
+}
+
+func (r *Retrier) wait(ctx context.Context, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	}
+}
+
+// isPermanent reports whether err will happen again no matter how many times the message is retried.
+func isPermanent(err error) bool {
+	var p interface{ Permanent() bool }
+
+	return errors.As(err, &p) && p.Permanent()
+}
+
+func IsAddressedTo(msg *message.Message, handler string) bool {
+	target := msg.Metadata.Get(pubsub.TargetHandlerMetadata)
+
+	return target == "" || target == handler
+}
@@ -0,0 +1,82 @@
+package handlersscheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+type Publisher interface {
+	PublishDue() error
+}
+
+type Scheduler struct {
+	handlers.NotificationState
+	handlers.Workers
+
+	q pubsub.Queue
+	p Publisher
+	c Config
+}
+
+type Config struct {
+	PublishDueTicker time.Duration
+}
+
+type Option func(s *Scheduler)
+
+func WithPublisher(p Publisher) Option {
+	return func(s *Scheduler) {
+		s.p = p
+	}
+}
+
+func WithQueue(q pubsub.Queue) Option {
+	return func(s *Scheduler) {
+		s.q = q
+	}
+}
+
+func WithConfig(c Config) Option {
+	return func(s *Scheduler) {
+		s.c = c
+	}
+}
+
+func NewScheduler(options ...Option) *Scheduler {
+	s := &Scheduler{}
+
+	for _, o := range options {
+		o(s)
+	}
+
+	return s
+}
+
+func (s *Scheduler) ID() string {
+	return "scheduler"
+}
+
+func (s *Scheduler) ExecuteHandlers(ctx context.Context) {
+	s.Go(func() {
+		ticker := time.NewTicker(s.c.PublishDueTicker)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if s.IsPaused() {
+					continue
+				}
+
+				if err := s.p.PublishDue(); err != nil {
+					handlers.SendError(s.q, err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package handlersscheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	hsc "github.com/quintodown/quintodownbot/internal/handlers/scheduler"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	msc "github.com/quintodown/quintodownbot/mocks/handlers/scheduler"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_ID(t *testing.T) {
+	require.Equal(t, "scheduler", hsc.NewScheduler().ID())
+}
+
+func TestScheduler_ExecuteHandlers(t *testing.T) {
+	t.Run("it should publish due posts periodically", func(t *testing.T) {
+		s, _, p := generateHandlerAndMocks()
+		published := make(chan struct{}, 1)
+		p.On("PublishDue").Return(nil).Run(func(mock.Arguments) {
+			select {
+			case published <- struct{}{}:
+			default:
+			}
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s.ExecuteHandlers(ctx)
+
+		require.Eventually(t, func() bool {
+			<-published
+
+			return true
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		s.Wait()
+	})
+
+	t.Run("it should send an error when due posts couldn't be published", func(t *testing.T) {
+		s, q, p := generateHandlerAndMocks()
+		p.On("PublishDue").Return(errors.New("store closed"))
+
+		notified := make(chan struct{}, 1)
+		q.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"store closed\"}"
+		})).Return(nil).Run(func(mock.Arguments) {
+			select {
+			case notified <- struct{}{}:
+			default:
+			}
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s.ExecuteHandlers(ctx)
+
+		require.Eventually(t, func() bool {
+			<-notified
+
+			return true
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		s.Wait()
+	})
+
+	t.Run("it should not publish due posts when paused", func(t *testing.T) {
+		s, _, p := generateHandlerAndMocks()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s.StopNotifications()
+		s.ExecuteHandlers(ctx)
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		s.Wait()
+
+		p.AssertNotCalled(t, "PublishDue")
+	})
+}
+
+func generateHandlerAndMocks() (*hsc.Scheduler, *mq.Queue, *msc.Publisher) {
+	q := new(mq.Queue)
+	p := new(msc.Publisher)
+
+	s := hsc.NewScheduler(
+		hsc.WithQueue(q),
+		hsc.WithPublisher(p),
+		hsc.WithConfig(hsc.Config{PublishDueTicker: time.Millisecond}),
+	)
+
+	return s, q, p
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+type SocialClient interface {
+	SendUpdate(string) ([]string, error)
+	SendUpdateWithPhoto(string, ...[]byte) ([]string, error)
+	ContinueThread(string, []string) ([]string, error)
+	DeleteUpdates(...string) error
+}
+
+// Social publishes texts and photos in a social network account, replacing them when their post is edited and
+// removing them when it's deleted. Networks publishing posts the same way only differ in their client.
+type Social struct {
+	NotificationState
+	Workers
+
+	id string
+	c  SocialClient
+	q  pubsub.Queue
+	ps Posts
+	r  *Retrier
+}
+
+func NewSocial(id string, c SocialClient, q pubsub.Queue, ps Posts, rp RetryPolicy) *Social {
+	return &Social{id: id, c: c, q: q, ps: ps, r: NewRetrier(q, id, rp)}
+}
+
+func (s *Social) ID() string {
+	return s.id
+}
+
+func (s *Social) ExecuteHandlers(ctx context.Context) {
+	s.handle(ctx, pubsub.TextTopic, s.handleText)
+	s.handle(ctx, pubsub.PhotoTopic, s.handlePhoto)
+	s.handle(ctx, pubsub.DeleteTopic, s.handleDelete)
+}
+
+func (s *Social) handle(ctx context.Context, topic pubsub.TopicName, f func(*message.Message) error) {
+	messages, err := s.q.Subscribe(ctx, topic.String())
+	if err != nil {
+		SendError(s.q, err)
+
+		return
+	}
+
+	s.Go(func() {
+		for msg := range messages {
+			if s.IsPaused() || !IsAddressedTo(msg, s.id) {
+				msg.Ack()
+
+				continue
+			}
+
+			if err := f(msg); err != nil {
+				s.r.Fail(msg, topic, err)
+
+				continue
+			}
+
+			s.r.Ack(msg)
+		}
+	})
+}
+
+func (s *Social) handleText(msg *message.Message) error {
+	var e pubsub.TextEvent
+	if err := easyjson.Unmarshal(msg.Payload, &e); err != nil {
+		SendError(s.q, err)
+
+		return nil
+	}
+
+	if !e.Destinations.Includes(s.id) {
+		return nil
+	}
+
+	text := PlainText(e)
+
+	return s.publish(e.Source, e.Edit, text, func() ([]string, error) {
+		return s.c.SendUpdate(text)
+	})
+}
+
+func (s *Social) handlePhoto(msg *message.Message) error {
+	var e pubsub.PhotoEvent
+	if err := easyjson.Unmarshal(msg.Payload, &e); err != nil {
+		SendError(s.q, err)
+
+		return nil
+	}
+
+	if !e.Destinations.Includes(s.id) {
+		return nil
+	}
+
+	return s.publish(e.Source, e.Edit, e.Caption, func() ([]string, error) {
+		return s.c.SendUpdateWithPhoto(e.Caption, e.FileContent)
+	})
+}
+
+func (s *Social) handleDelete(msg *message.Message) error {
+	var e pubsub.DeleteEvent
+	if err := easyjson.Unmarshal(msg.Payload, &e); err != nil {
+		SendError(s.q, err)
+
+		return nil
+	}
+
+	ids, published, err := PublishedMessages(s.ps, e.Source, s.id)
+	if err != nil || (!published && len(ids) == 0) {
+		return err
+	}
+
+	if err := s.c.DeleteUpdates(ids...); err != nil {
+		return err
+	}
+
+	if err := s.ps.Remove(e.Source, s.id); err != nil {
+		SendError(s.q, err)
+	}
+
+	return nil
+}
+
+// publish sends a post unless it was already published, replacing it when it's edited. A thread that failed partway
+// is continued from its last message on retry instead of being published again.
+func (s *Social) publish(source string, edit bool, text string, send func() ([]string, error)) error {
+	ids, published, err := PublishedMessages(s.ps, source, s.id)
+	if err != nil {
+		return err
+	}
+
+	unfinished := !published && len(ids) > 0
+
+	if !edit && unfinished {
+		return s.save(source, func() ([]string, error) {
+			return s.c.ContinueThread(text, ids)
+		})
+	}
+
+	if edit != (published || unfinished) {
+		return nil
+	}
+
+	if edit {
+		if err := s.c.DeleteUpdates(ids...); err != nil {
+			return err
+		}
+
+		SavePost(s.q, s.ps, source, s.id, nil)
+	}
+
+	return s.save(source, send)
+}
+
+func (s *Social) save(source string, send func() ([]string, error)) error {
+	ids, err := send()
+	if err != nil {
+		SaveUnfinished(s.q, s.ps, source, s.id, ids)
+
+		return err
+	}
+
+	SavePost(s.q, s.ps, source, s.id, ids)
+
+	return nil
+}
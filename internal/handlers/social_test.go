@@ -0,0 +1,358 @@
+package handlers_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/posts"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	mh "github.com/quintodown/quintodownbot/mocks/handlers"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type messageNotSendError struct{}
+
+func (m messageNotSendError) Error() string {
+	return "couldn't send message to mastodon"
+}
+
+type channelError struct{}
+
+func (c channelError) Error() string {
+	return "error getting channel error"
+}
+
+func TestSocial_ExecuteHandlers(t *testing.T) {
+	ctx := context.Background()
+
+	s, mockedQueue, _, _ := getSocialHandlerAndMocks(ctx, false, nil)
+
+	for _, topic := range []pubsub.TopicName{pubsub.TextTopic, pubsub.PhotoTopic, pubsub.DeleteTopic} {
+		mockedQueue.On("Subscribe", ctx, topic.String()).Once().Return(nil, channelError{})
+	}
+
+	mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+		return string(m.Payload) == "{\"error\":\"error getting channel error\"}"
+	})).Times(3).
+		Return(nil)
+
+	s.ExecuteHandlers(ctx)
+	s.Wait()
+
+	mockedQueue.AssertExpectations(t)
+}
+
+func TestSocial_ExecuteHandlersText(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should fail unmarshaling text event", func(t *testing.T) {
+		s, mockedQueue, _, channels := getSocialHandlerAndMocks(ctx, true, nil)
+
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) ==
+				"{\"error\":\"parse error: unterminated string literal near offset 12 of '{\\\"asd\\\":\\\"qwer'\"}"
+		})).Once().
+			Return(nil)
+
+		s.ExecuteHandlers(ctx)
+
+		sendSocialMessage(t, channels[pubsub.TextTopic], []byte("{\"asd\":\"qwer"))
+
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should fail sending text message to mastodon", func(t *testing.T) {
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, nil)
+
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"couldn't send message to mastodon\"}"
+		})).Once().
+			Return(nil)
+		mockedQueue.On("Publish", pubsub.DeadLetterTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			var e pubsub.DeadLetterEvent
+
+			return easyjson.Unmarshal(m.Payload, &e) == nil &&
+				e.Handler == "mastodon" &&
+				e.Topic == pubsub.TextTopic.String()
+		})).Once().
+			Return(nil)
+		mockedClient.On("SendUpdate", "testing message").Once().Return(nil, messageNotSendError{})
+
+		s.ExecuteHandlers(ctx)
+
+		sendSocialMessage(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+	})
+
+	t.Run("it should skip text message addressed only to telegram", func(t *testing.T) {
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, nil)
+
+		s.ExecuteHandlers(ctx)
+
+		sendSocialMessage(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"testing message\",\"destinations\":[\"telegram\"]}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertNotCalled(t, "SendUpdate", mock.Anything)
+	})
+
+	t.Run("it should skip text message when notifications stopped", func(t *testing.T) {
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, nil)
+
+		s.StopNotifications()
+		s.ExecuteHandlers(ctx)
+
+		sendSocialMessage(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertNotCalled(t, "SendUpdate", mock.Anything)
+	})
+
+	t.Run("it should send text message with expanded links to mastodon", func(t *testing.T) {
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, nil)
+
+		mockedClient.On("SendUpdate", "testing message (https://quintodown.com) now").Once().Return(nil, nil)
+
+		s.ExecuteHandlers(ctx)
+
+		sendSocialMessage(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message now\","+
+			"\"entities\":[{\"type\":\"text_link\",\"offset\":8,\"length\":7,\"url\":\"https://quintodown.com\"}]}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+	})
+}
+
+func TestSocial_ExecuteHandlersPhoto(t *testing.T) {
+	ctx := context.Background()
+	photoContent := []byte("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAIAAACQd1PeAAAAEElEQVR4nGKaks0ECAAA//" +
+		"8CoAEEsZgdLgAAAABJRU5ErkJggg==")
+	bytes, _ := easyjson.Marshal(pubsub.PhotoEvent{Caption: "testing caption", FileContent: photoContent})
+
+	t.Run("it should fail sending photo to mastodon", func(t *testing.T) {
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, nil)
+
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.Anything).Once().Return(nil)
+		mockedQueue.On("Publish", pubsub.DeadLetterTopic.String(), mock.Anything).Once().Return(nil)
+		mockedClient.On("SendUpdateWithPhoto", "testing caption", photoContent).
+			Once().
+			Return(nil, messageNotSendError{})
+
+		s.ExecuteHandlers(ctx)
+
+		sendSocialMessage(t, channels[pubsub.PhotoTopic], bytes)
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+	})
+
+	t.Run("it should send photo to mastodon", func(t *testing.T) {
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, nil)
+
+		mockedClient.On("SendUpdateWithPhoto", "testing caption", photoContent).Once().Return([]string{"1"}, nil)
+
+		s.ExecuteHandlers(ctx)
+
+		sendSocialMessage(t, channels[pubsub.PhotoTopic], bytes)
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+	})
+}
+
+func TestSocial_ExecuteHandlersPosts(t *testing.T) {
+	ctx := context.Background()
+	published := posts.Post{Source: "12345:7", Messages: map[string][]string{"mastodon": {"109"}}}
+
+	t.Run("it should record the published statuses", func(t *testing.T) {
+		ps := new(mh.Posts)
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, ps)
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+		mockedClient.On("SendUpdate", "testing message").Once().Return([]string{"109"}, nil)
+		ps.On("Save", "12345:7", "mastodon", []string{"109"}).Once().Return(nil)
+
+		s.ExecuteHandlers(ctx)
+		sendSocialMessage(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should skip posts already published", func(t *testing.T) {
+		ps := new(mh.Posts)
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, ps)
+
+		ps.On("Get", "12345:7").Once().Return(published, nil)
+
+		s.ExecuteHandlers(ctx)
+		sendSocialMessage(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertNotCalled(t, "SendUpdate", mock.Anything)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should replace the published statuses when the post is edited", func(t *testing.T) {
+		ps := new(mh.Posts)
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, ps)
+
+		ps.On("Get", "12345:7").Once().Return(published, nil)
+		mockedClient.On("DeleteUpdates", "109").Once().Return(nil)
+		ps.On("Save", "12345:7", "mastodon", []string(nil)).Once().Return(nil)
+		mockedClient.On("SendUpdate", "new text").Once().Return([]string{"110"}, nil)
+		ps.On("Save", "12345:7", "mastodon", []string{"110"}).Once().Return(nil)
+
+		s.ExecuteHandlers(ctx)
+		sendSocialMessage(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"new text\",\"source\":\"12345:7\",\"edit\":true}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should skip edits of posts not published on mastodon", func(t *testing.T) {
+		ps := new(mh.Posts)
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, ps)
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+
+		s.ExecuteHandlers(ctx)
+		sendSocialMessage(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"new text\",\"source\":\"12345:7\",\"edit\":true}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertNotCalled(t, "SendUpdate", mock.Anything)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should keep the statuses sent before the thread failed", func(t *testing.T) {
+		ps := new(mh.Posts)
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, ps)
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+		mockedClient.On("SendUpdate", "testing message").Once().Return([]string{"109"}, messageNotSendError{})
+		ps.On("SaveUnfinished", "12345:7", "mastodon", []string{"109"}).Once().Return(nil)
+		mockedQueue.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+		s.ExecuteHandlers(ctx)
+		sendSocialMessage(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"),
+		)
+
+		mockedClient.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should continue an unfinished thread from its last status", func(t *testing.T) {
+		ps := new(mh.Posts)
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, ps)
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{
+			Source:     "12345:7",
+			Messages:   map[string][]string{"mastodon": {"109"}},
+			Unfinished: []string{"mastodon"},
+		}, nil)
+		mockedClient.On("ContinueThread", "testing message", []string{"109"}).Once().Return([]string{"109", "110"}, nil)
+		ps.On("Save", "12345:7", "mastodon", []string{"109", "110"}).Once().Return(nil)
+
+		s.ExecuteHandlers(ctx)
+		sendSocialMessage(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+		mockedClient.AssertNotCalled(t, "SendUpdate", mock.Anything)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should delete the published statuses", func(t *testing.T) {
+		ps := new(mh.Posts)
+		s, mockedQueue, mockedClient, channels := getSocialHandlerAndMocks(ctx, true, ps)
+
+		ps.On("Get", "12345:7").Once().Return(published, nil)
+		mockedClient.On("DeleteUpdates", "109").Once().Return(nil)
+		ps.On("Remove", "12345:7", "mastodon").Once().Return(nil)
+
+		s.ExecuteHandlers(ctx)
+		sendSocialMessage(t, channels[pubsub.DeleteTopic], []byte("{\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedClient.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+}
+
+func getSocialHandlerAndMocks(ctx context.Context, returnChannels bool, ps handlers.Posts) (
+	*handlers.Social,
+	*mq.Queue,
+	*mh.SocialClient,
+	map[pubsub.TopicName]chan *message.Message,
+) {
+	mockedClient := new(mh.SocialClient)
+	mockedQueue := new(mq.Queue)
+
+	s := handlers.NewSocial("mastodon", mockedClient, mockedQueue, ps, handlers.RetryPolicy{})
+
+	channels := map[pubsub.TopicName]chan *message.Message{}
+
+	for _, topic := range []pubsub.TopicName{pubsub.TextTopic, pubsub.PhotoTopic, pubsub.DeleteTopic} {
+		channel := make(chan *message.Message)
+		channels[topic] = channel
+
+		if returnChannels {
+			mockedQueue.On("Subscribe", ctx, topic.String()).
+				Once().
+				Return(func(context.Context, string) <-chan *message.Message {
+					return channel
+				}, nil)
+		}
+	}
+
+	return s, mockedQueue, mockedClient, channels
+}
+
+func sendSocialMessage(t *testing.T, channel chan *message.Message, eventMsg []byte) {
+	newMessage := message.NewMessage(watermill.NewUUID(), eventMsg)
+	channel <- newMessage
+
+	require.Eventually(t, func() bool {
+		<-newMessage.Acked()
+
+		return true
+	}, time.Second, time.Millisecond)
+}
@@ -2,6 +2,8 @@ package handlerstelegram
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/mailru/easyjson"
@@ -11,11 +13,26 @@ import (
 	"github.com/quintodown/quintodownbot/internal/pubsub"
 )
 
+const (
+	textEvent     = "text"
+	photoEvent    = "photo"
+	albumEvent    = "album"
+	videoEvent    = "video"
+	documentEvent = "document"
+)
+
+var errUnknownChannel = errors.New("unknown broadcast channel")
+
 type Telegram struct {
-	bot          bot.TelegramBot
-	cfg          config.AppConfig
-	q            pubsub.Queue
-	shouldNotify bool
+	handlers.NotificationState
+	handlers.Workers
+
+	bot bot.TelegramBot
+	cfg config.AppConfig
+	q   pubsub.Queue
+	ps  handlers.Posts
+	rp  handlers.RetryPolicy
+	r   *handlers.Retrier
 }
 
 type Option func(b *Telegram)
@@ -38,13 +55,27 @@ func WithQueue(q pubsub.Queue) Option {
 	}
 }
 
+func WithPosts(ps handlers.Posts) Option {
+	return func(b *Telegram) {
+		b.ps = ps
+	}
+}
+
+func WithRetryPolicy(rp handlers.RetryPolicy) Option {
+	return func(b *Telegram) {
+		b.rp = rp
+	}
+}
+
 func NewTelegram(options ...Option) *Telegram {
-	t := &Telegram{shouldNotify: true}
+	t := &Telegram{}
 
 	for _, o := range options {
 		o(t)
 	}
 
+	t.r = handlers.NewRetrier(t.q, t.ID(), t.rp)
+
 	return t
 }
 
@@ -55,21 +86,23 @@ func (t *Telegram) ID() string {
 func (t *Telegram) ExecuteHandlers(ctx context.Context) {
 	t.handleText(ctx)
 	t.handlePhoto(ctx)
-}
-
-func (t *Telegram) StopNotifications() {
-	t.shouldNotify = false
+	t.handleAlbum(ctx)
+	t.handleVideo(ctx)
+	t.handleDocument(ctx)
+	t.handleDelete(ctx)
 }
 
 func (t *Telegram) handleText(ctx context.Context) {
 	messages, err := t.q.Subscribe(ctx, pubsub.TextTopic.String())
 	if err != nil {
 		handlers.SendError(t.q, err)
+
+		return
 	}
 
-	go func() {
+	t.Go(func() {
 		for msg := range messages {
-			if !t.shouldNotify {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
 				msg.Ack()
 
 				continue
@@ -84,24 +117,39 @@ func (t *Telegram) handleText(ctx context.Context) {
 				continue
 			}
 
-			if err := t.bot.Send(strconv.Itoa(int(t.cfg.BroadcastChannel)), m.Text); err != nil {
-				handlers.SendError(t.q, err)
+			if !m.Destinations.Includes(t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			event := m.Kind
+			if event == "" {
+				event = textEvent
+			}
+
+			if err := t.publish(m.Source, m.Edit, t.route(event, m.Text), text(m)); err != nil {
+				t.r.Fail(msg, pubsub.TextTopic, err)
+
+				continue
 			}
 
-			msg.Ack()
+			t.r.Ack(msg)
 		}
-	}()
+	})
 }
 
 func (t *Telegram) handlePhoto(ctx context.Context) {
 	messages, err := t.q.Subscribe(ctx, pubsub.PhotoTopic.String())
 	if err != nil {
 		handlers.SendError(t.q, err)
+
+		return
 	}
 
-	go func() {
+	t.Go(func() {
 		for msg := range messages {
-			if !t.shouldNotify {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
 				msg.Ack()
 
 				continue
@@ -115,16 +163,330 @@ func (t *Telegram) handlePhoto(ctx context.Context) {
 				continue
 			}
 
-			if err := t.bot.Send(strconv.Itoa(int(t.cfg.BroadcastChannel)), &bot.TelegramPhoto{
+			if !m.Destinations.Includes(t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			if err := t.publish(m.Source, m.Edit, t.route(photoEvent, m.Caption), bot.TelegramPhoto{
+				Caption:     m.Caption,
+				FileID:      m.FileID,
+				FileURL:     m.FileURL,
+				FileSize:    m.FileSize,
+				FileContent: m.FileContent,
+			}); err != nil {
+				t.r.Fail(msg, pubsub.PhotoTopic, err)
+
+				continue
+			}
+
+			t.r.Ack(msg)
+		}
+	})
+}
+
+func (t *Telegram) handleAlbum(ctx context.Context) {
+	messages, err := t.q.Subscribe(ctx, pubsub.AlbumTopic.String())
+	if err != nil {
+		handlers.SendError(t.q, err)
+
+		return
+	}
+
+	t.Go(func() {
+		for msg := range messages {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			var m pubsub.AlbumEvent
+			if err := easyjson.Unmarshal(msg.Payload, &m); err != nil {
+				handlers.SendError(t.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if !m.Destinations.Includes(t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			album := make(bot.TelegramAlbum, 0, len(m.Photos))
+			for i := range m.Photos {
+				album = append(album, bot.TelegramPhoto{
+					FileID:   m.Photos[i].FileID,
+					FileURL:  m.Photos[i].FileURL,
+					FileSize: m.Photos[i].FileSize,
+				})
+			}
+
+			if len(album) > 0 {
+				album[0].Caption = m.Caption
+			}
+
+			if err := t.publish(m.Source, false, t.route(albumEvent, m.Caption), album); err != nil {
+				t.r.Fail(msg, pubsub.AlbumTopic, err)
+
+				continue
+			}
+
+			t.r.Ack(msg)
+		}
+	})
+}
+
+func (t *Telegram) handleVideo(ctx context.Context) {
+	messages, err := t.q.Subscribe(ctx, pubsub.VideoTopic.String())
+	if err != nil {
+		handlers.SendError(t.q, err)
+
+		return
+	}
+
+	t.Go(func() {
+		for msg := range messages {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			var m pubsub.VideoEvent
+			if err := easyjson.Unmarshal(msg.Payload, &m); err != nil {
+				handlers.SendError(t.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if !m.Destinations.Includes(t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			if err := t.publish(m.Source, m.Edit, t.route(videoEvent, m.Caption), bot.TelegramVideo{
+				Caption:   m.Caption,
+				FileID:    m.FileID,
+				FileURL:   m.FileURL,
+				FileSize:  m.FileSize,
+				MimeType:  m.MimeType,
+				Animation: m.Animation,
+			}); err != nil {
+				t.r.Fail(msg, pubsub.VideoTopic, err)
+
+				continue
+			}
+
+			t.r.Ack(msg)
+		}
+	})
+}
+
+func (t *Telegram) handleDocument(ctx context.Context) {
+	messages, err := t.q.Subscribe(ctx, pubsub.DocumentTopic.String())
+	if err != nil {
+		handlers.SendError(t.q, err)
+
+		return
+	}
+
+	t.Go(func() {
+		for msg := range messages {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			var m pubsub.DocumentEvent
+			if err := easyjson.Unmarshal(msg.Payload, &m); err != nil {
+				handlers.SendError(t.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if !m.Destinations.Includes(t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			if err := t.publish(m.Source, m.Edit, t.route(documentEvent, m.Caption), bot.TelegramDocument{
 				Caption:  m.Caption,
 				FileID:   m.FileID,
 				FileURL:  m.FileURL,
 				FileSize: m.FileSize,
+				FileName: m.FileName,
+				MimeType: m.MimeType,
 			}); err != nil {
+				t.r.Fail(msg, pubsub.DocumentTopic, err)
+
+				continue
+			}
+
+			t.r.Ack(msg)
+		}
+	})
+}
+
+func (t *Telegram) handleDelete(ctx context.Context) {
+	messages, err := t.q.Subscribe(ctx, pubsub.DeleteTopic.String())
+	if err != nil {
+		handlers.SendError(t.q, err)
+
+		return
+	}
+
+	t.Go(func() {
+		for msg := range messages {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			var m pubsub.DeleteEvent
+			if err := easyjson.Unmarshal(msg.Payload, &m); err != nil {
 				handlers.SendError(t.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if err := t.delete(m.Source); err != nil {
+				t.r.Fail(msg, pubsub.DeleteTopic, err)
+
+				continue
+			}
+
+			t.r.Ack(msg)
+		}
+	})
+}
+
+// publish sends a post to every channel it wasn't published in yet, editing it when it's edited. A thread that failed
+// partway is completed on retry by editing the messages already sent, which replies with the missing chunks.
+func (t *Telegram) publish(source string, edit bool, channels []string, what interface{}) error {
+	if edit {
+		return t.published(source, func(channel string, d handlers.PublishedDestination) error {
+			if len(d.IDs) == 0 {
+				return nil
+			}
+
+			return t.save(source, d.Key, func() ([]string, error) {
+				return t.bot.Edit(channel, d.IDs, what)
+			})
+		})
+	}
+
+	broadcast := t.cfg.Channels()
+
+	for _, name := range channels {
+		id, ok := broadcast[name]
+		if !ok {
+			handlers.SendError(t.q, fmt.Errorf("%w: %s", errUnknownChannel, name))
+
+			continue
+		}
+
+		key := handlers.PostKey(t.ID(), name)
+
+		ids, published, err := handlers.PublishedMessages(t.ps, source, key)
+		if err != nil {
+			return err
+		}
+
+		if published {
+			continue
+		}
+
+		channel := strconv.FormatInt(id, 10)
+
+		if err := t.save(source, key, func() ([]string, error) {
+			if len(ids) > 0 {
+				return t.bot.Edit(channel, ids, what)
 			}
 
-			msg.Ack()
+			return t.bot.Publish(channel, what)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Telegram) save(source, key string, send func() ([]string, error)) error {
+	ids, err := send()
+	if err != nil {
+		handlers.SaveUnfinished(t.q, t.ps, source, key, ids)
+
+		return err
+	}
+
+	handlers.SavePost(t.q, t.ps, source, key, ids)
+
+	return nil
+}
+
+func (t *Telegram) delete(source string) error {
+	return t.published(source, func(channel string, d handlers.PublishedDestination) error {
+		if err := t.bot.Delete(channel, d.IDs...); err != nil {
+			return err
+		}
+
+		if err := t.ps.Remove(source, d.Key); err != nil {
+			handlers.SendError(t.q, err)
+		}
+
+		return nil
+	})
+}
+
+func (t *Telegram) published(source string, f func(channel string, d handlers.PublishedDestination) error) error {
+	destinations, err := handlers.PublishedDestinations(t.ps, source, t.ID())
+	if err != nil {
+		return err
+	}
+
+	broadcast := t.cfg.Channels()
+
+	for _, d := range destinations {
+		id, ok := broadcast[d.Name]
+		if !ok {
+			handlers.SendError(t.q, fmt.Errorf("%w: %s", errUnknownChannel, d.Name))
+
+			continue
+		}
+
+		if err := f(strconv.FormatInt(id, 10), d); err != nil {
+			return err
 		}
-	}()
+	}
+
+	return nil
+}
+
+func (t *Telegram) route(event, text string) []string {
+	return t.cfg.BroadcastRoute(event, handlers.Hashtags(text))
+}
+
+func text(m pubsub.TextEvent) interface{} {
+	if len(m.Entities) == 0 {
+		return m.Text
+	}
+
+	entities := make([]bot.TelegramEntity, 0, len(m.Entities))
+	for _, e := range m.Entities {
+		entities = append(entities, bot.TelegramEntity{Type: e.Type, Offset: e.Offset, Length: e.Length, URL: e.URL})
+	}
+
+	return bot.TelegramText{Text: m.Text, Entities: entities}
 }
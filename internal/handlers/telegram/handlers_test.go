@@ -8,11 +8,16 @@ import (
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
 	"github.com/quintodown/quintodownbot/internal/bot"
 	"github.com/quintodown/quintodownbot/internal/config"
+	"github.com/quintodown/quintodownbot/internal/handlers"
 	ht "github.com/quintodown/quintodownbot/internal/handlers/telegram"
+	"github.com/quintodown/quintodownbot/internal/posts"
 	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
 	mb "github.com/quintodown/quintodownbot/mocks/bot"
+	mh "github.com/quintodown/quintodownbot/mocks/handlers"
 	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -45,8 +50,8 @@ func TestTelegram_ExecuteHandlers(t *testing.T) {
 	}
 	ctx := context.Background()
 
-	t.Run("it should fail getting channel for text and photo notifications", func(t *testing.T) {
-		th, mockedQueue, _, _, _ := generateHandlerAndMocks(ctx, cfg, false)
+	t.Run("it should fail getting channel for notifications", func(t *testing.T) {
+		th, mockedQueue, _, _ := generateHandlerAndMocks(ctx, cfg, false)
 
 		mockedQueue.On("Subscribe", ctx, pubsub.TextTopic.String()).
 			Once().
@@ -54,12 +59,25 @@ func TestTelegram_ExecuteHandlers(t *testing.T) {
 		mockedQueue.On("Subscribe", ctx, pubsub.PhotoTopic.String()).
 			Once().
 			Return(nil, gettingChannelError{})
+		mockedQueue.On("Subscribe", ctx, pubsub.AlbumTopic.String()).
+			Once().
+			Return(nil, gettingChannelError{})
+		mockedQueue.On("Subscribe", ctx, pubsub.VideoTopic.String()).
+			Once().
+			Return(nil, gettingChannelError{})
+		mockedQueue.On("Subscribe", ctx, pubsub.DocumentTopic.String()).
+			Once().
+			Return(nil, gettingChannelError{})
+		mockedQueue.On("Subscribe", ctx, pubsub.DeleteTopic.String()).
+			Once().
+			Return(nil, gettingChannelError{})
 		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
 			return string(m.Payload) == "{\"error\":\"error getting channel error\"}"
-		})).Times(2).
+		})).Times(6).
 			Return(nil)
 
 		th.ExecuteHandlers(ctx)
+		th.Wait()
 
 		mockedQueue.AssertExpectations(t)
 	})
@@ -72,7 +90,7 @@ func TestTelegram_ExecuteHandlersText(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("it should fail unmarshaling text event", func(t *testing.T) {
-		th, mockedQueue, _, textChannel, _ := generateHandlerAndMocks(ctx, cfg, true)
+		th, mockedQueue, _, channels := generateHandlerAndMocks(ctx, cfg, true)
 
 		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
 			return string(m.Payload) ==
@@ -82,42 +100,285 @@ func TestTelegram_ExecuteHandlersText(t *testing.T) {
 
 		th.ExecuteHandlers(ctx)
 
-		sendMessageToChannel(t, textChannel, []byte("{\"asd\":\"qwer"))
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"asd\":\"qwer"))
 
 		mockedQueue.AssertExpectations(t)
 	})
 
 	t.Run("it should fail sending text message to telegram", func(t *testing.T) {
-		th, mockedQueue, mockedBot, textChannel, _ := generateHandlerAndMocks(ctx, cfg, true)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
 
 		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
 			return string(m.Payload) == "{\"error\":\"couldn't send message to telegram\"}"
 		})).Once().
 			Return(nil)
-		mockedBot.On("Send", strconv.Itoa(int(cfg.BroadcastChannel)), "failing message").
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), "failing message").
 			Once().
-			Return(messageNotSendError{})
+			Return(nil, messageNotSendError{})
+		expectDeadLetter(mockedQueue, pubsub.TextTopic, "telegram")
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"failing message\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should skip text message addressed only to twitter", func(t *testing.T) {
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
 
 		th.ExecuteHandlers(ctx)
-		sendMessageToChannel(t, textChannel, []byte("{\"text\":\"failing message\"}"))
+
+		sendMessageToChannel(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"testing message\",\"destinations\":[\"twitter\"]}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should send formatted text message to telegram", func(t *testing.T) {
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), bot.TelegramText{
+			Text:     "testing message",
+			Entities: []bot.TelegramEntity{{Type: "bold", Offset: 0, Length: 7}},
+		}).Once().Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\","+
+			"\"entities\":[{\"type\":\"bold\",\"offset\":0,\"length\":7}]}"))
 
 		mockedQueue.AssertExpectations(t)
 		mockedBot.AssertExpectations(t)
 	})
 
 	t.Run("it should send text message to telegram", func(t *testing.T) {
-		th, mockedQueue, mockedBot, textChannel, _ := generateHandlerAndMocks(ctx, cfg, true)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
 
-		mockedBot.On("Send", strconv.Itoa(int(cfg.BroadcastChannel)), "testing message").
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), "testing message").
 			Once().
 			Return(nil, nil)
 
 		th.ExecuteHandlers(ctx)
 
-		sendMessageToChannel(t, textChannel, []byte("{\"text\":\"testing message\"}"))
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+}
+
+func TestTelegram_ExecuteHandlersPosts(t *testing.T) {
+	cfg := config.AppConfig{
+		BroadcastChannel: 1234,
+	}
+	ctx := context.Background()
+	channel := strconv.Itoa(int(cfg.BroadcastChannel))
+	published := posts.Post{Source: "12345:7", Messages: map[string][]string{"telegram": {"10", "11"}}}
+
+	t.Run("it should record the messages published in the channel", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+		mockedBot.On("Publish", channel, "testing message").Once().Return([]string{"10", "11"}, nil)
+		ps.On("Save", "12345:7", "telegram", []string{"10", "11"}).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should complete an unfinished thread replying to its last message", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{
+			Source:     "12345:7",
+			Messages:   map[string][]string{"telegram": {"10"}},
+			Unfinished: []string{"telegram"},
+		}, nil)
+		mockedBot.On("Edit", channel, []string{"10"}, "testing message").Once().Return([]string{"10", "11"}, nil)
+		ps.On("Save", "12345:7", "telegram", []string{"10", "11"}).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+		mockedBot.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should edit the messages published in the channel", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(published, nil)
+		mockedBot.On("Edit", channel, []string{"10", "11"}, "new text").Once().Return([]string{"10"}, nil)
+		ps.On("Save", "12345:7", "telegram", []string{"10"}).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"new text\",\"source\":\"12345:7\",\"edit\":true}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should skip edits of posts not published in the channel", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"new text\",\"source\":\"12345:7\",\"edit\":true}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertNotCalled(t, "Edit", mock.Anything, mock.Anything, mock.Anything)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should delete the messages published in the channel", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(published, nil)
+		mockedBot.On("Delete", channel, "10", "11").Once().Return(nil)
+		ps.On("Remove", "12345:7", "telegram").Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.DeleteTopic], []byte("{\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should retry when messages couldn't be deleted", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(published, nil)
+		mockedBot.On("Delete", channel, "10", "11").Once().Return(messageNotSendError{})
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.Anything).Once().Return(nil)
+		expectDeadLetter(mockedQueue, pubsub.DeleteTopic, "telegram")
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.DeleteTopic], []byte("{\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+		ps.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTelegram_ExecuteHandlersRouting(t *testing.T) {
+	cfg := config.AppConfig{
+		BroadcastChannel:  1234,
+		BroadcastChannels: map[string]int64{"games": 5678},
+		BroadcastRoutes:   map[string]string{"game": "games", "#NFL": "games+default"},
+	}
+	ctx := context.Background()
+
+	t.Run("it should fan out posts to the channels of their hashtags", func(t *testing.T) {
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedBot.On("Publish", "5678", "#NFL touchdown").Once().Return([]string{"20"}, nil)
+		mockedBot.On("Publish", "1234", "#NFL touchdown").Once().Return([]string{"10"}, nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"#NFL touchdown\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should route game events", func(t *testing.T) {
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedBot.On("Publish", "5678", "game started").Once().Return([]string{"20"}, nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"game started\",\"kind\":\"game\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+		mockedBot.AssertNotCalled(t, "Publish", "1234", mock.Anything)
+	})
+
+	t.Run("it should skip channels where the post was already published", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").
+			Return(posts.Post{Source: "12345:7", Messages: map[string][]string{"telegram:games": {"20"}}}, nil)
+		mockedBot.On("Publish", "1234", "#NFL touchdown").Once().Return([]string{"10"}, nil)
+		ps.On("Save", "12345:7", "telegram", []string{"10"}).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"#NFL touchdown\",\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+		mockedBot.AssertNotCalled(t, "Publish", "5678", mock.Anything)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should edit the post in every channel where it was published", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{
+			Source:   "12345:7",
+			Messages: map[string][]string{"telegram": {"10"}, "telegram:games": {"20"}, "twitter": {"30"}},
+		}, nil)
+		mockedBot.On("Edit", "1234", []string{"10"}, "new text").Once().Return([]string{"10"}, nil)
+		mockedBot.On("Edit", "5678", []string{"20"}, "new text").Once().Return([]string{"20"}, nil)
+		ps.On("Save", "12345:7", "telegram", []string{"10"}).Once().Return(nil)
+		ps.On("Save", "12345:7", "telegram:games", []string{"20"}).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"new text\",\"source\":\"12345:7\",\"edit\":true}"),
+		)
 
 		mockedQueue.AssertExpectations(t)
 		mockedBot.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should report channels missing in the configuration", func(t *testing.T) {
+		cfg := config.AppConfig{BroadcastChannel: 1234, BroadcastRoutes: map[string]string{"#NFL": "nfl"}}
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"unknown broadcast channel: nfl\"}"
+		})).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"#NFL touchdown\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
 	})
 }
 
@@ -130,7 +391,7 @@ func TestTelegram_ExecuteHandlersPhoto(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("it should fail unmarshaling photo event", func(t *testing.T) {
-		th, mockedQueue, _, _, photoChannel := generateHandlerAndMocks(ctx, cfg, true)
+		th, mockedQueue, _, channels := generateHandlerAndMocks(ctx, cfg, true)
 
 		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
 			return string(m.Payload) ==
@@ -140,37 +401,129 @@ func TestTelegram_ExecuteHandlersPhoto(t *testing.T) {
 
 		th.ExecuteHandlers(ctx)
 
-		sendMessageToChannel(t, photoChannel, []byte("{\"asd\":\"qwer"))
+		sendMessageToChannel(t, channels[pubsub.PhotoTopic], []byte("{\"asd\":\"qwer"))
 
 		mockedQueue.AssertExpectations(t)
 	})
 
 	t.Run("it should fail sending photo message to telegram", func(t *testing.T) {
-		th, mockedQueue, mockedBot, _, photoChannel := generateHandlerAndMocks(ctx, cfg, true)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
 
 		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
 			return string(m.Payload) == "{\"error\":\"couldn't send message to telegram\"}"
 		})).Once().
 			Return(nil)
-		mockedBot.On("Send", strconv.Itoa(int(cfg.BroadcastChannel)), mock.MatchedBy(matchTelegramPhoto())).
-			Once().Return(messageNotSendError{})
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), mock.MatchedBy(matchTelegramPhoto())).
+			Once().Return(nil, messageNotSendError{})
+		expectDeadLetter(mockedQueue, pubsub.PhotoTopic, "telegram")
 
 		th.ExecuteHandlers(ctx)
 
-		sendMessageToChannel(t, photoChannel, eventMsg)
+		sendMessageToChannel(t, channels[pubsub.PhotoTopic], eventMsg)
 
 		mockedQueue.AssertExpectations(t)
 		mockedBot.AssertExpectations(t)
 	})
 
 	t.Run("it should send photo message to telegram", func(t *testing.T) {
-		th, mockedQueue, mockedBot, _, photoChannel := generateHandlerAndMocks(ctx, cfg, true)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), mock.MatchedBy(matchTelegramPhoto())).
+			Once().Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.PhotoTopic], eventMsg)
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+}
+
+func TestTelegram_ExecuteHandlersAlbum(t *testing.T) {
+	cfg := config.AppConfig{
+		BroadcastChannel: 1234,
+	}
+	ctx := context.Background()
+	eventMsg := []byte("{\"caption\":\"testing album\",\"photos\":[" +
+		"{\"fileId\":\"photo1\",\"fileUrl\":\"http://photo1.url\",\"fileSize\":1234}," +
+		"{\"fileId\":\"photo2\",\"fileUrl\":\"http://photo2.url\",\"fileSize\":5678}]}")
+	album := bot.TelegramAlbum{
+		{Caption: "testing album", FileID: "photo1", FileURL: "http://photo1.url", FileSize: 1234},
+		{FileID: "photo2", FileURL: "http://photo2.url", FileSize: 5678},
+	}
 
-		mockedBot.On("Send", strconv.Itoa(int(cfg.BroadcastChannel)), mock.MatchedBy(matchTelegramPhoto())).
-			Once().Return(nil)
+	t.Run("it should fail sending album to telegram", func(t *testing.T) {
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), album).
+			Once().
+			Return(nil, messageNotSendError{})
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.Anything).Once().Return(nil)
+		expectDeadLetter(mockedQueue, pubsub.AlbumTopic, "telegram")
 
 		th.ExecuteHandlers(ctx)
-		sendMessageToChannel(t, photoChannel, eventMsg)
+
+		sendMessageToChannel(t, channels[pubsub.AlbumTopic], eventMsg)
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should send album to telegram", func(t *testing.T) {
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), album).Once().Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.AlbumTopic], eventMsg)
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+}
+
+func TestTelegram_ExecuteHandlersVideoAndDocument(t *testing.T) {
+	cfg := config.AppConfig{
+		BroadcastChannel: 1234,
+	}
+	ctx := context.Background()
+
+	t.Run("it should send animation to telegram", func(t *testing.T) {
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), bot.TelegramVideo{
+			Caption:   "goal",
+			FileID:    "video",
+			FileSize:  1234,
+			MimeType:  "video/mp4",
+			Animation: true,
+		}).Once().Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.VideoTopic], []byte("{\"caption\":\"goal\",\"fileId\":\"video\","+
+			"\"fileSize\":1234,\"mimeType\":\"video/mp4\",\"animation\":true}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should send document to telegram", func(t *testing.T) {
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), bot.TelegramDocument{
+			Caption:  "rules",
+			FileID:   "document",
+			FileSize: 1234,
+			FileName: "rules.pdf",
+			MimeType: "application/pdf",
+		}).Once().Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.DocumentTopic], []byte("{\"caption\":\"rules\",\"fileId\":\"document\","+
+			"\"fileSize\":1234,\"fileName\":\"rules.pdf\",\"mimeType\":\"application/pdf\"}"))
 
 		mockedQueue.AssertExpectations(t)
 		mockedBot.AssertExpectations(t)
@@ -184,31 +537,79 @@ func TestTelegram_ExecuteHandlersNotificationsDisabled(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("it should not send text message to telegram when notification disabled", func(t *testing.T) {
-		th, mockedQueue, mockedBot, textChannel, _ := generateHandlerAndMocks(ctx, cfg, true)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
 
 		th.StopNotifications()
 		th.ExecuteHandlers(ctx)
 
-		sendMessageToChannel(t, textChannel, []byte("{\"text\":\"testing message\"}"))
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
 
 		mockedQueue.AssertExpectations(t)
 		mockedBot.Test(t)
-		mockedBot.AssertNotCalled(t, "Send", strconv.Itoa(int(cfg.BroadcastChannel)), "testing message")
+		mockedBot.AssertNotCalled(t, "Publish", strconv.Itoa(int(cfg.BroadcastChannel)), "testing message")
 	})
 
 	t.Run("it should not send photo message to telegram when notification disabled", func(t *testing.T) {
 		eventMsg := []byte("{\"caption\":\"testing message\",\"fileId\":\"blablabla\",\"fileUrl\":\"http://photo.url\"," +
 			"\"fileSize\":1234}")
 
-		th, mockedQueue, mockedBot, _, photoChannel := generateHandlerAndMocks(ctx, cfg, true)
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
 
 		th.StopNotifications()
 		th.ExecuteHandlers(ctx)
-		sendMessageToChannel(t, photoChannel, eventMsg)
+		sendMessageToChannel(t, channels[pubsub.PhotoTopic], eventMsg)
 
 		mockedQueue.AssertExpectations(t)
 		mockedBot.Test(t)
-		mockedBot.AssertNotCalled(t, "Send", strconv.Itoa(int(cfg.BroadcastChannel)), mock.MatchedBy(matchTelegramPhoto()))
+		mockedBot.AssertNotCalled(t, "Publish", strconv.Itoa(int(cfg.BroadcastChannel)), mock.MatchedBy(matchTelegramPhoto()))
+	})
+
+	t.Run("it should send text message to telegram when notifications resumed", func(t *testing.T) {
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), "testing message").
+			Once().
+			Return(nil, nil)
+
+		th.StopNotifications()
+		require.True(t, th.IsPaused())
+
+		th.ResumeNotifications()
+		require.False(t, th.IsPaused())
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+
+	t.Run("it should toggle notifications while receiving messages", func(t *testing.T) {
+		th, _, mockedBot, channels := generateHandlerAndMocks(ctx, cfg, true)
+
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), "testing message").Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			for i := 0; i < 100; i++ {
+				th.StopNotifications()
+				th.ResumeNotifications()
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
+		}
+
+		<-done
+
+		require.False(t, th.IsPaused())
 	})
 }
 
@@ -216,29 +617,102 @@ func generateHandlerAndMocks(
 	ctx context.Context,
 	cfg config.AppConfig,
 	returnChannels bool,
-) (*ht.Telegram, *mq.Queue, *mb.TelegramBot, chan *message.Message, chan *message.Message) {
+	options ...ht.Option,
+) (*ht.Telegram, *mq.Queue, *mb.TelegramBot, map[pubsub.TopicName]chan *message.Message) {
 	mockedBot := new(mb.TelegramBot)
 	mockedQueue := new(mq.Queue)
 
-	th := ht.NewTelegram(ht.WithAppConfig(cfg), ht.WithTelegramBot(mockedBot), ht.WithQueue(mockedQueue))
+	th := ht.NewTelegram(append([]ht.Option{
+		ht.WithAppConfig(cfg),
+		ht.WithTelegramBot(mockedBot),
+		ht.WithQueue(mockedQueue),
+	}, options...)...)
+
+	channels := map[pubsub.TopicName]chan *message.Message{}
+
+	for _, topic := range []pubsub.TopicName{
+		pubsub.TextTopic,
+		pubsub.PhotoTopic,
+		pubsub.AlbumTopic,
+		pubsub.VideoTopic,
+		pubsub.DocumentTopic,
+		pubsub.DeleteTopic,
+	} {
+		channel := make(chan *message.Message)
+		channels[topic] = channel
+
+		if returnChannels {
+			mockedQueue.On("Subscribe", ctx, topic.String()).
+				Once().
+				Return(func(context.Context, string) <-chan *message.Message {
+					return channel
+				}, nil)
+		}
+	}
+
+	return th, mockedQueue, mockedBot, channels
+}
+
+func TestTelegram_ExecuteHandlersRetries(t *testing.T) {
+	cfg := config.AppConfig{
+		BroadcastChannel: 1234,
+	}
+	ctx := context.Background()
 
-	textChannel := make(chan *message.Message)
-	photoChannel := make(chan *message.Message)
+	t.Run("it should retry sending text message to telegram until it succeeds", func(t *testing.T) {
+		th, mockedQueue, mockedBot, channels := generateHandlerAndMocks(
+			ctx,
+			cfg,
+			true,
+			ht.WithRetryPolicy(handlers.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+		)
 
-	if returnChannels {
-		mockedQueue.On("Subscribe", ctx, pubsub.TextTopic.String()).
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"couldn't send message to telegram\"}"
+		})).Once().
+			Return(nil)
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), "testing message").
 			Once().
-			Return(func(context.Context, string) <-chan *message.Message {
-				return textChannel
-			}, nil)
-		mockedQueue.On("Subscribe", ctx, pubsub.PhotoTopic.String()).
+			Return(nil, messageNotSendError{})
+		mockedBot.On("Publish", strconv.Itoa(int(cfg.BroadcastChannel)), "testing message").
 			Once().
-			Return(func(context.Context, string) <-chan *message.Message {
-				return photoChannel
-			}, nil)
-	}
+			Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+
+		msg := message.NewMessage(watermill.NewUUID(), []byte("{\"text\":\"testing message\"}"))
+		channels[pubsub.TextTopic] <- msg
+
+		require.Eventually(t, func() bool {
+			<-msg.Nacked()
+
+			return true
+		}, time.Second, time.Millisecond)
+
+		retried := msg.Copy()
+		channels[pubsub.TextTopic] <- retried
+
+		require.Eventually(t, func() bool {
+			<-retried.Acked()
+
+			return true
+		}, time.Second, time.Millisecond)
+
+		mockedQueue.AssertExpectations(t)
+		mockedBot.AssertExpectations(t)
+	})
+}
+
+func expectDeadLetter(mockedQueue *mq.Queue, topic pubsub.TopicName, handler string) {
+	mockedQueue.On("Publish", pubsub.DeadLetterTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+		var e pubsub.DeadLetterEvent
 
-	return th, mockedQueue, mockedBot, textChannel, photoChannel
+		return easyjson.Unmarshal(m.Payload, &e) == nil &&
+			e.Handler == handler &&
+			e.Topic == topic.String() &&
+			e.Attempts == 1
+	})).Once().
+		Return(nil)
 }
 
 func sendMessageToChannel(t *testing.T, channel chan *message.Message, eventMsg []byte) {
@@ -255,11 +729,11 @@ func sendMessageToChannel(t *testing.T, channel chan *message.Message, eventMsg
 func matchTelegramPhoto() func(m interface{}) bool {
 	return func(m interface{}) bool {
 		var (
-			p  *bot.TelegramPhoto
+			p  bot.TelegramPhoto
 			ok bool
 		)
 
-		if p, ok = m.(*bot.TelegramPhoto); !ok {
+		if p, ok = m.(bot.TelegramPhoto); !ok {
 			return false
 		}
 
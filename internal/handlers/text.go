@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+func PlainText(m pubsub.TextEvent) string {
+	units := utf16.Encode([]rune(m.Text))
+	links := map[int][]string{}
+	ends := make([]int, 0, len(m.Entities))
+
+	for _, e := range m.Entities {
+		end := e.Offset + e.Length
+		if e.Type != "text_link" || e.URL == "" || end > len(units) {
+			continue
+		}
+
+		if _, ok := links[end]; !ok {
+			ends = append(ends, end)
+		}
+
+		links[end] = append(links[end], e.URL)
+	}
+
+	if len(ends) == 0 {
+		return m.Text
+	}
+
+	sort.Ints(ends)
+
+	var (
+		sb    strings.Builder
+		start int
+	)
+
+	for _, end := range ends {
+		sb.WriteString(string(utf16.Decode(units[start:end])))
+
+		for _, url := range links[end] {
+			sb.WriteString(" (" + url + ")")
+		}
+
+		start = end
+	}
+
+	sb.WriteString(string(utf16.Decode(units[start:])))
+
+	return sb.String()
+}
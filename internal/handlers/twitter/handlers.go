@@ -2,17 +2,36 @@ package handlerstwitter
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/mailru/easyjson"
 	"github.com/quintodown/quintodownbot/internal/bot"
+	"github.com/quintodown/quintodownbot/internal/config"
 	"github.com/quintodown/quintodownbot/internal/handlers"
 	"github.com/quintodown/quintodownbot/internal/pubsub"
 )
 
+const (
+	textEvent  = "text"
+	photoEvent = "photo"
+	albumEvent = "album"
+	videoEvent = "video"
+)
+
+var errUnknownAccount = errors.New("unknown twitter account")
+
 type Twitter struct {
-	tc           bot.TwitterClient
-	q            pubsub.Queue
-	shouldNotify bool
+	handlers.NotificationState
+	handlers.Workers
+
+	tc       bot.TwitterClient
+	accounts map[string]bot.TwitterClient
+	cfg      config.AppConfig
+	q        pubsub.Queue
+	ps       handlers.Posts
+	rp       handlers.RetryPolicy
+	r        *handlers.Retrier
 }
 
 type Option func(b *Twitter)
@@ -23,19 +42,45 @@ func WithTwitterClient(tc bot.TwitterClient) Option {
 	}
 }
 
+func WithAccount(name string, tc bot.TwitterClient) Option {
+	return func(t *Twitter) {
+		t.accounts[name] = tc
+	}
+}
+
+func WithAppConfig(cfg config.AppConfig) Option {
+	return func(t *Twitter) {
+		t.cfg = cfg
+	}
+}
+
 func WithQueue(q pubsub.Queue) Option {
 	return func(t *Twitter) {
 		t.q = q
 	}
 }
 
+func WithPosts(ps handlers.Posts) Option {
+	return func(t *Twitter) {
+		t.ps = ps
+	}
+}
+
+func WithRetryPolicy(rp handlers.RetryPolicy) Option {
+	return func(t *Twitter) {
+		t.rp = rp
+	}
+}
+
 func NewTwitter(options ...Option) *Twitter {
-	t := &Twitter{shouldNotify: true}
+	t := &Twitter{accounts: map[string]bot.TwitterClient{}}
 
 	for _, o := range options {
 		o(t)
 	}
 
+	t.r = handlers.NewRetrier(t.q, t.ID(), t.rp)
+
 	return t
 }
 
@@ -46,21 +91,22 @@ func (t *Twitter) ID() string {
 func (t *Twitter) ExecuteHandlers(ctx context.Context) {
 	t.handleText(ctx)
 	t.handlePhoto(ctx)
-}
-
-func (t *Twitter) StopNotifications() {
-	t.shouldNotify = false
+	t.handleAlbum(ctx)
+	t.handleVideo(ctx)
+	t.handleDelete(ctx)
 }
 
 func (t *Twitter) handleText(ctx context.Context) {
 	messages, err := t.q.Subscribe(ctx, pubsub.TextTopic.String())
 	if err != nil {
 		handlers.SendError(t.q, err)
+
+		return
 	}
 
-	go func() {
+	t.Go(func() {
 		for msg := range messages {
-			if !t.shouldNotify {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
 				msg.Ack()
 
 				continue
@@ -74,24 +120,43 @@ func (t *Twitter) handleText(ctx context.Context) {
 				continue
 			}
 
-			if err := t.tc.SendUpdate(m.Text); err != nil {
-				handlers.SendError(t.q, err)
+			if !m.Destinations.Includes(t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			event := m.Kind
+			if event == "" {
+				event = textEvent
+			}
+
+			accounts, text := t.route(event, m.Text), handlers.PlainText(m)
+
+			if err := t.publish(m.Source, m.Edit, accounts, text, func(tc bot.TwitterClient) ([]string, error) {
+				return tc.SendUpdate(text)
+			}); err != nil {
+				t.r.Fail(msg, pubsub.TextTopic, err)
+
+				continue
 			}
 
-			msg.Ack()
+			t.r.Ack(msg)
 		}
-	}()
+	})
 }
 
 func (t *Twitter) handlePhoto(ctx context.Context) {
 	messages, err := t.q.Subscribe(ctx, pubsub.PhotoTopic.String())
 	if err != nil {
 		handlers.SendError(t.q, err)
+
+		return
 	}
 
-	go func() {
+	t.Go(func() {
 		for msg := range messages {
-			if !t.shouldNotify {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
 				msg.Ack()
 
 				continue
@@ -105,11 +170,272 @@ func (t *Twitter) handlePhoto(ctx context.Context) {
 				continue
 			}
 
-			if err := t.tc.SendUpdateWithPhoto(m.Caption, m.FileContent); err != nil {
+			if !m.Destinations.Includes(t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			accounts := t.route(photoEvent, m.Caption)
+
+			if err := t.publish(m.Source, m.Edit, accounts, m.Caption, func(tc bot.TwitterClient) ([]string, error) {
+				return tc.SendUpdateWithPhoto(m.Caption, m.FileContent)
+			}); err != nil {
+				t.r.Fail(msg, pubsub.PhotoTopic, err)
+
+				continue
+			}
+
+			t.r.Ack(msg)
+		}
+	})
+}
+
+func (t *Twitter) handleAlbum(ctx context.Context) {
+	messages, err := t.q.Subscribe(ctx, pubsub.AlbumTopic.String())
+	if err != nil {
+		handlers.SendError(t.q, err)
+
+		return
+	}
+
+	t.Go(func() {
+		for msg := range messages {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			var m pubsub.AlbumEvent
+			if err := easyjson.Unmarshal(msg.Payload, &m); err != nil {
 				handlers.SendError(t.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if !m.Destinations.Includes(t.ID()) {
+				msg.Ack()
+
+				continue
 			}
 
-			msg.Ack()
+			pics := make([][]byte, 0, len(m.Photos))
+			for i := range m.Photos {
+				pics = append(pics, m.Photos[i].FileContent)
+			}
+
+			accounts := t.route(albumEvent, m.Caption)
+
+			if err := t.publish(m.Source, false, accounts, m.Caption, func(tc bot.TwitterClient) ([]string, error) {
+				return tc.SendUpdateWithPhoto(m.Caption, pics...)
+			}); err != nil {
+				t.r.Fail(msg, pubsub.AlbumTopic, err)
+
+				continue
+			}
+
+			t.r.Ack(msg)
 		}
-	}()
+	})
+}
+
+func (t *Twitter) handleVideo(ctx context.Context) {
+	messages, err := t.q.Subscribe(ctx, pubsub.VideoTopic.String())
+	if err != nil {
+		handlers.SendError(t.q, err)
+
+		return
+	}
+
+	t.Go(func() {
+		for msg := range messages {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			var m pubsub.VideoEvent
+			if err := easyjson.Unmarshal(msg.Payload, &m); err != nil {
+				handlers.SendError(t.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if !m.Destinations.Includes(t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			accounts := t.route(videoEvent, m.Caption)
+
+			if err := t.publish(m.Source, m.Edit, accounts, m.Caption, func(tc bot.TwitterClient) ([]string, error) {
+				return tc.SendUpdateWithVideo(m.Caption, m.FileContent)
+			}); err != nil {
+				t.r.Fail(msg, pubsub.VideoTopic, err)
+
+				continue
+			}
+
+			t.r.Ack(msg)
+		}
+	})
+}
+
+func (t *Twitter) handleDelete(ctx context.Context) {
+	messages, err := t.q.Subscribe(ctx, pubsub.DeleteTopic.String())
+	if err != nil {
+		handlers.SendError(t.q, err)
+
+		return
+	}
+
+	t.Go(func() {
+		for msg := range messages {
+			if t.IsPaused() || !handlers.IsAddressedTo(msg, t.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			var m pubsub.DeleteEvent
+			if err := easyjson.Unmarshal(msg.Payload, &m); err != nil {
+				handlers.SendError(t.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if err := t.delete(m.Source); err != nil {
+				t.r.Fail(msg, pubsub.DeleteTopic, err)
+
+				continue
+			}
+
+			t.r.Ack(msg)
+		}
+	})
+}
+
+// publish sends a post to every account it wasn't published in yet, replacing it when it's edited. A thread that
+// failed partway is continued from its last tweet with text on retry instead of being published again.
+func (t *Twitter) publish(
+	source string,
+	edit bool,
+	accounts []string,
+	text string,
+	send func(tc bot.TwitterClient) ([]string, error),
+) error {
+	if edit {
+		return t.published(source, func(tc bot.TwitterClient, d handlers.PublishedDestination) error {
+			if err := tc.DeleteUpdates(d.IDs...); err != nil {
+				return err
+			}
+
+			handlers.SavePost(t.q, t.ps, source, d.Key, nil)
+
+			return t.save(source, d.Key, func() ([]string, error) {
+				return send(tc)
+			})
+		})
+	}
+
+	for _, name := range accounts {
+		tc, ok := t.client(name)
+		if !ok {
+			handlers.SendError(t.q, fmt.Errorf("%w: %s", errUnknownAccount, name))
+
+			continue
+		}
+
+		key := handlers.PostKey(t.ID(), name)
+
+		ids, published, err := handlers.PublishedMessages(t.ps, source, key)
+		if err != nil {
+			return err
+		}
+
+		if published {
+			continue
+		}
+
+		if err := t.save(source, key, func() ([]string, error) {
+			if len(ids) > 0 {
+				return tc.ContinueThread(text, ids)
+			}
+
+			return send(tc)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Twitter) save(source, key string, send func() ([]string, error)) error {
+	ids, err := send()
+	if err != nil {
+		handlers.SaveUnfinished(t.q, t.ps, source, key, ids)
+
+		return err
+	}
+
+	handlers.SavePost(t.q, t.ps, source, key, ids)
+
+	return nil
+}
+
+func (t *Twitter) delete(source string) error {
+	return t.published(source, func(tc bot.TwitterClient, d handlers.PublishedDestination) error {
+		if err := tc.DeleteUpdates(d.IDs...); err != nil {
+			return err
+		}
+
+		if err := t.ps.Remove(source, d.Key); err != nil {
+			handlers.SendError(t.q, err)
+		}
+
+		return nil
+	})
+}
+
+func (t *Twitter) published(source string, f func(tc bot.TwitterClient, d handlers.PublishedDestination) error) error {
+	destinations, err := handlers.PublishedDestinations(t.ps, source, t.ID())
+	if err != nil {
+		return err
+	}
+
+	for _, d := range destinations {
+		tc, ok := t.client(d.Name)
+		if !ok {
+			handlers.SendError(t.q, fmt.Errorf("%w: %s", errUnknownAccount, d.Name))
+
+			continue
+		}
+
+		if err := f(tc, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Twitter) client(name string) (bot.TwitterClient, bool) {
+	if name == config.DefaultDestination {
+		return t.tc, t.tc != nil
+	}
+
+	tc, ok := t.accounts[name]
+
+	return tc, ok
+}
+
+func (t *Twitter) route(event, text string) []string {
+	return t.cfg.TwitterRoute(event, handlers.Hashtags(text))
 }
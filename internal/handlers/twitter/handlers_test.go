@@ -8,9 +8,14 @@ import (
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/config"
+	"github.com/quintodown/quintodownbot/internal/handlers"
 	ht "github.com/quintodown/quintodownbot/internal/handlers/twitter"
+	"github.com/quintodown/quintodownbot/internal/posts"
 	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
 	mb "github.com/quintodown/quintodownbot/mocks/bot"
+	mh "github.com/quintodown/quintodownbot/mocks/handlers"
 	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -38,10 +43,10 @@ func TestTwitter_ID(t *testing.T) {
 }
 
 func TestTwitter_ExecuteHandlers(t *testing.T) {
-	t.Run("it should fail getting channel for text and photo notifications", func(t *testing.T) {
+	t.Run("it should fail getting channel for notifications", func(t *testing.T) {
 		ctx := context.Background()
 
-		th, mockedQueue, _, _, _ := getTwitterHandlerAndMocks(ctx, false)
+		th, mockedQueue, _, _ := getTwitterHandlerAndMocks(ctx, false)
 
 		mockedQueue.On("Subscribe", context.Background(), pubsub.TextTopic.String()).
 			Once().
@@ -49,12 +54,22 @@ func TestTwitter_ExecuteHandlers(t *testing.T) {
 		mockedQueue.On("Subscribe", context.Background(), pubsub.PhotoTopic.String()).
 			Once().
 			Return(nil, channelError{})
+		mockedQueue.On("Subscribe", context.Background(), pubsub.AlbumTopic.String()).
+			Once().
+			Return(nil, channelError{})
+		mockedQueue.On("Subscribe", context.Background(), pubsub.VideoTopic.String()).
+			Once().
+			Return(nil, channelError{})
+		mockedQueue.On("Subscribe", context.Background(), pubsub.DeleteTopic.String()).
+			Once().
+			Return(nil, channelError{})
 		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
 			return string(m.Payload) == "{\"error\":\"error getting channel error\"}"
-		})).Times(2).
+		})).Times(5).
 			Return(nil)
 
 		th.ExecuteHandlers(ctx)
+		th.Wait()
 
 		mockedQueue.AssertExpectations(t)
 	})
@@ -64,7 +79,7 @@ func TestTwitter_ExecuteHandlersText(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("it should fail unmarshaling text event", func(t *testing.T) {
-		th, mockedQueue, _, textChannel, _ := getTwitterHandlerAndMocks(ctx, true)
+		th, mockedQueue, _, channels := getTwitterHandlerAndMocks(ctx, true)
 
 		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
 			return string(m.Payload) ==
@@ -74,13 +89,13 @@ func TestTwitter_ExecuteHandlersText(t *testing.T) {
 
 		th.ExecuteHandlers(ctx)
 
-		sendMessageToChannel(t, textChannel, []byte("{\"asd\":\"qwer"))
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"asd\":\"qwer"))
 
 		mockedQueue.AssertExpectations(t)
 	})
 
 	t.Run("it should fail sending text message to twitter", func(t *testing.T) {
-		th, mockedQueue, mockedTwitter, textChannel, _ := getTwitterHandlerAndMocks(ctx, true)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
 
 		mockedQueue.On(
 			"Publish",
@@ -92,24 +107,55 @@ func TestTwitter_ExecuteHandlersText(t *testing.T) {
 			Return(nil)
 		mockedTwitter.On("SendUpdate", "testing message").
 			Once().
-			Return(messageNotSendError{})
+			Return(nil, messageNotSendError{})
+		expectDeadLetter(mockedQueue, pubsub.TextTopic, "twitter")
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+	})
+
+	t.Run("it should skip text message addressed only to telegram", func(t *testing.T) {
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
 
 		th.ExecuteHandlers(ctx)
 
-		sendMessageToChannel(t, textChannel, []byte("{\"text\":\"testing message\"}"))
+		sendMessageToChannel(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"testing message\",\"destinations\":[\"telegram\"]}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertNotCalled(t, "SendUpdate", mock.Anything)
+	})
+
+	t.Run("it should send text message with expanded links to twitter", func(t *testing.T) {
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
+
+		mockedTwitter.On("SendUpdate", "😀 testing message (https://quintodown.com) now").Once().Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"😀 testing message now\","+
+			"\"entities\":[{\"type\":\"bold\",\"offset\":3,\"length\":7},"+
+			"{\"type\":\"text_link\",\"offset\":11,\"length\":7,\"url\":\"https://quintodown.com\"}]}"))
 
 		mockedQueue.AssertExpectations(t)
 		mockedTwitter.AssertExpectations(t)
 	})
 
 	t.Run("it should send text message to twitter", func(t *testing.T) {
-		th, mockedQueue, mockedTwitter, textChannel, _ := getTwitterHandlerAndMocks(ctx, true)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
 
-		mockedTwitter.On("SendUpdate", "testing message").Once().Return(nil)
+		mockedTwitter.On("SendUpdate", "testing message").Once().Return(nil, nil)
 
 		th.ExecuteHandlers(ctx)
 
-		sendMessageToChannel(t, textChannel, []byte("{\"text\":\"testing message\"}"))
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
 
 		mockedQueue.AssertExpectations(t)
 		mockedTwitter.AssertExpectations(t)
@@ -129,7 +175,7 @@ func TestTwitter_ExecuteHandlersPhoto(t *testing.T) {
 	})
 
 	t.Run("it should fail unmarshaling photo event", func(t *testing.T) {
-		th, mockedQueue, _, _, photoChannel := getTwitterHandlerAndMocks(context.Background(), true)
+		th, mockedQueue, _, channels := getTwitterHandlerAndMocks(context.Background(), true)
 
 		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
 			return string(m.Payload) ==
@@ -138,13 +184,13 @@ func TestTwitter_ExecuteHandlersPhoto(t *testing.T) {
 
 		th.ExecuteHandlers(context.Background())
 
-		sendMessageToChannel(t, photoChannel, []byte("{\"asd\":\"qwer"))
+		sendMessageToChannel(t, channels[pubsub.PhotoTopic], []byte("{\"asd\":\"qwer"))
 
 		mockedQueue.AssertExpectations(t)
 	})
 
 	t.Run("it should fail sending photo to twitter", func(t *testing.T) {
-		th, mockedQueue, mockedTwitter, _, photoChannel := getTwitterHandlerAndMocks(context.Background(), true)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(context.Background(), true)
 
 		mockedQueue.On(
 			"Publish",
@@ -154,25 +200,98 @@ func TestTwitter_ExecuteHandlersPhoto(t *testing.T) {
 			}),
 		).Once().Return(nil)
 		mockedTwitter.On("SendUpdateWithPhoto", "testing caption", photoContent).
-			Once().Return(messageNotSendError{})
+			Once().Return(nil, messageNotSendError{})
+		expectDeadLetter(mockedQueue, pubsub.PhotoTopic, "twitter")
 
 		th.ExecuteHandlers(context.Background())
 
-		sendMessageToChannel(t, photoChannel, bytes)
+		sendMessageToChannel(t, channels[pubsub.PhotoTopic], bytes)
 
 		mockedQueue.AssertExpectations(t)
 		mockedTwitter.AssertExpectations(t)
 	})
 
 	t.Run("it should send photo to twitter", func(t *testing.T) {
-		th, mockedQueue, mockedTwitter, _, photoChannel := getTwitterHandlerAndMocks(context.Background(), true)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(context.Background(), true)
 
 		mockedTwitter.On("SendUpdateWithPhoto", "testing caption", photoContent).
-			Once().Return(nil)
+			Once().Return(nil, nil)
 
 		th.ExecuteHandlers(context.Background())
 
-		sendMessageToChannel(t, photoChannel, bytes)
+		sendMessageToChannel(t, channels[pubsub.PhotoTopic], bytes)
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+	})
+}
+
+func TestTwitter_ExecuteHandlersAlbum(t *testing.T) {
+	ctx := context.Background()
+	eventMsg := []byte("{\"caption\":\"testing album\",\"photos\":[" +
+		"{\"fileId\":\"photo1\",\"fileContent\":\"cGhvdG8x\"}," +
+		"{\"fileId\":\"photo2\",\"fileContent\":\"cGhvdG8y\"}]}")
+
+	t.Run("it should fail sending album to twitter", func(t *testing.T) {
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
+
+		mockedTwitter.On("SendUpdateWithPhoto", "testing album", []byte("photo1"), []byte("photo2")).
+			Once().
+			Return(nil, messageNotSendError{})
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.Anything).Once().Return(nil)
+		expectDeadLetter(mockedQueue, pubsub.AlbumTopic, "twitter")
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.AlbumTopic], eventMsg)
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+	})
+
+	t.Run("it should send album to twitter", func(t *testing.T) {
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
+
+		mockedTwitter.On("SendUpdateWithPhoto", "testing album", []byte("photo1"), []byte("photo2")).
+			Once().
+			Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.AlbumTopic], eventMsg)
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+	})
+}
+
+func TestTwitter_ExecuteHandlersVideo(t *testing.T) {
+	ctx := context.Background()
+	eventMsg := []byte("{\"caption\":\"goal\",\"fileId\":\"video\",\"fileContent\":\"dmlkZW8=\"}")
+
+	t.Run("it should fail sending video to twitter", func(t *testing.T) {
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
+
+		mockedTwitter.On("SendUpdateWithVideo", "goal", []byte("video")).Once().Return(nil, messageNotSendError{})
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.Anything).Once().Return(nil)
+		expectDeadLetter(mockedQueue, pubsub.VideoTopic, "twitter")
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.VideoTopic], eventMsg)
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+	})
+
+	t.Run("it should send video to twitter", func(t *testing.T) {
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
+
+		mockedTwitter.On("SendUpdateWithVideo", "goal", []byte("video")).Once().Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.VideoTopic], eventMsg)
 
 		mockedQueue.AssertExpectations(t)
 		mockedTwitter.AssertExpectations(t)
@@ -183,12 +302,12 @@ func TestTwitter_ExecuteHandlersNotificationsDisabled(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("it should not send text message to twitter when notifications disabled", func(t *testing.T) {
-		th, mockedQueue, mockedTwitter, textChannel, _ := getTwitterHandlerAndMocks(ctx, true)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
 
 		th.StopNotifications()
 		th.ExecuteHandlers(ctx)
 
-		sendMessageToChannel(t, textChannel, []byte("{\"text\":\"testing message\"}"))
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
 
 		mockedQueue.AssertExpectations(t)
 		mockedTwitter.Test(t)
@@ -207,48 +326,374 @@ func TestTwitter_ExecuteHandlersNotificationsDisabled(t *testing.T) {
 			FileContent: photoContent,
 		})
 
-		th, mockedQueue, mockedTwitter, _, photoChannel := getTwitterHandlerAndMocks(context.Background(), true)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(context.Background(), true)
 
 		th.StopNotifications()
 		th.ExecuteHandlers(context.Background())
 
-		sendMessageToChannel(t, photoChannel, bytes)
+		sendMessageToChannel(t, channels[pubsub.PhotoTopic], bytes)
 
 		mockedQueue.AssertExpectations(t)
 		mockedTwitter.Test(t)
 		mockedTwitter.AssertNotCalled(t, "SendUpdateWithPhoto", "testing caption", photoContent)
 	})
+
+	t.Run("it should send text message to twitter when notifications resumed", func(t *testing.T) {
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
+
+		mockedTwitter.On("SendUpdate", "testing message").Once().Return(nil, nil)
+
+		th.StopNotifications()
+		require.True(t, th.IsPaused())
+
+		th.ResumeNotifications()
+		require.False(t, th.IsPaused())
+
+		th.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+	})
+}
+
+func TestTwitter_ExecuteHandlersPosts(t *testing.T) {
+	ctx := context.Background()
+	published := posts.Post{Source: "12345:7", Messages: map[string][]string{"twitter": {"1445823463904798049"}}}
+
+	t.Run("it should record the published tweets", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+		mockedTwitter.On("SendUpdate", "testing message").Once().Return([]string{"1445823463904798049"}, nil)
+		ps.On("Save", "12345:7", "twitter", []string{"1445823463904798049"}).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should keep the tweets sent before the thread failed", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+		mockedTwitter.On("SendUpdate", "testing message").
+			Once().
+			Return([]string{"1445823463904798049"}, messageNotSendError{})
+		ps.On("SaveUnfinished", "12345:7", "twitter", []string{"1445823463904798049"}).Once().Return(nil)
+		mockedQueue.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"))
+
+		mockedTwitter.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should continue an unfinished thread from its last tweet", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{
+			Source:     "12345:7",
+			Messages:   published.Messages,
+			Unfinished: []string{"twitter"},
+		}, nil)
+		mockedTwitter.On("ContinueThread", "testing message", []string{"1445823463904798049"}).
+			Once().
+			Return([]string{"1445823463904798049", "1445823463904798051"}, nil)
+		ps.On("Save", "12345:7", "twitter", []string{"1445823463904798049", "1445823463904798051"}).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"testing message\",\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+		mockedTwitter.AssertNotCalled(t, "SendUpdate", mock.Anything)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should replace the published tweets when the post is edited", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(published, nil)
+		mockedTwitter.On("DeleteUpdates", "1445823463904798049").Once().Return(nil)
+		ps.On("Save", "12345:7", "twitter", []string(nil)).Once().Return(nil)
+		mockedTwitter.On("SendUpdate", "new text").Once().Return([]string{"1445823463904798051"}, nil)
+		ps.On("Save", "12345:7", "twitter", []string{"1445823463904798051"}).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"new text\",\"source\":\"12345:7\",\"edit\":true}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should skip edits of posts not published on twitter", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{}, storage.ErrNotFound)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(
+			t,
+			channels[pubsub.TextTopic],
+			[]byte("{\"text\":\"new text\",\"source\":\"12345:7\",\"edit\":true}"),
+		)
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertNotCalled(t, "SendUpdate", mock.Anything)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should delete the published tweets", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(published, nil)
+		mockedTwitter.On("DeleteUpdates", "1445823463904798049").Once().Return(nil)
+		ps.On("Remove", "12345:7", "twitter").Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.DeleteTopic], []byte("{\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should retry when tweets couldn't be deleted", func(t *testing.T) {
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true, ht.WithPosts(ps))
+
+		ps.On("Get", "12345:7").Once().Return(published, nil)
+		mockedTwitter.On("DeleteUpdates", "1445823463904798049").Once().Return(messageNotSendError{})
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.Anything).Once().Return(nil)
+		expectDeadLetter(mockedQueue, pubsub.DeleteTopic, "twitter")
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.DeleteTopic], []byte("{\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+		ps.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTwitter_ExecuteHandlersAccounts(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.AppConfig{TwitterRoutes: map[string]string{"game": "scores", "#NFL": "scores+default"}}
+
+	t.Run("it should publish game events with their account", func(t *testing.T) {
+		scores := new(mb.TwitterClient)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(
+			ctx,
+			true,
+			ht.WithAppConfig(cfg),
+			ht.WithAccount("scores", scores),
+		)
+
+		scores.On("SendUpdate", "game started").Once().Return([]string{"1"}, nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"game started\",\"kind\":\"game\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		scores.AssertExpectations(t)
+		mockedTwitter.AssertNotCalled(t, "SendUpdate", mock.Anything)
+	})
+
+	t.Run("it should publish posts in every account of their hashtags", func(t *testing.T) {
+		scores := new(mb.TwitterClient)
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(
+			ctx,
+			true,
+			ht.WithAppConfig(cfg),
+			ht.WithAccount("scores", scores),
+			ht.WithPosts(ps),
+		)
+
+		ps.On("Get", "12345:7").Return(posts.Post{}, storage.ErrNotFound)
+		scores.On("SendUpdate", "#NFL touchdown").Once().Return([]string{"1"}, nil)
+		mockedTwitter.On("SendUpdate", "#NFL touchdown").Once().Return([]string{"2"}, nil)
+		ps.On("Save", "12345:7", "twitter:scores", []string{"1"}).Once().Return(nil)
+		ps.On("Save", "12345:7", "twitter", []string{"2"}).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"#NFL touchdown\",\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		scores.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should delete the tweets of every account", func(t *testing.T) {
+		scores := new(mb.TwitterClient)
+		ps := new(mh.Posts)
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(
+			ctx,
+			true,
+			ht.WithAppConfig(cfg),
+			ht.WithAccount("scores", scores),
+			ht.WithPosts(ps),
+		)
+
+		ps.On("Get", "12345:7").Once().Return(posts.Post{
+			Source:   "12345:7",
+			Messages: map[string][]string{"twitter": {"2"}, "twitter:scores": {"1"}, "telegram": {"10"}},
+		}, nil)
+		mockedTwitter.On("DeleteUpdates", "2").Once().Return(nil)
+		scores.On("DeleteUpdates", "1").Once().Return(nil)
+		ps.On("Remove", "12345:7", "twitter").Once().Return(nil)
+		ps.On("Remove", "12345:7", "twitter:scores").Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.DeleteTopic], []byte("{\"source\":\"12345:7\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		scores.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+		ps.AssertExpectations(t)
+	})
+
+	t.Run("it should report accounts missing in the configuration", func(t *testing.T) {
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true, ht.WithAppConfig(cfg))
+
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"unknown twitter account: scores\"}"
+		})).Once().Return(nil)
+
+		th.ExecuteHandlers(ctx)
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"game started\",\"kind\":\"game\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertNotCalled(t, "SendUpdate", mock.Anything)
+	})
 }
 
-func getTwitterHandlerAndMocks(ctx context.Context, returnChannels bool) (
+func getTwitterHandlerAndMocks(ctx context.Context, returnChannels bool, options ...ht.Option) (
 	*ht.Twitter,
 	*mq.Queue,
 	*mb.TwitterClient,
-	chan *message.Message,
-	chan *message.Message,
+	map[pubsub.TopicName]chan *message.Message,
 ) {
 	mockedTwitter := new(mb.TwitterClient)
 	mockedQueue := new(mq.Queue)
 
-	th := ht.NewTwitter(ht.WithTwitterClient(mockedTwitter), ht.WithQueue(mockedQueue))
+	th := ht.NewTwitter(append([]ht.Option{ht.WithTwitterClient(mockedTwitter), ht.WithQueue(mockedQueue)}, options...)...)
 
-	textChannel := make(chan *message.Message)
-	photoChannel := make(chan *message.Message)
+	channels := map[pubsub.TopicName]chan *message.Message{}
 
-	if returnChannels {
-		mockedQueue.On("Subscribe", ctx, pubsub.TextTopic.String()).
-			Once().
-			Return(func(context.Context, string) <-chan *message.Message {
-				return textChannel
-			}, nil)
-		mockedQueue.On("Subscribe", ctx, pubsub.PhotoTopic.String()).
-			Once().
-			Return(func(context.Context, string) <-chan *message.Message {
-				return photoChannel
-			}, nil)
+	topics := []pubsub.TopicName{
+		pubsub.TextTopic,
+		pubsub.PhotoTopic,
+		pubsub.AlbumTopic,
+		pubsub.VideoTopic,
+		pubsub.DeleteTopic,
+	}
+
+	for _, topic := range topics {
+		channel := make(chan *message.Message)
+		channels[topic] = channel
+
+		if returnChannels {
+			mockedQueue.On("Subscribe", ctx, topic.String()).
+				Once().
+				Return(func(context.Context, string) <-chan *message.Message {
+					return channel
+				}, nil)
+		}
 	}
 
-	return th, mockedQueue, mockedTwitter, textChannel, photoChannel
+	return th, mockedQueue, mockedTwitter, channels
+}
+
+func TestTwitter_ExecuteHandlersRetries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it should retry sending text message to twitter until it succeeds", func(t *testing.T) {
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(
+			ctx,
+			true,
+			ht.WithRetryPolicy(handlers.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+		)
+
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"couldn't send message to twitter\"}"
+		})).Once().
+			Return(nil)
+		mockedTwitter.On("SendUpdate", "testing message").Once().Return(nil, messageNotSendError{})
+		mockedTwitter.On("SendUpdate", "testing message").Once().Return(nil, nil)
+
+		th.ExecuteHandlers(ctx)
+
+		msg := message.NewMessage(watermill.NewUUID(), []byte("{\"text\":\"testing message\"}"))
+		channels[pubsub.TextTopic] <- msg
+
+		require.Eventually(t, func() bool {
+			<-msg.Nacked()
+
+			return true
+		}, time.Second, time.Millisecond)
+
+		retried := msg.Copy()
+		channels[pubsub.TextTopic] <- retried
+
+		require.Eventually(t, func() bool {
+			<-retried.Acked()
+
+			return true
+		}, time.Second, time.Millisecond)
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertExpectations(t)
+	})
+
+	t.Run("it should skip messages addressed to another handler", func(t *testing.T) {
+		th, mockedQueue, mockedTwitter, channels := getTwitterHandlerAndMocks(ctx, true)
+
+		th.ExecuteHandlers(ctx)
+
+		msg := message.NewMessage(watermill.NewUUID(), []byte("{\"text\":\"testing message\"}"))
+		msg.Metadata.Set(pubsub.TargetHandlerMetadata, "telegram")
+		channels[pubsub.TextTopic] <- msg
+
+		require.Eventually(t, func() bool {
+			<-msg.Acked()
+
+			return true
+		}, time.Second, time.Millisecond)
+
+		mockedQueue.AssertExpectations(t)
+		mockedTwitter.AssertNotCalled(t, "SendUpdate", mock.Anything)
+	})
+}
+
+func expectDeadLetter(mockedQueue *mq.Queue, topic pubsub.TopicName, handler string) {
+	mockedQueue.On("Publish", pubsub.DeadLetterTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+		var e pubsub.DeadLetterEvent
+
+		return easyjson.Unmarshal(m.Payload, &e) == nil &&
+			e.Handler == handler &&
+			e.Topic == topic.String() &&
+			e.Attempts == 1
+	})).Once().
+		Return(nil)
 }
 
 func sendMessageToChannel(t *testing.T, channel chan *message.Message, eventMsg []byte) {
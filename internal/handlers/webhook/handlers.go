@@ -0,0 +1,319 @@
+package handlerswebhook
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/webhook"
+)
+
+type Subscriptions interface {
+	List() ([]webhook.Subscription, error)
+}
+
+type Client interface {
+	Deliver(context.Context, webhook.Subscription, webhook.Delivery) error
+}
+
+var errSubscriptionRemoved = errors.New("webhook subscription removed")
+
+// Webhook posts the events to the webhook subscriptions. Every event is split in a delivery per subscription, and
+// each subscription handles its deliveries apart, so one backing off after a failure doesn't hold back the rest.
+type Webhook struct {
+	handlers.NotificationState
+	handlers.Workers
+
+	c  Client
+	ss Subscriptions
+	q  pubsub.Queue
+	rp handlers.RetryPolicy
+	r  *handlers.Retrier
+
+	mu         sync.Mutex
+	deliveries map[string]context.CancelFunc
+	fanned     map[string]map[string]bool
+}
+
+type Option func(w *Webhook)
+
+func WithWebhookClient(c Client) Option {
+	return func(w *Webhook) {
+		w.c = c
+	}
+}
+
+func WithSubscriptions(ss Subscriptions) Option {
+	return func(w *Webhook) {
+		w.ss = ss
+	}
+}
+
+func WithQueue(q pubsub.Queue) Option {
+	return func(w *Webhook) {
+		w.q = q
+	}
+}
+
+func WithRetryPolicy(rp handlers.RetryPolicy) Option {
+	return func(w *Webhook) {
+		w.rp = rp
+	}
+}
+
+func NewWebhook(options ...Option) *Webhook {
+	w := &Webhook{deliveries: map[string]context.CancelFunc{}, fanned: map[string]map[string]bool{}}
+
+	for _, o := range options {
+		o(w)
+	}
+
+	w.r = handlers.NewRetrier(w.q, w.ID(), w.rp)
+
+	return w
+}
+
+func (w *Webhook) ID() string {
+	return "webhook"
+}
+
+func (w *Webhook) ExecuteHandlers(ctx context.Context) {
+	subscriptions, err := w.ss.List()
+	if err != nil {
+		handlers.SendError(w.q, err)
+	}
+
+	for _, s := range subscriptions {
+		w.handleDeliveries(ctx, s.ID)
+	}
+
+	for _, topic := range webhook.Topics() {
+		w.handleTopic(ctx, topic)
+	}
+}
+
+func (w *Webhook) handleTopic(ctx context.Context, topic pubsub.TopicName) {
+	messages, err := w.q.Subscribe(ctx, topic.String())
+	if err != nil {
+		handlers.SendError(w.q, err)
+
+		return
+	}
+
+	w.Go(func() {
+		for msg := range messages {
+			if w.IsPaused() || !handlers.IsAddressedTo(msg, w.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			body, err := payload(msg, topic)
+			if err != nil {
+				handlers.SendError(w.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if err := w.fanOut(ctx, msg.UUID, topic, body); err != nil {
+				if !w.r.Fail(msg, topic, err) {
+					w.forget(msg.UUID)
+				}
+
+				continue
+			}
+
+			w.forget(msg.UUID)
+			w.r.Ack(msg)
+		}
+	})
+}
+
+// fanOut publishes a delivery of the event for every subscription to its topic. The deliveries published are
+// remembered until the event is acked, so retrying the event only publishes the ones that failed.
+func (w *Webhook) fanOut(ctx context.Context, id string, topic pubsub.TopicName, body []byte) error {
+	subscriptions, err := w.ss.List()
+	if err != nil {
+		return err
+	}
+
+	w.stopRemoved(subscriptions)
+
+	var errs []error
+
+	for _, s := range subscriptions {
+		if !s.Includes(topic) || w.isFanned(id, s.ID) {
+			continue
+		}
+
+		w.handleDeliveries(ctx, s.ID)
+
+		eb, _ := easyjson.Marshal(pubsub.WebhookEvent{
+			Subscription: s.ID,
+			ID:           id,
+			Topic:        webhook.TopicName(topic),
+			Body:         body,
+		})
+
+		if err := w.q.Publish(pubsub.WebhookTopic.String(), message.NewMessage(id+"-"+s.ID, eb)); err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		w.setFanned(id, s.ID)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (w *Webhook) isFanned(id, subscription string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.fanned[id][subscription]
+}
+
+func (w *Webhook) setFanned(id, subscription string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.fanned[id] == nil {
+		w.fanned[id] = map[string]bool{}
+	}
+
+	w.fanned[id][subscription] = true
+}
+
+func (w *Webhook) forget(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.fanned, id)
+}
+
+// handleDeliveries starts the delivery loop of a subscription unless it's already running. Subscriptions added after
+// starting get their loop with their first delivery, and the loop stops once the subscription is removed.
+func (w *Webhook) handleDeliveries(ctx context.Context, id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.deliveries[id] != nil {
+		return
+	}
+
+	subscriber := w.ID() + "-" + id
+	loopCtx, stop := context.WithCancel(ctx)
+
+	messages, err := w.q.Subscribe(pubsub.WithSubscriber(loopCtx, subscriber), pubsub.WebhookTopic.String())
+	if err != nil {
+		stop()
+		handlers.SendError(w.q, err)
+
+		return
+	}
+
+	w.deliveries[id] = stop
+
+	w.GoStoppable(func() bool {
+		for msg := range messages {
+			var e pubsub.WebhookEvent
+			if err := easyjson.Unmarshal(msg.Payload, &e); err != nil {
+				handlers.SendError(w.q, err)
+				msg.Ack()
+
+				continue
+			}
+
+			if e.Subscription != id || w.IsPaused() || !handlers.IsAddressedTo(msg, w.ID()) {
+				msg.Ack()
+
+				continue
+			}
+
+			err := w.deliver(loopCtx, e)
+			if errors.Is(err, errSubscriptionRemoved) {
+				msg.Ack()
+				w.stop(id)
+
+				continue
+			}
+
+			if err != nil {
+				w.r.Fail(msg, pubsub.WebhookTopic, err)
+
+				continue
+			}
+
+			w.r.Ack(msg)
+		}
+
+		if ctx.Err() != nil {
+			return false
+		}
+
+		w.unsubscribe(subscriber)
+
+		return true
+	})
+}
+
+// stopRemoved stops the delivery loops of the subscriptions that aren't listed anymore.
+func (w *Webhook) stopRemoved(subscriptions []webhook.Subscription) {
+	listed := make(map[string]bool, len(subscriptions))
+	for _, s := range subscriptions {
+		listed[s.ID] = true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for id, stop := range w.deliveries {
+		if !listed[id] {
+			stop()
+			delete(w.deliveries, id)
+		}
+	}
+}
+
+func (w *Webhook) stop(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if stop := w.deliveries[id]; stop != nil {
+		stop()
+		delete(w.deliveries, id)
+	}
+}
+
+// unsubscribe drops the deliveries still pending for a removed subscription from durable queues.
+func (w *Webhook) unsubscribe(subscriber string) {
+	u, ok := w.q.(pubsub.Unsubscriber)
+	if !ok {
+		return
+	}
+
+	if err := u.Unsubscribe(pubsub.WebhookTopic.String(), subscriber); err != nil {
+		handlers.SendError(w.q, err)
+	}
+}
+
+func (w *Webhook) deliver(ctx context.Context, e pubsub.WebhookEvent) error {
+	subscriptions, err := w.ss.List()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range subscriptions {
+		if s.ID == e.Subscription {
+			return w.c.Deliver(ctx, s, webhook.Delivery{ID: e.ID, Topic: e.Topic, Body: e.Body})
+		}
+	}
+
+	return errSubscriptionRemoved
+}
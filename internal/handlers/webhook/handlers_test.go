@@ -0,0 +1,357 @@
+package handlerswebhook_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	hw "github.com/quintodown/quintodownbot/internal/handlers/webhook"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/webhook"
+	mw "github.com/quintodown/quintodownbot/mocks/handlers/webhook"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type channelError struct{}
+
+func (c channelError) Error() string {
+	return "error getting channel error"
+}
+
+type deliveryError struct{}
+
+func (d deliveryError) Error() string {
+	return "error delivering webhook 1"
+}
+
+type publishError struct{}
+
+func (p publishError) Error() string {
+	return "error publishing delivery"
+}
+
+// flakyQueue fails the first publication of the failing messages and records the subscribers unsubscribed.
+type flakyQueue struct {
+	pubsub.Queue
+
+	mu           sync.Mutex
+	failing      map[string]bool
+	unsubscribed chan string
+}
+
+func (q *flakyQueue) Publish(topic string, messages ...*message.Message) error {
+	q.mu.Lock()
+	fail := q.failing[messages[0].UUID]
+	delete(q.failing, messages[0].UUID)
+	q.mu.Unlock()
+
+	if fail {
+		return publishError{}
+	}
+
+	return q.Queue.Publish(topic, messages...)
+}
+
+func (q *flakyQueue) Unsubscribe(_, subscriber string) error {
+	q.unsubscribed <- subscriber
+
+	return nil
+}
+
+var (
+	allTopics = webhook.Subscription{ID: "1", URL: "https://quintodown.com/hooks"}
+	gamesOnly = webhook.Subscription{ID: "2", URL: "https://hooks.slack.com/services/T0/B0/X", Topics: []string{"games"}}
+)
+
+func TestWebhook_ID(t *testing.T) {
+	require.Equal(t, "webhook", hw.NewWebhook().ID())
+}
+
+func TestWebhook_ExecuteHandlers(t *testing.T) {
+	t.Run("it should notify subscription errors", func(t *testing.T) {
+		ctx := context.Background()
+		mockedQueue := new(mq.Queue)
+		ss := new(mw.Subscriptions)
+		wh := hw.NewWebhook(hw.WithSubscriptions(ss), hw.WithQueue(mockedQueue))
+
+		ss.On("List").Once().Return(nil, nil)
+
+		for _, topic := range webhook.Topics() {
+			mockedQueue.On("Subscribe", ctx, topic.String()).Once().Return(nil, channelError{})
+		}
+
+		mockedQueue.On("Publish", pubsub.ErrorTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "{\"error\":\"error getting channel error\"}"
+		})).Times(len(webhook.Topics())).
+			Return(nil)
+
+		wh.ExecuteHandlers(ctx)
+		wh.Wait()
+
+		mockedQueue.AssertExpectations(t)
+	})
+
+	t.Run("it should deliver the public fields of events to the subscribed webhooks", func(t *testing.T) {
+		wh, ctx, q, mockedClient, ss := getWebhookHandlerAndMocks(t, handlers.RetryPolicy{MaxAttempts: 1})
+		delivered := make(chan webhook.Delivery, 3)
+
+		ss.On("List").Return([]webhook.Subscription{allTopics, gamesOnly}, nil)
+		mockedClient.On("Deliver", mock.Anything, allTopics, mock.Anything).
+			Twice().
+			Run(func(args mock.Arguments) { delivered <- args.Get(2).(webhook.Delivery) }).
+			Return(nil)
+		mockedClient.On("Deliver", mock.Anything, gamesOnly, mock.Anything).
+			Once().
+			Run(func(args mock.Arguments) { delivered <- args.Get(2).(webhook.Delivery) }).
+			Return(nil)
+
+		wh.ExecuteHandlers(ctx)
+
+		photo, _ := easyjson.Marshal(pubsub.PhotoEvent{
+			Caption:      "touchdown",
+			FileID:       "AgADBAADbq0xG",
+			FileContent:  []byte("\x89PNG\x0D\x0A\x1A\x0A"),
+			Destinations: pubsub.Destinations{"twitter"},
+			Source:       "1234:1",
+		})
+		msg := message.NewMessage(watermill.NewUUID(), photo)
+		require.NoError(t, q.Publish(pubsub.PhotoTopic.String(), msg))
+
+		d := receive(t, delivered)
+		require.Equal(t, msg.UUID, d.ID)
+		require.Equal(t, "photo", d.Topic)
+		require.JSONEq(
+			t,
+			`{"id":"`+msg.UUID+`","topic":"photo","event":{"source":"1234:1","caption":"touchdown","photos":1}}`,
+			string(d.Body),
+		)
+
+		require.NoError(t, q.Publish(pubsub.GamesTopic.String(), message.NewMessage(watermill.NewUUID(), []byte("{}"))))
+
+		receive(t, delivered)
+		receive(t, delivered)
+		mockedClient.AssertExpectations(t)
+	})
+
+	t.Run("it should keep delivering to the rest while a webhook is retried", func(t *testing.T) {
+		wh, ctx, q, mockedClient, ss := getWebhookHandlerAndMocks(
+			t,
+			handlers.RetryPolicy{MaxAttempts: 2, InitialBackoff: 200 * time.Millisecond},
+		)
+		delivered := make(chan webhook.Delivery, 2)
+
+		ss.On("List").Return([]webhook.Subscription{allTopics, gamesOnly}, nil)
+		mockedClient.On("Deliver", mock.Anything, allTopics, mock.Anything).Once().Return(deliveryError{})
+		mockedClient.On("Deliver", mock.Anything, allTopics, mock.Anything).
+			Once().
+			Run(func(args mock.Arguments) { delivered <- args.Get(2).(webhook.Delivery) }).
+			Return(nil)
+		mockedClient.On("Deliver", mock.Anything, gamesOnly, mock.Anything).
+			Once().
+			Run(func(args mock.Arguments) { delivered <- args.Get(2).(webhook.Delivery) }).
+			Return(nil)
+
+		wh.ExecuteHandlers(ctx)
+
+		start := time.Now()
+
+		require.NoError(t, q.Publish(pubsub.GamesTopic.String(), message.NewMessage(watermill.NewUUID(), []byte("{}"))))
+
+		receive(t, delivered)
+		require.Less(t, time.Since(start), 200*time.Millisecond)
+
+		receive(t, delivered)
+		require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+		mockedClient.AssertExpectations(t)
+	})
+
+	t.Run("it should only publish the failed deliveries when the event is retried", func(t *testing.T) {
+		wh, ctx, q, mockedClient, ss := getWebhookHandlerAndMocks(
+			t,
+			handlers.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+		)
+		delivered := make(chan webhook.Delivery, 3)
+
+		q.failing["event-2"] = true
+
+		ss.On("List").Return([]webhook.Subscription{allTopics, gamesOnly}, nil)
+		mockedClient.On("Deliver", mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { delivered <- args.Get(2).(webhook.Delivery) }).
+			Return(nil)
+
+		wh.ExecuteHandlers(ctx)
+
+		require.NoError(t, q.Publish(pubsub.GamesTopic.String(), message.NewMessage("event", []byte("{}"))))
+
+		receive(t, delivered)
+		receive(t, delivered)
+
+		select {
+		case d := <-delivered:
+			require.Failf(t, "event delivered twice", "delivery %s", d.ID)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		mockedClient.AssertNumberOfCalls(t, "Deliver", 2)
+	})
+
+	t.Run("it should stop delivering to removed subscriptions", func(t *testing.T) {
+		wh, ctx, q, mockedClient, ss := getWebhookHandlerAndMocks(t, handlers.RetryPolicy{MaxAttempts: 1})
+		delivered := make(chan webhook.Delivery, 3)
+
+		ss.On("List").Times(4).Return([]webhook.Subscription{allTopics, gamesOnly}, nil)
+		ss.On("List").Return([]webhook.Subscription{allTopics}, nil)
+		mockedClient.On("Deliver", mock.Anything, allTopics, mock.Anything).
+			Twice().
+			Run(func(args mock.Arguments) { delivered <- args.Get(2).(webhook.Delivery) }).
+			Return(nil)
+		mockedClient.On("Deliver", mock.Anything, gamesOnly, mock.Anything).
+			Once().
+			Run(func(args mock.Arguments) { delivered <- args.Get(2).(webhook.Delivery) }).
+			Return(nil)
+
+		wh.ExecuteHandlers(ctx)
+
+		require.NoError(t, q.Publish(pubsub.GamesTopic.String(), message.NewMessage(watermill.NewUUID(), []byte("{}"))))
+
+		receive(t, delivered)
+		receive(t, delivered)
+
+		require.NoError(t, q.Publish(pubsub.GamesTopic.String(), message.NewMessage(watermill.NewUUID(), []byte("{}"))))
+
+		receive(t, delivered)
+
+		select {
+		case subscriber := <-q.unsubscribed:
+			require.Equal(t, "webhook-2", subscriber)
+		case <-time.After(time.Second):
+			require.Fail(t, "removed subscription wasn't unsubscribed")
+		}
+
+		require.True(t, wh.Running())
+		mockedClient.AssertExpectations(t)
+	})
+
+	t.Run("it should send deliveries to the dead letters after the last attempt", func(t *testing.T) {
+		wh, ctx, q, mockedClient, ss := getWebhookHandlerAndMocks(t, handlers.RetryPolicy{MaxAttempts: 1})
+
+		deadLetters, err := q.Subscribe(ctx, pubsub.DeadLetterTopic.String())
+		require.NoError(t, err)
+
+		ss.On("List").Return([]webhook.Subscription{allTopics}, nil)
+		mockedClient.On("Deliver", mock.Anything, allTopics, mock.Anything).Once().Return(deliveryError{})
+
+		wh.ExecuteHandlers(ctx)
+
+		require.NoError(t, q.Publish(pubsub.DeleteTopic.String(), message.NewMessage(watermill.NewUUID(), []byte("{}"))))
+
+		select {
+		case msg := <-deadLetters:
+			var e pubsub.DeadLetterEvent
+			require.NoError(t, easyjson.Unmarshal(msg.Payload, &e))
+			require.Equal(t, "webhook", e.Handler)
+			require.Equal(t, pubsub.WebhookTopic.String(), e.Topic)
+			require.Equal(t, "error delivering webhook 1", e.Error)
+			msg.Ack()
+		case <-time.After(time.Second):
+			require.Fail(t, "delivery wasn't sent to the dead letters")
+		}
+	})
+
+	t.Run("it should not deliver events while paused", func(t *testing.T) {
+		ctx := context.Background()
+		mockedQueue := new(mq.Queue)
+		mockedClient := new(mw.Client)
+		ss := new(mw.Subscriptions)
+		wh := hw.NewWebhook(hw.WithWebhookClient(mockedClient), hw.WithSubscriptions(ss), hw.WithQueue(mockedQueue))
+		channels := map[pubsub.TopicName]chan *message.Message{}
+
+		ss.On("List").Once().Return([]webhook.Subscription{allTopics}, nil)
+
+		for _, topic := range append(webhook.Topics(), pubsub.WebhookTopic) {
+			channel := make(chan *message.Message)
+			channels[topic] = channel
+
+			mockedQueue.On("Subscribe", mock.Anything, topic.String()).
+				Once().
+				Return(func(context.Context, string) <-chan *message.Message {
+					return channel
+				}, nil)
+		}
+
+		wh.StopNotifications()
+		wh.ExecuteHandlers(ctx)
+
+		sendMessageToChannel(t, channels[pubsub.TextTopic], []byte("{\"text\":\"touchdown\"}"))
+
+		mockedQueue.AssertExpectations(t)
+		ss.AssertExpectations(t)
+		mockedClient.AssertNotCalled(t, "Deliver", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func getWebhookHandlerAndMocks(t *testing.T, rp handlers.RetryPolicy) (
+	*hw.Webhook,
+	context.Context,
+	*flakyQueue,
+	*mw.Client,
+	*mw.Subscriptions,
+) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &flakyQueue{
+		Queue:        gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{}),
+		failing:      map[string]bool{},
+		unsubscribed: make(chan string, 1),
+	}
+	mockedClient := new(mw.Client)
+	mockedSubscriptions := new(mw.Subscriptions)
+
+	wh := hw.NewWebhook(
+		hw.WithWebhookClient(mockedClient),
+		hw.WithSubscriptions(mockedSubscriptions),
+		hw.WithQueue(q),
+		hw.WithRetryPolicy(rp),
+	)
+
+	t.Cleanup(func() {
+		cancel()
+		_ = q.Close()
+		wh.Wait()
+	})
+
+	return wh, ctx, q, mockedClient, mockedSubscriptions
+}
+
+func sendMessageToChannel(t *testing.T, channel chan *message.Message, eventMsg []byte) {
+	newMessage := message.NewMessage(watermill.NewUUID(), eventMsg)
+	channel <- newMessage
+
+	require.Eventually(t, func() bool {
+		<-newMessage.Acked()
+
+		return true
+	}, time.Second, time.Millisecond)
+}
+
+func receive(t *testing.T, delivered chan webhook.Delivery) webhook.Delivery {
+	t.Helper()
+
+	select {
+	case d := <-delivered:
+		return d
+	case <-time.After(time.Second):
+		require.Fail(t, "webhook wasn't delivered")
+	}
+
+	return webhook.Delivery{}
+}
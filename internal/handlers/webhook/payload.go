@@ -0,0 +1,96 @@
+package handlerswebhook
+
+import (
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/webhook"
+)
+
+// payload returns the body posted to the webhooks for an event, with only its public fields.
+func payload(msg *message.Message, topic pubsub.TopicName) ([]byte, error) {
+	e, err := event(msg.Payload, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	return easyjson.Marshal(webhook.Payload{ID: msg.UUID, Topic: webhook.TopicName(topic), Event: e})
+}
+
+func event(payload []byte, topic pubsub.TopicName) (webhook.Event, error) {
+	switch topic {
+	case pubsub.TextTopic:
+		var e pubsub.TextEvent
+		if err := easyjson.Unmarshal(payload, &e); err != nil {
+			return webhook.Event{}, err
+		}
+
+		return webhook.Event{Source: e.Source, Edit: e.Edit, Kind: e.Kind, Text: handlers.PlainText(e)}, nil
+	case pubsub.PhotoTopic:
+		var e pubsub.PhotoEvent
+		if err := easyjson.Unmarshal(payload, &e); err != nil {
+			return webhook.Event{}, err
+		}
+
+		return webhook.Event{
+			Source:   e.Source,
+			Edit:     e.Edit,
+			Kind:     e.Kind,
+			Caption:  e.Caption,
+			Photos:   1,
+			FileSize: e.FileSize,
+		}, nil
+	case pubsub.AlbumTopic:
+		var e pubsub.AlbumEvent
+		if err := easyjson.Unmarshal(payload, &e); err != nil {
+			return webhook.Event{}, err
+		}
+
+		return webhook.Event{Source: e.Source, Caption: e.Caption, Photos: len(e.Photos)}, nil
+	case pubsub.VideoTopic:
+		var e pubsub.VideoEvent
+		if err := easyjson.Unmarshal(payload, &e); err != nil {
+			return webhook.Event{}, err
+		}
+
+		return webhook.Event{
+			Source:    e.Source,
+			Edit:      e.Edit,
+			Caption:   e.Caption,
+			FileSize:  e.FileSize,
+			MimeType:  e.MimeType,
+			Animation: e.Animation,
+		}, nil
+	case pubsub.DocumentTopic:
+		var e pubsub.DocumentEvent
+		if err := easyjson.Unmarshal(payload, &e); err != nil {
+			return webhook.Event{}, err
+		}
+
+		return webhook.Event{
+			Source:   e.Source,
+			Edit:     e.Edit,
+			Caption:  e.Caption,
+			FileName: e.FileName,
+			FileSize: e.FileSize,
+			MimeType: e.MimeType,
+		}, nil
+	case pubsub.GamesTopic:
+		var e pubsub.GameEvent
+		if err := easyjson.Unmarshal(payload, &e); err != nil {
+			return webhook.Event{}, err
+		}
+
+		return webhook.Event{Kind: pubsub.GameKind, Game: &e}, nil
+	case pubsub.DeleteTopic:
+		var e pubsub.DeleteEvent
+		if err := easyjson.Unmarshal(payload, &e); err != nil {
+			return webhook.Event{}, err
+		}
+
+		return webhook.Event{Source: e.Source}, nil
+	}
+
+	return webhook.Event{}, nil
+}
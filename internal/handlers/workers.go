@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type Workers struct {
+	wg      sync.WaitGroup
+	started atomic.Int32
+	exited  atomic.Int32
+}
+
+func (w *Workers) Go(f func()) {
+	w.GoStoppable(func() bool {
+		f()
+
+		return false
+	})
+}
+
+// GoStoppable runs a loop like Go, but when it returns true it was stopped on purpose, so it isn't taken as exited.
+func (w *Workers) GoStoppable(f func() bool) {
+	w.wg.Add(1)
+	w.started.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+
+		if f() {
+			w.started.Add(-1)
+
+			return
+		}
+
+		w.exited.Add(1)
+	}()
+}
+
+func (w *Workers) Wait() {
+	w.wg.Wait()
+}
+
+// Running reports whether workers were started and none of them has exited, as each one runs a loop of its handler.
+func (w *Workers) Running() bool {
+	return w.started.Load() > 0 && w.exited.Load() == 0
+}
@@ -0,0 +1,77 @@
+package handlers_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quintodown/quintodownbot/internal/handlers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkers(t *testing.T) {
+	t.Run("it should wait until all workers finish", func(t *testing.T) {
+		var (
+			w        handlers.Workers
+			finished atomic.Int32
+		)
+
+		release := make(chan struct{})
+
+		for i := 0; i < 5; i++ {
+			w.Go(func() {
+				<-release
+				finished.Add(1)
+			})
+		}
+
+		close(release)
+		w.Wait()
+
+		require.Equal(t, int32(5), finished.Load())
+	})
+
+	t.Run("it should not wait when there are no workers", func(t *testing.T) {
+		var w handlers.Workers
+
+		w.Wait()
+	})
+
+	t.Run("it should be running while every worker is running", func(t *testing.T) {
+		var w handlers.Workers
+
+		require.False(t, w.Running())
+
+		first, second := make(chan struct{}), make(chan struct{})
+		w.Go(func() { <-first })
+		w.Go(func() { <-second })
+
+		require.True(t, w.Running())
+
+		close(first)
+
+		require.Eventually(t, func() bool { return !w.Running() }, time.Second, time.Millisecond)
+
+		close(second)
+		w.Wait()
+
+		require.False(t, w.Running())
+	})
+
+	t.Run("it should not count workers stopped on purpose as exited", func(t *testing.T) {
+		var w handlers.Workers
+
+		w.GoStoppable(func() bool { return true })
+		w.Wait()
+
+		stop := make(chan struct{})
+		w.Go(func() { <-stop })
+
+		require.True(t, w.Running())
+
+		close(stop)
+		w.Wait()
+
+		require.False(t, w.Running())
+	})
+}
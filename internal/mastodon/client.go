@@ -0,0 +1,373 @@
+package mastodon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/javiyt/twitter-text-go/extract"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/textsplit"
+)
+
+const (
+	statusMaxLength      = 500
+	statusMaxMedia       = 4
+	statusURLLength      = 23
+	mediaCheckInterval   = time.Second
+	instancePath         = "/api/v2/instance"
+	statusesPath         = "/api/v1/statuses"
+	mediaUploadPath      = "/api/v2/media"
+	mediaPath            = "/api/v1/media"
+	authorizationHeader  = "Authorization"
+	contentTypeHeader    = "Content-Type"
+	applicationJSONValue = "application/json"
+)
+
+var errUnexpectedStatus = errors.New("unexpected response status")
+
+//easyjson:json
+type statusRequest struct {
+	Status      string   `json:"status"`
+	InReplyToID string   `json:"in_reply_to_id,omitempty"`
+	MediaIDs    []string `json:"media_ids,omitempty"`
+}
+
+//easyjson:json
+type statusResponse struct {
+	ID string `json:"id"`
+}
+
+//easyjson:json
+type mediaResponse struct {
+	ID string `json:"id"`
+}
+
+//easyjson:json
+type instanceResponse struct {
+	Configuration struct {
+		Statuses struct {
+			MaxCharacters            int `json:"max_characters"`
+			MaxMediaAttachments      int `json:"max_media_attachments"`
+			CharactersReservedPerURL int `json:"characters_reserved_per_url"`
+		} `json:"statuses"`
+	} `json:"configuration"`
+}
+
+type Client struct {
+	hc            *http.Client
+	instanceURL   string
+	accessToken   string
+	numbering     bool
+	checkInterval time.Duration
+	maxLength     int
+	maxMedia      int
+	urlLength     int
+	splitter      *textsplit.Splitter
+	limits        sync.Mutex
+	loaded        bool
+}
+
+type Option func(c *Client)
+
+func WithThreadNumbering(numbering bool) Option {
+	return func(c *Client) {
+		c.numbering = numbering
+	}
+}
+
+func WithMediaCheckInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.checkInterval = interval
+	}
+}
+
+func NewMastodonClient(hc *http.Client, instanceURL, accessToken string, options ...Option) *Client {
+	c := &Client{
+		hc:            hc,
+		instanceURL:   strings.TrimSuffix(instanceURL, "/"),
+		accessToken:   accessToken,
+		checkInterval: mediaCheckInterval,
+		maxLength:     statusMaxLength,
+		maxMedia:      statusMaxMedia,
+		urlLength:     statusURLLength,
+	}
+
+	for _, o := range options {
+		o(c)
+	}
+
+	return c
+}
+
+func (c *Client) SendUpdate(s string) ([]string, error) {
+	return c.publishStatus(s, nil, nil)
+}
+
+// ContinueThread publishes the chunks of s missing from a thread that failed partway, replying to its last status.
+func (c *Client) ContinueThread(s string, thread []string) ([]string, error) {
+	return c.publishStatus(s, nil, thread)
+}
+
+func (c *Client) SendUpdateWithPhoto(s string, pics ...[]byte) ([]string, error) {
+	if _, maxMedia := c.loadLimits(); len(pics) > maxMedia {
+		pics = pics[:maxMedia]
+	}
+
+	mediaIDs := make([]string, 0, len(pics))
+
+	for _, pic := range pics {
+		media, err := c.uploadMedia(pic)
+		if err != nil {
+			return nil, err
+		}
+
+		mediaIDs = append(mediaIDs, media.ID)
+	}
+
+	return c.publishStatus(s, mediaIDs, nil)
+}
+
+func (c *Client) SendUpdateWithVideo(s string, video []byte) ([]string, error) {
+	media, err := c.uploadMedia(video)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.publishStatus(s, []string{media.ID}, nil)
+}
+
+func (c *Client) DeleteUpdates(ids ...string) error {
+	for i := range ids {
+		resp, err := c.do(http.MethodDelete, statusesPath+"/"+ids[i], "", nil)
+		if err != nil {
+			return responseError(err, nil)
+		}
+
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+			return responseError(errUnexpectedStatus, resp)
+		}
+
+		_ = resp.Body.Close()
+	}
+
+	return nil
+}
+
+func (c *Client) Chunks(s string) []string {
+	splitter, _ := c.loadLimits()
+
+	return splitter.Split(s)
+}
+
+func (c *Client) publishStatus(s string, mediaIDs, thread []string) ([]string, error) {
+	if s == "" && len(mediaIDs) == 0 {
+		return thread, nil
+	}
+
+	ids := append([]string(nil), thread...)
+
+	for _, chunk := range textsplit.Remaining(c.Chunks(s), len(ids)) {
+		status := statusRequest{Status: chunk}
+
+		if len(ids) == 0 {
+			status.MediaIDs = mediaIDs
+		} else {
+			status.InReplyToID = ids[len(ids)-1]
+		}
+
+		id, err := c.postStatus(status)
+		if err != nil {
+			return ids, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (c *Client) postStatus(status statusRequest) (string, error) {
+	body, _ := easyjson.Marshal(status)
+
+	resp, err := c.do(http.MethodPost, statusesPath, applicationJSONValue, bytes.NewReader(body))
+	if err != nil {
+		return "", responseError(err, nil)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(errUnexpectedStatus, resp)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	var created statusResponse
+	if err := easyjson.UnmarshalFromReader(resp.Body, &created); err != nil {
+		return "", fmt.Errorf("error sending status: %w", err)
+	}
+
+	return created.ID, nil
+}
+
+// uploadMedia uploads a file without description, posts have no alt texts and their text is already in the status.
+func (c *Client) uploadMedia(media []byte) (mediaResponse, error) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="media"`)
+	header.Set(contentTypeHeader, http.DetectContentType(media))
+
+	fw, _ := mw.CreatePart(header)
+	_, _ = fw.Write(media)
+	_ = mw.Close()
+
+	resp, err := c.do(http.MethodPost, mediaUploadPath, mw.FormDataContentType(), body)
+	if err != nil {
+		return mediaResponse{}, responseError(err, nil)
+	}
+
+	uploaded, processing, err := readMedia(resp)
+
+	for err == nil && processing {
+		time.Sleep(c.checkInterval)
+
+		resp, err = c.do(http.MethodGet, mediaPath+"/"+uploaded.ID, "", nil)
+		if err != nil {
+			return mediaResponse{}, responseError(err, nil)
+		}
+
+		uploaded, processing, err = readMedia(resp)
+	}
+
+	return uploaded, err
+}
+
+func readMedia(resp *http.Response) (mediaResponse, bool, error) {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusPartialContent:
+	default:
+		return mediaResponse{}, false, responseError(errUnexpectedStatus, resp)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	var media mediaResponse
+	if err := easyjson.UnmarshalFromReader(resp.Body, &media); err != nil {
+		return mediaResponse{}, false, fmt.Errorf("error processing media: %w", err)
+	}
+
+	return media, resp.StatusCode != http.StatusOK, nil
+}
+
+// loadLimits returns the splitter and the media limit of the instance. The default limits are used until the instance
+// answers, and they are requested again on later calls while it fails.
+func (c *Client) loadLimits() (*textsplit.Splitter, int) {
+	c.limits.Lock()
+	defer c.limits.Unlock()
+
+	if !c.loaded {
+		if instance, err := c.instance(); err == nil {
+			statuses := instance.Configuration.Statuses
+			if statuses.MaxCharacters > 0 {
+				c.maxLength = statuses.MaxCharacters
+			}
+
+			if statuses.MaxMediaAttachments > 0 {
+				c.maxMedia = statuses.MaxMediaAttachments
+			}
+
+			if statuses.CharactersReservedPerURL > 0 {
+				c.urlLength = statuses.CharactersReservedPerURL
+			}
+
+			c.loaded = true
+			c.splitter = nil
+		}
+	}
+
+	if c.splitter == nil {
+		c.splitter = textsplit.NewSplitter(
+			textsplit.WithMaxLength(c.maxLength),
+			textsplit.WithLengthFunc(statusLength(c.urlLength)),
+			textsplit.WithNumbering(c.numbering),
+		)
+	}
+
+	return c.splitter, c.maxMedia
+}
+
+func (c *Client) instance() (instanceResponse, error) {
+	resp, err := c.do(http.MethodGet, instancePath, "", nil)
+	if err != nil {
+		return instanceResponse{}, err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return instanceResponse{}, errUnexpectedStatus
+	}
+
+	var instance instanceResponse
+	err = easyjson.UnmarshalFromReader(resp.Body, &instance)
+
+	return instance, err
+}
+
+func statusLength(urlLength int) func(string) int {
+	return func(s string) int {
+		length := utf8.RuneCountInString(s)
+
+		for _, url := range extract.ExtractUrls(s) {
+			length += urlLength - utf8.RuneCountInString(url.Text)
+		}
+
+		return length
+	}
+}
+
+func (c *Client) do(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	if body == nil {
+		body = http.NoBody
+	}
+
+	req, err := http.NewRequest(method, c.instanceURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(authorizationHeader, "Bearer "+c.accessToken)
+
+	if contentType != "" {
+		req.Header.Set(contentTypeHeader, contentType)
+	}
+
+	return c.hc.Do(req)
+}
+
+func responseError(err error, resp *http.Response) error {
+	if resp == nil {
+		return fmt.Errorf("error sending status: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	buf := new(strings.Builder)
+	_, _ = io.Copy(buf, resp.Body)
+
+	return fmt.Errorf(
+		"error sending status: %w. Response status code: %v and body: %s",
+		err,
+		resp.StatusCode,
+		buf.String(),
+	)
+}
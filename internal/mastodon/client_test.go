@@ -0,0 +1,311 @@
+package mastodon_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quintodown/quintodownbot/internal/mastodon"
+	"github.com/stretchr/testify/require"
+)
+
+const accessToken = "mastodonToken"
+
+type status struct {
+	Status      string   `json:"status"`
+	InReplyToID string   `json:"in_reply_to_id"`
+	MediaIDs    []string `json:"media_ids"`
+}
+
+type instance struct {
+	mu          sync.Mutex
+	unavailable int
+	statuses    []status
+	media       []string
+	deleted     []string
+}
+
+func (i *instance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+accessToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"error":"The access token is invalid"}`)
+
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/instance" && i.unavailable > 0:
+		i.unavailable--
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/instance":
+		_, _ = io.WriteString(w, `{"configuration":{"statuses":{"max_characters":100,"max_media_attachments":2,`+
+			`"characters_reserved_per_url":23}}}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/statuses":
+		i.postStatus(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/media":
+		i.uploadMedia(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/media/processing":
+		_, _ = io.WriteString(w, `{"id":"processing","url":"https://files.quintodown.com/video.mp4"}`)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v1/statuses/"):
+		i.deleteStatus(w, strings.TrimPrefix(r.URL.Path, "/api/v1/statuses/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (i *instance) postStatus(w http.ResponseWriter, r *http.Request) {
+	var s status
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil || s.Status == "it should fail" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = io.WriteString(w, `{"error":"Validation failed"}`)
+
+		return
+	}
+
+	i.statuses = append(i.statuses, s)
+	_, _ = io.WriteString(w, `{"id":"`+string(rune('0'+len(i.statuses)))+`"}`)
+}
+
+func (i *instance) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	content, _ := io.ReadAll(file)
+	i.media = append(i.media, r.FormValue("description"))
+
+	switch string(content) {
+	case "invalid media":
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = io.WriteString(w, `{"error":"File type not supported"}`)
+	case "video":
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, `{"id":"processing","url":null}`)
+	default:
+		_, _ = io.WriteString(w, `{"id":"media`+string(rune('0'+len(i.media)))+`"}`)
+	}
+}
+
+func (i *instance) deleteStatus(w http.ResponseWriter, id string) {
+	switch id {
+	case "403":
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, `{"error":"This action is not allowed"}`)
+	case "404":
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"error":"Record not found"}`)
+	default:
+		i.deleted = append(i.deleted, id)
+		_, _ = io.WriteString(w, `{"id":"`+id+`"}`)
+	}
+}
+
+func TestClient_SendUpdate(t *testing.T) {
+	t.Run("it should fail when error happens on Mastodon API", func(t *testing.T) {
+		client, _ := getClient(t)
+
+		_, err := client.SendUpdate("it should fail")
+
+		require.EqualError(
+			t,
+			err,
+			"error sending status: unexpected response status. Response status code: 422 and body: "+
+				`{"error":"Validation failed"}`,
+		)
+	})
+
+	t.Run("it should fail when access token is not valid", func(t *testing.T) {
+		i := &instance{}
+		server := httptest.NewServer(i)
+		defer server.Close()
+
+		_, err := mastodon.NewMastodonClient(server.Client(), server.URL, "invalid").SendUpdate("testing")
+
+		require.EqualError(
+			t,
+			err,
+			"error sending status: unexpected response status. Response status code: 401 and body: "+
+				`{"error":"The access token is invalid"}`,
+		)
+	})
+
+	t.Run("it should not send status when it's empty", func(t *testing.T) {
+		client, i := getClient(t)
+
+		ids, err := client.SendUpdate("")
+
+		require.NoError(t, err)
+		require.Empty(t, ids)
+		require.Empty(t, i.statuses)
+	})
+
+	t.Run("it should send status to Mastodon API", func(t *testing.T) {
+		client, i := getClient(t)
+
+		ids, err := client.SendUpdate("testing")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1"}, ids)
+		require.Equal(t, []status{{Status: "testing"}}, i.statuses)
+	})
+
+	t.Run("it should send long status as a thread split at the instance limit", func(t *testing.T) {
+		client, i := getClient(t)
+
+		ids, err := client.SendUpdate(strings.Repeat("word ", 30))
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1", "2"}, ids)
+		require.Len(t, i.statuses, 2)
+		require.Empty(t, i.statuses[0].InReplyToID)
+		require.Equal(t, "1", i.statuses[1].InReplyToID)
+	})
+
+	t.Run("it should continue a thread from its last status", func(t *testing.T) {
+		client, i := getClient(t)
+
+		ids, err := client.ContinueThread(strings.Repeat("word ", 30), []string{"7"})
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"7", "1"}, ids)
+		require.Len(t, i.statuses, 1)
+		require.Equal(t, "7", i.statuses[0].InReplyToID)
+	})
+}
+
+func TestClient_SendUpdateWithPhoto(t *testing.T) {
+	t.Run("it should fail when photo couldn't be uploaded", func(t *testing.T) {
+		client, i := getClient(t)
+
+		_, err := client.SendUpdateWithPhoto("testing", []byte("invalid media"))
+
+		require.EqualError(
+			t,
+			err,
+			"error sending status: unexpected response status. Response status code: 422 and body: "+
+				`{"error":"File type not supported"}`,
+		)
+		require.Empty(t, i.statuses)
+	})
+
+	t.Run("it should send status with photos without alt text", func(t *testing.T) {
+		client, i := getClient(t)
+
+		ids, err := client.SendUpdateWithPhoto("testing", []byte("photo1"), []byte("photo2"), []byte("photo3"))
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1"}, ids)
+		require.Equal(t, []string{"", ""}, i.media)
+		require.Equal(t, []status{{Status: "testing", MediaIDs: []string{"media1", "media2"}}}, i.statuses)
+	})
+
+	t.Run("it should send photo without caption", func(t *testing.T) {
+		client, i := getClient(t)
+
+		ids, err := client.SendUpdateWithPhoto("", []byte("photo"))
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1"}, ids)
+		require.Equal(t, []status{{MediaIDs: []string{"media1"}}}, i.statuses)
+	})
+}
+
+func TestClient_SendUpdateWithVideo(t *testing.T) {
+	client, i := getClient(t)
+
+	ids, err := client.SendUpdateWithVideo("testing", []byte("video"))
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"1"}, ids)
+	require.Equal(t, []status{{Status: "testing", MediaIDs: []string{"processing"}}}, i.statuses)
+}
+
+func TestClient_DeleteUpdates(t *testing.T) {
+	t.Run("it should delete statuses ignoring the ones already deleted", func(t *testing.T) {
+		client, i := getClient(t)
+
+		require.NoError(t, client.DeleteUpdates("1", "404", "2"))
+		require.Equal(t, []string{"1", "2"}, i.deleted)
+	})
+
+	t.Run("it should fail when status couldn't be deleted", func(t *testing.T) {
+		client, _ := getClient(t)
+
+		require.EqualError(
+			t,
+			client.DeleteUpdates("403"),
+			"error sending status: unexpected response status. Response status code: 403 and body: "+
+				`{"error":"This action is not allowed"}`,
+		)
+	})
+}
+
+func TestClient_Chunks(t *testing.T) {
+	t.Run("it should count links as the instance reserved characters", func(t *testing.T) {
+		client, _ := getClient(t)
+
+		text := strings.Repeat("a", 70) + " https://quintodown.com/" + strings.Repeat("b", 60)
+
+		require.Equal(t, []string{text}, client.Chunks(text))
+	})
+
+	t.Run("it should use the default limit when the instance is not available", func(t *testing.T) {
+		client := mastodon.NewMastodonClient(http.DefaultClient, "http://127.0.0.1:0", accessToken)
+
+		require.Len(t, client.Chunks(strings.Repeat("word ", 100)), 1)
+		require.Len(t, client.Chunks(strings.Repeat("word ", 101)), 2)
+	})
+
+	t.Run("it should load the instance limits again when they couldn't be loaded", func(t *testing.T) {
+		client, i := getClient(t)
+		i.unavailable = 1
+
+		require.Len(t, client.Chunks(strings.Repeat("word ", 30)), 1)
+		require.Len(t, client.Chunks(strings.Repeat("word ", 30)), 2)
+	})
+
+	t.Run("it should number the statuses of a thread", func(t *testing.T) {
+		i := &instance{}
+		server := httptest.NewServer(i)
+		defer server.Close()
+
+		client := mastodon.NewMastodonClient(
+			server.Client(),
+			server.URL+"/",
+			accessToken,
+			mastodon.WithThreadNumbering(true),
+		)
+
+		chunks := client.Chunks(strings.Repeat("word ", 30))
+
+		require.Len(t, chunks, 2)
+		require.True(t, strings.HasSuffix(chunks[0], "1/2"))
+		require.True(t, strings.HasSuffix(chunks[1], "2/2"))
+	})
+}
+
+func getClient(t *testing.T) (*mastodon.Client, *instance) {
+	t.Helper()
+
+	i := &instance{}
+	server := httptest.NewServer(i)
+	t.Cleanup(server.Close)
+
+	return mastodon.NewMastodonClient(
+		server.Client(),
+		server.URL,
+		accessToken,
+		mastodon.WithMediaCheckInterval(time.Millisecond),
+	), i
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "quintodown"
+
+var (
+	messagesPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_published_total",
+		Help:      "Messages published to the queue by topic.",
+	}, []string{"topic"})
+	messagesAcked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_acked_total",
+		Help:      "Messages acked by the subscribers of the queue by topic.",
+	}, []string{"topic"})
+	handlerFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "handler_failures_total",
+		Help:      "Messages that failed to be handled by handler.",
+	}, []string{"handler"})
+	clientRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "client_requests_total",
+		Help:      "Requests made to external APIs by service and response status code.",
+	}, []string{"service", "code"})
+	clientRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "client_request_duration_seconds",
+		Help:      "Latency of the requests made to external APIs by service.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service"})
+	gamesTracked = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "games_tracked",
+		Help:      "Games being tracked by competition.",
+	}, []string{"competition"})
+)
+
+func HandlerFailed(handler string) {
+	handlerFailures.WithLabelValues(handler).Inc()
+}
+
+func GamesTracked(competition string, games int) {
+	gamesTracked.WithLabelValues(competition).Set(float64(games))
+}
@@ -0,0 +1,170 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/quintodown/quintodownbot/internal/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := metrics.NewQueue(gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{}))
+
+	labels := map[string]string{"topic": "testtopic"}
+	published := value(t, "quintodown_messages_published_total", labels)
+	acked := value(t, "quintodown_messages_acked_total", labels)
+
+	messages, err := q.Subscribe(ctx, "testtopic")
+	require.NoError(t, err)
+
+	require.NoError(t, q.Publish("testtopic", message.NewMessage(watermill.NewUUID(), []byte("test"))))
+	require.Equal(t, published+1, value(t, "quintodown_messages_published_total", labels))
+
+	msg := <-messages
+	msg.Ack()
+
+	require.Eventually(t, func() bool {
+		return value(t, "quintodown_messages_acked_total", labels) == acked+1
+	}, time.Second, time.Millisecond)
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bot1234/sendMessage":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/bot1234/sendPhoto":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	tr := metrics.NewTransport("testservice", nil, metrics.WithHeartbeat("getUpdates"))
+	hc := &http.Client{Transport: tr}
+
+	t.Run("it should not be alive before the first heartbeat", func(t *testing.T) {
+		require.EqualError(t, tr.Alive(time.Minute), "no heartbeat from testservice yet")
+	})
+
+	t.Run("it should count requests by status code", func(t *testing.T) {
+		labels := map[string]string{"service": "testservice", "code": "429"}
+		requests := value(t, "quintodown_client_requests_total", labels)
+
+		resp, err := hc.Get(server.URL + "/bot1234/sendMessage")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		require.Equal(t, requests+1, value(t, "quintodown_client_requests_total", labels))
+		require.Error(t, tr.Alive(time.Minute))
+		require.NoError(t, tr.Healthy())
+	})
+
+	t.Run("it should count failed requests", func(t *testing.T) {
+		labels := map[string]string{"service": "testservice", "code": "error"}
+		requests := value(t, "quintodown_client_requests_total", labels)
+
+		_, err := hc.Get("http://127.0.0.1:0/bot1234/getMe")
+		require.Error(t, err)
+
+		require.Equal(t, requests+1, value(t, "quintodown_client_requests_total", labels))
+		require.ErrorContains(t, tr.Healthy(), "last request failed to testservice: ")
+	})
+
+	t.Run("it should not be healthy after a server error", func(t *testing.T) {
+		resp, err := hc.Get(server.URL + "/bot1234/sendPhoto")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		require.EqualError(t, tr.Healthy(), "last request failed to testservice: 500 Internal Server Error")
+	})
+
+	t.Run("it should be alive after a heartbeat", func(t *testing.T) {
+		labels := map[string]string{"service": "testservice"}
+		observed := histogramCount(t, "quintodown_client_request_duration_seconds", labels)
+
+		resp, err := hc.Get(server.URL + "/bot1234/getUpdates")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		require.NoError(t, tr.Alive(time.Minute))
+		require.Error(t, tr.Alive(0))
+		require.NoError(t, tr.Healthy())
+		require.Equal(t, observed, histogramCount(t, "quintodown_client_request_duration_seconds", labels))
+	})
+}
+
+func TestGamesTracked(t *testing.T) {
+	metrics.GamesTracked("TEST", 3)
+
+	require.Equal(t, float64(3), value(t, "quintodown_games_tracked", map[string]string{"competition": "TEST"}))
+}
+
+func TestHandlerFailed(t *testing.T) {
+	labels := map[string]string{"handler": "testhandler"}
+	failures := value(t, "quintodown_handler_failures_total", labels)
+
+	metrics.HandlerFailed("testhandler")
+
+	require.Equal(t, failures+1, value(t, "quintodown_handler_failures_total", labels))
+}
+
+func value(t *testing.T, name string, labels map[string]string) float64 {
+	m := find(t, name, labels)
+	if m == nil {
+		return 0
+	}
+
+	if m.GetCounter() != nil {
+		return m.GetCounter().GetValue()
+	}
+
+	return m.GetGauge().GetValue()
+}
+
+func histogramCount(t *testing.T, name string, labels map[string]string) float64 {
+	m := find(t, name, labels)
+	if m == nil {
+		return 0
+	}
+
+	return float64(m.GetHistogram().GetSampleCount())
+}
+
+func find(t *testing.T, name string, labels map[string]string) *dto.Metric {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+
+		for _, m := range f.GetMetric() {
+			matches := 0
+
+			for _, l := range m.GetLabel() {
+				if labels[l.GetName()] == l.GetValue() {
+					matches++
+				}
+			}
+
+			if matches == len(labels) {
+				return m
+			}
+		}
+	}
+
+	return nil
+}
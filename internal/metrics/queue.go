@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+type Queue struct {
+	pubsub.Queue
+}
+
+func NewQueue(q pubsub.Queue) *Queue {
+	return &Queue{Queue: q}
+}
+
+func (q *Queue) Publish(topic string, messages ...*message.Message) error {
+	if err := q.Queue.Publish(topic, messages...); err != nil {
+		return err
+	}
+
+	messagesPublished.WithLabelValues(topic).Add(float64(len(messages)))
+
+	return nil
+}
+
+func (q *Queue) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	messages, err := q.Queue.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	instrumented := make(chan *message.Message)
+
+	go func() {
+		defer close(instrumented)
+
+		for msg := range messages {
+			go watchAck(ctx, topic, msg)
+
+			select {
+			case instrumented <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return instrumented, nil
+}
+
+func (q *Queue) Started() error {
+	if s, ok := q.Queue.(pubsub.Starter); ok {
+		return s.Started()
+	}
+
+	return nil
+}
+
+func (q *Queue) Unsubscribe(topic, subscriber string) error {
+	if u, ok := q.Queue.(pubsub.Unsubscriber); ok {
+		return u.Unsubscribe(topic, subscriber)
+	}
+
+	return nil
+}
+
+func watchAck(ctx context.Context, topic string, msg *message.Message) {
+	select {
+	case <-msg.Acked():
+		messagesAcked.WithLabelValues(topic).Inc()
+	case <-msg.Nacked():
+	case <-ctx.Done():
+	}
+}
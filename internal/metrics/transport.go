@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	errNoHeartbeat   = errors.New("no heartbeat")
+	errFailedRequest = errors.New("last request failed")
+)
+
+type Transport struct {
+	service   string
+	next      http.RoundTripper
+	heartbeat string
+	lastBeat  atomic.Int64
+
+	mu      sync.Mutex
+	failure error
+}
+
+type TransportOption func(t *Transport)
+
+// WithHeartbeat tracks the successful requests to the endpoint as heartbeats. They are expected to be long polling
+// requests, so they aren't observed as latency.
+func WithHeartbeat(endpoint string) TransportOption {
+	return func(t *Transport) {
+		t.heartbeat = endpoint
+	}
+}
+
+func NewTransport(service string, next http.RoundTripper, options ...TransportOption) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	t := &Transport{service: service, next: next}
+
+	for _, o := range options {
+		o(t)
+	}
+
+	return t
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	heartbeat := t.heartbeat != "" && path.Base(req.URL.Path) == t.heartbeat
+	start := time.Now()
+
+	resp, err := t.next.RoundTrip(req)
+
+	if !heartbeat {
+		clientRequestDuration.WithLabelValues(t.service).Observe(time.Since(start).Seconds())
+	}
+
+	if err != nil {
+		clientRequests.WithLabelValues(t.service, "error").Inc()
+		t.fail(err)
+
+		return nil, err
+	}
+
+	clientRequests.WithLabelValues(t.service, strconv.Itoa(resp.StatusCode)).Inc()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode >= http.StatusInternalServerError {
+		t.fail(errors.New(resp.Status))
+	} else {
+		t.fail(nil)
+	}
+
+	if heartbeat && resp.StatusCode == http.StatusOK {
+		t.lastBeat.Store(time.Now().UnixNano())
+	}
+
+	return resp, nil
+}
+
+func (t *Transport) Alive(maxAge time.Duration) error {
+	last := t.lastBeat.Load()
+	if last == 0 {
+		return fmt.Errorf("%w from %s yet", errNoHeartbeat, t.service)
+	}
+
+	if age := time.Since(time.Unix(0, last)); age > maxAge {
+		return fmt.Errorf("%w from %s in %s", errNoHeartbeat, t.service, age.Truncate(time.Second))
+	}
+
+	return nil
+}
+
+// Healthy fails while the last request to the service failed, answered it's unauthorized or had a server error.
+func (t *Transport) Healthy() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.failure != nil {
+		return fmt.Errorf("%w to %s: %w", errFailedRequest, t.service, t.failure)
+	}
+
+	return nil
+}
+
+func (t *Transport) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.failure = err
+}
@@ -0,0 +1,276 @@
+package posts
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/clock"
+	"github.com/quintodown/quintodownbot/internal/storage"
+)
+
+const (
+	bucket           = "posts"
+	historyBucket    = "history"
+	defaultRetention = 1000
+)
+
+const (
+	Published = "published"
+	Edited    = "edited"
+	Deleted   = "deleted"
+)
+
+//easyjson:json
+type Post struct {
+	Source     string              `json:"source"`
+	Messages   map[string][]string `json:"messages"`
+	Unfinished []string            `json:"unfinished,omitempty"`
+}
+
+// IsUnfinished tells whether the messages of a handler are a thread that failed before being completely published.
+func (p Post) IsUnfinished(handler string) bool {
+	for i := range p.Unfinished {
+		if p.Unfinished[i] == handler {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Post) finish(handler string) {
+	unfinished := p.Unfinished[:0]
+
+	for i := range p.Unfinished {
+		if p.Unfinished[i] != handler {
+			unfinished = append(unfinished, p.Unfinished[i])
+		}
+	}
+
+	p.Unfinished = unfinished
+	if len(p.Unfinished) == 0 {
+		p.Unfinished = nil
+	}
+}
+
+//easyjson:json
+type Publication struct {
+	ID          string    `json:"id"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Action      string    `json:"action"`
+	Messages    []string  `json:"messages"`
+	At          time.Time `json:"at"`
+}
+
+type Repository struct {
+	s         storage.Store
+	clk       clock.Clock
+	retention int
+	mu        sync.Mutex
+}
+
+type Option func(r *Repository)
+
+// WithRetention sets how many publications are kept in the history, older ones are removed as new ones are recorded.
+func WithRetention(n int) Option {
+	return func(r *Repository) {
+		r.retention = n
+	}
+}
+
+func NewRepository(s storage.Store, clk clock.Clock, options ...Option) *Repository {
+	r := &Repository{s: s, clk: clk, retention: defaultRetention}
+
+	for _, o := range options {
+		o(r)
+	}
+
+	return r
+}
+
+func (r *Repository) Get(source string) (Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.get(source)
+}
+
+func (r *Repository) Save(source, handler string, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, err := r.get(source)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	if p.Messages == nil {
+		p = Post{Source: source, Messages: map[string][]string{}}
+	}
+
+	if ids == nil {
+		ids = []string{}
+	}
+
+	action := Published
+	if _, ok := p.Messages[handler]; ok && !p.IsUnfinished(handler) {
+		action = Edited
+	}
+
+	p.Messages[handler] = ids
+	p.finish(handler)
+
+	if err := r.put(p); err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return r.record(source, handler, action, ids)
+}
+
+// SaveUnfinished keeps the messages sent by a handler before its thread failed, so it can be continued later instead
+// of being published again. It isn't recorded in the history until the thread is saved completely.
+func (r *Repository) SaveUnfinished(source, handler string, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, err := r.get(source)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	if p.Messages == nil {
+		p = Post{Source: source, Messages: map[string][]string{}}
+	}
+
+	p.Messages[handler] = ids
+
+	if !p.IsUnfinished(handler) {
+		p.Unfinished = append(p.Unfinished, handler)
+	}
+
+	return r.put(p)
+}
+
+func (r *Repository) Remove(source, handler string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, err := r.get(source)
+	if err != nil {
+		return err
+	}
+
+	ids := p.Messages[handler]
+	delete(p.Messages, handler)
+	p.finish(handler)
+
+	if len(p.Messages) == 0 {
+		err = r.s.Delete(bucket, source)
+	} else {
+		err = r.put(p)
+	}
+
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	return r.record(source, handler, Deleted, ids)
+}
+
+func (r *Repository) History(n int) ([]Publication, error) {
+	items, err := r.s.List(historyBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]Publication, 0, len(items))
+
+	for i := range items {
+		var p Publication
+		if err := easyjson.Unmarshal(items[i].Value, &p); err != nil {
+			return nil, err
+		}
+
+		history = append(history, p)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		a, _ := strconv.Atoi(history[i].ID)
+		b, _ := strconv.Atoi(history[j].ID)
+
+		return a > b
+	})
+
+	if n > 0 && len(history) > n {
+		history = history[:n]
+	}
+
+	return history, nil
+}
+
+func (r *Repository) record(source, handler, action string, ids []string) error {
+	id, err := r.s.NextID(historyBucket)
+	if err != nil {
+		return err
+	}
+
+	pb, _ := easyjson.Marshal(Publication{
+		ID:          id,
+		Source:      source,
+		Destination: handler,
+		Action:      action,
+		Messages:    ids,
+		At:          r.clk.Now(),
+	})
+
+	if err := r.s.Put(historyBucket, id, pb); err != nil {
+		return err
+	}
+
+	return r.prune(id)
+}
+
+// prune removes the publication falling out of the retention with the one just recorded. Ids are consecutive, so the
+// history never holds more publications than the retention.
+func (r *Repository) prune(id string) error {
+	n, err := strconv.Atoi(id)
+	if err != nil || r.retention <= 0 || n <= r.retention {
+		return err
+	}
+
+	err = r.s.Delete(historyBucket, strconv.Itoa(n-r.retention))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (r *Repository) get(source string) (Post, error) {
+	pb, err := r.s.Get(bucket, source)
+	if err != nil {
+		return Post{}, err
+	}
+
+	var p Post
+	if err := easyjson.Unmarshal(pb, &p); err != nil {
+		return Post{}, err
+	}
+
+	return p, nil
+}
+
+func (r *Repository) put(p Post) error {
+	pb, _ := easyjson.Marshal(p)
+
+	return r.s.Put(bucket, p.Source, pb)
+}
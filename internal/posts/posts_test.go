@@ -0,0 +1,170 @@
+package posts_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quintodown/quintodownbot/internal/posts"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	"github.com/quintodown/quintodownbot/mocks/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository(t *testing.T) {
+	s, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	defer func() { _ = s.Close() }()
+
+	now := time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC)
+	clk := new(clock.Clock)
+	clk.On("Now").Return(now)
+
+	r := posts.NewRepository(s, clk)
+
+	t.Run("it should return an empty history when nothing was published", func(t *testing.T) {
+		history, err := r.History(10)
+
+		require.NoError(t, err)
+		require.Empty(t, history)
+	})
+
+	t.Run("it should fail getting a missing post", func(t *testing.T) {
+		_, err := r.Get("1234:1")
+
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("it should save the messages published by each handler", func(t *testing.T) {
+		require.NoError(t, r.Save("1234:1", "telegram", []string{"10", "11"}))
+		require.NoError(t, r.Save("1234:1", "twitter", nil))
+		require.NoError(t, r.Save("1234:1", "twitter", []string{"1445823463904798049"}))
+
+		p, err := r.Get("1234:1")
+
+		require.NoError(t, err)
+		require.Equal(t, posts.Post{
+			Source: "1234:1",
+			Messages: map[string][]string{
+				"telegram": {"10", "11"},
+				"twitter":  {"1445823463904798049"},
+			},
+		}, p)
+	})
+
+	t.Run("it should keep handlers without messages", func(t *testing.T) {
+		require.NoError(t, r.Save("1234:2", "twitter", nil))
+
+		p, err := r.Get("1234:2")
+
+		require.NoError(t, err)
+		require.Equal(t, map[string][]string{"twitter": {}}, p.Messages)
+	})
+
+	t.Run("it should remove the messages of a handler", func(t *testing.T) {
+		require.NoError(t, r.Remove("1234:1", "telegram"))
+
+		p, err := r.Get("1234:1")
+
+		require.NoError(t, err)
+		require.Equal(t, map[string][]string{"twitter": {"1445823463904798049"}}, p.Messages)
+	})
+
+	t.Run("it should delete the post when no handler has messages", func(t *testing.T) {
+		require.NoError(t, r.Remove("1234:1", "twitter"))
+
+		_, err := r.Get("1234:1")
+
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("it should fail removing messages of a missing post", func(t *testing.T) {
+		require.ErrorIs(t, r.Remove("1234:3", "twitter"), storage.ErrNotFound)
+	})
+
+	t.Run("it should return the latest publications first", func(t *testing.T) {
+		history, err := r.History(2)
+
+		require.NoError(t, err)
+		require.Equal(t, []posts.Publication{
+			{
+				ID:          "4",
+				Source:      "1234:1",
+				Destination: "twitter",
+				Action:      posts.Deleted,
+				Messages:    []string{"1445823463904798049"},
+				At:          now,
+			},
+			{
+				ID:          "3",
+				Source:      "1234:1",
+				Destination: "telegram",
+				Action:      posts.Deleted,
+				Messages:    []string{"10", "11"},
+				At:          now,
+			},
+		}, history)
+	})
+
+	t.Run("it should record the whole history", func(t *testing.T) {
+		history, err := r.History(0)
+
+		require.NoError(t, err)
+		require.Len(t, history, 4)
+		require.Equal(t, posts.Edited, history[2].Action)
+		require.Equal(t, posts.Published, history[3].Action)
+		require.Equal(t, []string{"10", "11"}, history[3].Messages)
+	})
+
+	t.Run("it should keep unfinished threads out of the history until they are completed", func(t *testing.T) {
+		require.NoError(t, r.SaveUnfinished("1234:4", "twitter", []string{"1", "2"}))
+
+		p, err := r.Get("1234:4")
+
+		require.NoError(t, err)
+		require.True(t, p.IsUnfinished("twitter"))
+		require.Equal(t, map[string][]string{"twitter": {"1", "2"}}, p.Messages)
+
+		history, err := r.History(0)
+
+		require.NoError(t, err)
+		require.Len(t, history, 4)
+
+		require.NoError(t, r.Save("1234:4", "twitter", []string{"1", "2", "3"}))
+
+		p, err = r.Get("1234:4")
+
+		require.NoError(t, err)
+		require.False(t, p.IsUnfinished("twitter"))
+
+		history, err = r.History(1)
+
+		require.NoError(t, err)
+		require.Equal(t, posts.Published, history[0].Action)
+		require.Equal(t, []string{"1", "2", "3"}, history[0].Messages)
+	})
+}
+
+func TestRepository_Retention(t *testing.T) {
+	s, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	defer func() { _ = s.Close() }()
+
+	clk := new(clock.Clock)
+	clk.On("Now").Return(time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC))
+
+	r := posts.NewRepository(s, clk, posts.WithRetention(2))
+
+	for _, source := range []string{"1234:1", "1234:2", "1234:3"} {
+		require.NoError(t, r.Save(source, "telegram", []string{"10"}))
+	}
+
+	history, err := r.History(0)
+
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, "3", history[0].ID)
+	require.Equal(t, "2", history[1].ID)
+}
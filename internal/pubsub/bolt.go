@@ -0,0 +1,420 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	boltFileMode    = 0o600
+	boltOpenTimeout = 5 * time.Second
+	nackResendSleep = 100 * time.Millisecond
+)
+
+var (
+	ErrQueueClosed          = errors.New("queue is closed")
+	ErrMissingSubscriber    = errors.New("subscriber name is required")
+	ErrDuplicatedSubscriber = errors.New("subscriber already subscribed")
+)
+
+//easyjson:json
+type storedMessage struct {
+	UUID     string            `json:"uuid"`
+	Metadata map[string]string `json:"metadata"`
+	Payload  []byte            `json:"payload"`
+}
+
+// BoltQueue is a Queue persisted on a bbolt file. Every subscription owns a bucket inside its topic bucket where
+// published messages are stored until they are acked, so pending messages survive a restart. Subscriptions are
+// identified by the subscriber name of their context (see WithSubscriber).
+//
+// Until Started is called, the messages published are also kept in memory and stored for the subscriptions created
+// later, so nothing is lost while handlers are subscribing. Started removes the buckets of subscribers that didn't
+// subscribe again.
+type BoltQueue struct {
+	db            *bbolt.DB
+	mu            sync.Mutex
+	subscriptions map[string]map[string]bool
+	dropped       map[string]map[string]bool
+	backlog       map[string][]*message.Message
+	started       bool
+	wakeUp        map[string][]chan struct{}
+	closed        bool
+	closing       chan struct{}
+	wg            sync.WaitGroup
+}
+
+func NewBoltQueue(path string) (*BoltQueue, error) {
+	db, err := bbolt.Open(path, boltFileMode, &bbolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltQueue{
+		db:            db,
+		subscriptions: map[string]map[string]bool{},
+		dropped:       map[string]map[string]bool{},
+		backlog:       map[string][]*message.Message{},
+		wakeUp:        map[string][]chan struct{}{},
+		closing:       make(chan struct{}),
+	}, nil
+}
+
+func (bq *BoltQueue) Publish(topic string, messages ...*message.Message) error {
+	bq.mu.Lock()
+
+	if bq.closed {
+		bq.mu.Unlock()
+
+		return ErrQueueClosed
+	}
+
+	err := bq.db.Update(func(tx *bbolt.Tx) error {
+		tb, err := tx.CreateBucketIfNotExists([]byte(topic))
+		if err != nil {
+			return err
+		}
+
+		return tb.ForEach(func(k, v []byte) error {
+			if v != nil {
+				return nil
+			}
+
+			return bq.storeMessages(tb.Bucket(k), messages)
+		})
+	})
+	if err == nil && !bq.started {
+		bq.backlog[topic] = append(bq.backlog[topic], messages...)
+	}
+
+	bq.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	bq.notify(topic)
+
+	return nil
+}
+
+func (bq *BoltQueue) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+
+	if bq.closed {
+		return nil, ErrQueueClosed
+	}
+
+	subscriber := Subscriber(ctx)
+	if subscriber == "" {
+		return nil, fmt.Errorf("%w for topic %s", ErrMissingSubscriber, topic)
+	}
+
+	if bq.subscriptions[topic][subscriber] {
+		return nil, fmt.Errorf("%w to topic %s: %s", ErrDuplicatedSubscriber, topic, subscriber)
+	}
+
+	name := []byte(subscriber)
+
+	err := bq.db.Update(func(tx *bbolt.Tx) error {
+		tb, err := tx.CreateBucketIfNotExists([]byte(topic))
+		if err != nil {
+			return err
+		}
+
+		if tb.Bucket(name) != nil {
+			return nil
+		}
+
+		sb, err := tb.CreateBucket(name)
+		if err != nil {
+			return err
+		}
+
+		if bq.started {
+			return nil
+		}
+
+		return bq.storeMessages(sb, bq.backlog[topic])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if bq.subscriptions[topic] == nil {
+		bq.subscriptions[topic] = map[string]bool{}
+	}
+
+	bq.subscriptions[topic][subscriber] = true
+
+	wakeUp := make(chan struct{}, 1)
+	bq.wakeUp[topic] = append(bq.wakeUp[topic], wakeUp)
+
+	output := make(chan *message.Message)
+
+	bq.wg.Add(1)
+
+	go bq.consume(ctx, topic, name, wakeUp, output)
+
+	return output, nil
+}
+
+// Started stops keeping the published messages for subscriptions to come and removes the buckets of the subscribers
+// that haven't subscribed since the queue was opened.
+func (bq *BoltQueue) Started() error {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+
+	if bq.closed {
+		return ErrQueueClosed
+	}
+
+	bq.started = true
+	bq.backlog = nil
+
+	return bq.db.Update(func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(topic []byte, tb *bbolt.Bucket) error {
+			var unclaimed [][]byte
+
+			err := tb.ForEach(func(k, v []byte) error {
+				if v == nil && !bq.subscriptions[string(topic)][string(k)] {
+					unclaimed = append(unclaimed, append([]byte{}, k...))
+				}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, k := range unclaimed {
+				if err := tb.DeleteBucket(k); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+}
+
+// Unsubscribe removes the bucket of a subscriber with its pending messages. When the subscriber is still consuming,
+// the bucket is removed once its subscription stops.
+func (bq *BoltQueue) Unsubscribe(topic, subscriber string) error {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+
+	if bq.closed {
+		return ErrQueueClosed
+	}
+
+	if bq.subscriptions[topic][subscriber] {
+		if bq.dropped[topic] == nil {
+			bq.dropped[topic] = map[string]bool{}
+		}
+
+		bq.dropped[topic][subscriber] = true
+
+		return nil
+	}
+
+	return bq.deleteBucket(topic, subscriber)
+}
+
+func (bq *BoltQueue) deleteBucket(topic, subscriber string) error {
+	return bq.db.Update(func(tx *bbolt.Tx) error {
+		tb := tx.Bucket([]byte(topic))
+		if tb == nil || tb.Bucket([]byte(subscriber)) == nil {
+			return nil
+		}
+
+		return tb.DeleteBucket([]byte(subscriber))
+	})
+}
+
+func (bq *BoltQueue) Close() error {
+	bq.mu.Lock()
+	if bq.closed {
+		bq.mu.Unlock()
+
+		return nil
+	}
+
+	bq.closed = true
+	close(bq.closing)
+	bq.mu.Unlock()
+
+	bq.wg.Wait()
+
+	return bq.db.Close()
+}
+
+func (bq *BoltQueue) consume(
+	ctx context.Context,
+	topic string,
+	subscription []byte,
+	wakeUp <-chan struct{},
+	output chan<- *message.Message,
+) {
+	defer bq.wg.Done()
+	defer close(output)
+	defer bq.release(topic, string(subscription), wakeUp)
+
+	for {
+		key, sm, err := bq.nextMessage(topic, subscription)
+		if err != nil {
+			return
+		}
+
+		if key == nil {
+			select {
+			case <-wakeUp:
+				continue
+			case <-ctx.Done():
+				return
+			case <-bq.closing:
+				return
+			}
+		}
+
+		if !bq.deliver(ctx, sm, output) {
+			return
+		}
+
+		if err := bq.removeMessage(topic, subscription, key); err != nil {
+			return
+		}
+	}
+}
+
+// release forgets the subscription when its consumer stops, so the subscriber can subscribe again. Its bucket is kept
+// to deliver the pending messages to the next subscription, unless the subscriber was unsubscribed.
+func (bq *BoltQueue) release(topic, subscriber string, wakeUp <-chan struct{}) {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+
+	delete(bq.subscriptions[topic], subscriber)
+
+	if bq.dropped[topic][subscriber] {
+		delete(bq.dropped[topic], subscriber)
+		_ = bq.deleteBucket(topic, subscriber)
+	}
+
+	channels := bq.wakeUp[topic]
+	for i := range channels {
+		if channels[i] == wakeUp {
+			bq.wakeUp[topic] = append(channels[:i:i], channels[i+1:]...)
+
+			break
+		}
+	}
+}
+
+func (bq *BoltQueue) deliver(ctx context.Context, sm storedMessage, output chan<- *message.Message) bool {
+	for {
+		msg := message.NewMessage(sm.UUID, sm.Payload)
+		for k, v := range sm.Metadata {
+			msg.Metadata.Set(k, v)
+		}
+
+		msg.SetContext(ctx)
+
+		select {
+		case output <- msg:
+		case <-ctx.Done():
+			return false
+		case <-bq.closing:
+			return false
+		}
+
+		select {
+		case <-msg.Acked():
+			return true
+		case <-msg.Nacked():
+			if ctx.Err() != nil {
+				return false
+			}
+
+			time.Sleep(nackResendSleep)
+		case <-bq.closing:
+			select {
+			case <-msg.Acked():
+				return true
+			default:
+				return false
+			}
+		}
+	}
+}
+
+func (bq *BoltQueue) nextMessage(topic string, subscription []byte) ([]byte, storedMessage, error) {
+	var (
+		key []byte
+		sm  storedMessage
+	)
+
+	err := bq.db.View(func(tx *bbolt.Tx) error {
+		sb := tx.Bucket([]byte(topic)).Bucket(subscription)
+
+		k, v := sb.Cursor().First()
+		if k == nil {
+			return nil
+		}
+
+		key = append([]byte{}, k...)
+
+		return easyjson.Unmarshal(v, &sm)
+	})
+
+	return key, sm, err
+}
+
+func (bq *BoltQueue) removeMessage(topic string, subscription, key []byte) error {
+	return bq.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(topic)).Bucket(subscription).Delete(key)
+	})
+}
+
+func (bq *BoltQueue) storeMessages(b *bbolt.Bucket, messages []*message.Message) error {
+	for _, msg := range messages {
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		v, err := easyjson.Marshal(storedMessage{UUID: msg.UUID, Metadata: msg.Metadata, Payload: msg.Payload})
+		if err != nil {
+			return err
+		}
+
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+
+		if err := b.Put(key, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (bq *BoltQueue) notify(topic string) {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+
+	for _, c := range bq.wakeUp[topic] {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
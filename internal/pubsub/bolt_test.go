@@ -0,0 +1,333 @@
+package pubsub_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoltQueue_PublishSubscribe(t *testing.T) {
+	t.Run("it should deliver published messages to every subscription", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		first, err := q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+		second, err := q.Subscribe(subscriber("twitter"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+
+		require.NoError(t, q.Publish(pubsub.TextTopic.String(), newMessage("testing")))
+
+		require.Equal(t, "testing", receiveMessage(t, first, true))
+		require.Equal(t, "testing", receiveMessage(t, second, true))
+	})
+
+	t.Run("it should deliver messages in the order they were published", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		messages, err := q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+
+		require.NoError(t, q.Publish(pubsub.TextTopic.String(), newMessage("first"), newMessage("second")))
+
+		require.Equal(t, "first", receiveMessage(t, messages, true))
+		require.Equal(t, "second", receiveMessage(t, messages, true))
+	})
+
+	t.Run("it should redeliver nacked messages", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		messages, err := q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+
+		require.NoError(t, q.Publish(pubsub.TextTopic.String(), newMessage("testing")))
+
+		require.Equal(t, "testing", receiveMessage(t, messages, false))
+		require.Equal(t, "testing", receiveMessage(t, messages, true))
+	})
+
+	t.Run("it should close subscription channel when context is cancelled", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		ctx, cancel := context.WithCancel(subscriber("telegram"))
+		messages, err := q.Subscribe(ctx, pubsub.TextTopic.String())
+		require.NoError(t, err)
+
+		cancel()
+
+		require.Eventually(t, func() bool {
+			_, ok := <-messages
+
+			return !ok
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("it should wait for in-flight message when context is cancelled", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "queue.db")
+		q, err := pubsub.NewBoltQueue(path)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(subscriber("telegram"))
+		messages, err := q.Subscribe(ctx, pubsub.TextTopic.String())
+		require.NoError(t, err)
+
+		require.NoError(t, q.Publish(pubsub.TextTopic.String(), newMessage("in-flight")))
+
+		msg := <-messages
+		cancel()
+		msg.Ack()
+
+		require.Eventually(t, func() bool {
+			_, ok := <-messages
+
+			return !ok
+		}, time.Second, time.Millisecond)
+		require.NoError(t, q.Close())
+
+		q, err = pubsub.NewBoltQueue(path)
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		messages, err = q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+
+		select {
+		case msg := <-messages:
+			require.Failf(t, "acked message delivered again", "payload %s", msg.Payload)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+func TestBoltQueue_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.db")
+
+	q, err := pubsub.NewBoltQueue(path)
+	require.NoError(t, err)
+
+	_, err = q.Subscribe(subscriber("telegram"), pubsub.PhotoTopic.String())
+	require.NoError(t, err)
+	messages, err := q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+	require.NoError(t, err)
+
+	require.NoError(t, q.Publish(pubsub.TextTopic.String(), newMessage("acked"), newMessage("pending")))
+	require.Equal(t, "acked", receiveMessage(t, messages, true))
+	require.NoError(t, q.Close())
+
+	t.Run("it should fail publishing when queue is closed", func(t *testing.T) {
+		require.ErrorIs(t, q.Publish(pubsub.TextTopic.String(), newMessage("closed")), pubsub.ErrQueueClosed)
+	})
+
+	t.Run("it should deliver pending messages after reopening the queue", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(path)
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		_, err = q.Subscribe(subscriber("telegram"), pubsub.PhotoTopic.String())
+		require.NoError(t, err)
+		messages, err := q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+
+		require.Equal(t, "pending", receiveMessage(t, messages, true))
+	})
+}
+
+func TestBoltQueue_Subscribers(t *testing.T) {
+	t.Run("it should fail subscribing without subscriber name", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		_, err = q.Subscribe(context.Background(), pubsub.TextTopic.String())
+		require.ErrorIs(t, err, pubsub.ErrMissingSubscriber)
+	})
+
+	t.Run("it should fail subscribing twice with the same name", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		_, err = q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+		_, err = q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+		require.ErrorIs(t, err, pubsub.ErrDuplicatedSubscriber)
+	})
+
+	t.Run("it should subscribe again with the same name once the subscription is cancelled", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		ctx, cancel := context.WithCancel(subscriber("telegram"))
+		messages, err := q.Subscribe(ctx, pubsub.TextTopic.String())
+		require.NoError(t, err)
+		require.NoError(t, q.Started())
+
+		cancel()
+
+		require.Eventually(t, func() bool {
+			_, ok := <-messages
+
+			return !ok
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, q.Publish(pubsub.TextTopic.String(), newMessage("pending")))
+
+		messages, err = q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+		require.Equal(t, "pending", receiveMessage(t, messages, true))
+	})
+
+	t.Run("it should drop the pending messages of an unsubscribed subscriber", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		ctx, cancel := context.WithCancel(subscriber("webhook-1"))
+		messages, err := q.Subscribe(ctx, pubsub.WebhookTopic.String())
+		require.NoError(t, err)
+		require.NoError(t, q.Started())
+		require.NoError(t, q.Publish(pubsub.WebhookTopic.String(), newMessage("first"), newMessage("pending")))
+		require.Equal(t, "first", receiveMessage(t, messages, true))
+		require.NoError(t, q.Unsubscribe(pubsub.WebhookTopic.String(), "webhook-1"))
+
+		cancel()
+
+		require.Eventually(t, func() bool {
+			_, ok := <-messages
+
+			return !ok
+		}, time.Second, time.Millisecond)
+
+		messages, err = q.Subscribe(subscriber("webhook-1"), pubsub.WebhookTopic.String())
+		require.NoError(t, err)
+
+		select {
+		case msg := <-messages:
+			require.Failf(t, "unsubscribed subscriber delivered", "payload %s", msg.Payload)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("it should deliver messages published before subscribing", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		require.NoError(t, q.Publish(pubsub.TextTopic.String(), newMessage("early")))
+
+		messages, err := q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+		require.NoError(t, q.Started())
+
+		require.Equal(t, "early", receiveMessage(t, messages, true))
+	})
+
+	path := filepath.Join(t.TempDir(), "queue.db")
+
+	q, err := pubsub.NewBoltQueue(path)
+	require.NoError(t, err)
+
+	_, err = q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+	require.NoError(t, err)
+	messages, err := q.Subscribe(subscriber("twitter"), pubsub.TextTopic.String())
+	require.NoError(t, err)
+	_, err = q.Subscribe(subscriber("mastodon"), pubsub.TextTopic.String())
+	require.NoError(t, err)
+
+	require.NoError(t, q.Started())
+	require.NoError(t, q.Publish(pubsub.TextTopic.String(), newMessage("first"), newMessage("second")))
+	require.Equal(t, "first", receiveMessage(t, messages, true))
+	require.NoError(t, q.Close())
+
+	t.Run("it should keep pending messages by subscriber name", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(path)
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		twitter, err := q.Subscribe(subscriber("twitter"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+		telegram, err := q.Subscribe(subscriber("telegram"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+		require.NoError(t, q.Started())
+
+		require.Equal(t, "second", receiveMessage(t, twitter, false))
+		require.Equal(t, "first", receiveMessage(t, telegram, false))
+	})
+
+	t.Run("it should remove subscriptions that aren't claimed after starting", func(t *testing.T) {
+		q, err := pubsub.NewBoltQueue(path)
+		require.NoError(t, err)
+
+		_, err = q.Subscribe(subscriber("twitter"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+		require.NoError(t, q.Started())
+		require.NoError(t, q.Close())
+
+		q, err = pubsub.NewBoltQueue(path)
+		require.NoError(t, err)
+
+		defer func() { _ = q.Close() }()
+
+		messages, err := q.Subscribe(subscriber("mastodon"), pubsub.TextTopic.String())
+		require.NoError(t, err)
+
+		select {
+		case msg := <-messages:
+			require.Failf(t, "removed subscription delivered", "payload %s", msg.Payload)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+func subscriber(name string) context.Context {
+	return pubsub.WithSubscriber(context.Background(), name)
+}
+
+func newMessage(payload string) *message.Message {
+	return message.NewMessage(watermill.NewUUID(), []byte(payload))
+}
+
+func receiveMessage(t *testing.T, messages <-chan *message.Message, ack bool) string {
+	t.Helper()
+
+	select {
+	case msg := <-messages:
+		if ack {
+			msg.Ack()
+		} else {
+			msg.Nack()
+		}
+
+		return string(msg.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+	}
+
+	return ""
+}
@@ -18,10 +18,25 @@ const (
 	TextTopic
 	CommandTopic
 	GamesTopic
+	DeadLetterTopic
+	AlbumTopic
+	VideoTopic
+	DocumentTopic
+	DeleteTopic
+	WebhookTopic
 )
 
 const (
 	StopCommand CommandName = iota
+	StartCommand
+	StatusCommand
+)
+
+const TargetHandlerMetadata = "targetHandler"
+
+const (
+	GameKind = "game"
+	FeedKind = "feed"
 )
 
 type Queue interface {
@@ -37,22 +52,121 @@ type ErrorEvent struct {
 
 //easyjson:json
 type PhotoEvent struct {
-	Caption     string `json:"caption"`
+	Caption      string       `json:"caption"`
+	FileID       string       `json:"fileId"`
+	FileURL      string       `json:"fileUrl"`
+	FileSize     int64        `json:"fileSize"`
+	FileContent  []byte       `json:"fileContent"`
+	Destinations Destinations `json:"destinations,omitempty"`
+	Source       string       `json:"source,omitempty"`
+	Edit         bool         `json:"edit,omitempty"`
+	Kind         string       `json:"kind,omitempty"`
+}
+
+//easyjson:json
+type AlbumEvent struct {
+	Caption      string       `json:"caption"`
+	Photos       []AlbumPhoto `json:"photos"`
+	Destinations Destinations `json:"destinations,omitempty"`
+	Source       string       `json:"source,omitempty"`
+}
+
+type AlbumPhoto struct {
 	FileID      string `json:"fileId"`
 	FileURL     string `json:"fileUrl"`
 	FileSize    int64  `json:"fileSize"`
 	FileContent []byte `json:"fileContent"`
 }
 
+//easyjson:json
+type VideoEvent struct {
+	Caption      string       `json:"caption"`
+	FileID       string       `json:"fileId"`
+	FileURL      string       `json:"fileUrl"`
+	FileSize     int64        `json:"fileSize"`
+	FileContent  []byte       `json:"fileContent"`
+	MimeType     string       `json:"mimeType"`
+	Animation    bool         `json:"animation"`
+	Destinations Destinations `json:"destinations,omitempty"`
+	Source       string       `json:"source,omitempty"`
+	Edit         bool         `json:"edit,omitempty"`
+}
+
+//easyjson:json
+type DocumentEvent struct {
+	Caption      string       `json:"caption"`
+	FileID       string       `json:"fileId"`
+	FileURL      string       `json:"fileUrl"`
+	FileSize     int64        `json:"fileSize"`
+	FileName     string       `json:"fileName"`
+	MimeType     string       `json:"mimeType"`
+	Destinations Destinations `json:"destinations,omitempty"`
+	Source       string       `json:"source,omitempty"`
+	Edit         bool         `json:"edit,omitempty"`
+}
+
 //easyjson:json
 type TextEvent struct {
-	Text string `json:"text"`
+	Text         string       `json:"text"`
+	Entities     []Entity     `json:"entities,omitempty"`
+	Destinations Destinations `json:"destinations,omitempty"`
+	Source       string       `json:"source,omitempty"`
+	Edit         bool         `json:"edit,omitempty"`
+	Kind         string       `json:"kind,omitempty"`
+}
+
+type Entity struct {
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	URL    string `json:"url,omitempty"`
+}
+
+//easyjson:json
+type DeleteEvent struct {
+	Source string `json:"source"`
+}
+
+type Destinations []string
+
+func (d Destinations) Includes(handler string) bool {
+	if len(d) == 0 {
+		return true
+	}
+
+	for i := range d {
+		if d[i] == handler {
+			return true
+		}
+	}
+
+	return false
+}
+
+//easyjson:json
+type DeadLetterEvent struct {
+	ID       string    `json:"id"`
+	Handler  string    `json:"handler"`
+	Topic    string    `json:"topic"`
+	Payload  []byte    `json:"payload"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+//easyjson:json
+type WebhookEvent struct {
+	Subscription string `json:"subscription"`
+	ID           string `json:"id"`
+	Topic        string `json:"topic"`
+	Body         []byte `json:"body"`
 }
 
 //easyjson:json
 type CommandEvent struct {
 	Command CommandName `json:"command"`
 	Handler string      `json:"handler"`
+	ReplyTo string      `json:"replyTo,omitempty"`
 }
 
 //easyjson:json
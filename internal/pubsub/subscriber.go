@@ -0,0 +1,28 @@
+package pubsub
+
+import "context"
+
+type subscriberKey struct{}
+
+// Starter is implemented by queues that need to know when every subscriber has subscribed.
+type Starter interface {
+	Started() error
+}
+
+// Unsubscriber is implemented by durable queues to drop the pending messages of a subscriber that won't subscribe
+// again.
+type Unsubscriber interface {
+	Unsubscribe(topic, subscriber string) error
+}
+
+// WithSubscriber names the subscriptions made with the returned context. Durable queues keep the pending messages of
+// a subscription under its name, so it must be the same on every start.
+func WithSubscriber(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, subscriberKey{}, name)
+}
+
+func Subscriber(ctx context.Context) string {
+	name, _ := ctx.Value(subscriberKey{}).(string)
+
+	return name
+}
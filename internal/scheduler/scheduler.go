@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/clock"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
+)
+
+const bucket = "scheduled"
+
+var (
+	ErrInvalidTime = errors.New("time must be RFC3339 or +duration")
+	ErrPastTime    = errors.New("time must be in the future")
+)
+
+//easyjson:json
+type Job struct {
+	ID        string    `json:"id"`
+	Topic     string    `json:"topic"`
+	Payload   []byte    `json:"payload"`
+	Text      string    `json:"text"`
+	PublishAt time.Time `json:"publishAt"`
+}
+
+type Scheduler struct {
+	s   storage.Store
+	q   pubsub.Queue
+	clk clock.Clock
+}
+
+func NewScheduler(s storage.Store, q pubsub.Queue, clk clock.Clock) *Scheduler {
+	return &Scheduler{s: s, q: q, clk: clk}
+}
+
+func (sc *Scheduler) Schedule(when string, topic pubsub.TopicName, text string, payload []byte) (Job, error) {
+	publishAt, err := sc.parseTime(when)
+	if err != nil {
+		return Job{}, err
+	}
+
+	id, err := sc.s.NextID(bucket)
+	if err != nil {
+		return Job{}, err
+	}
+
+	j := Job{ID: id, Topic: topic.String(), Payload: payload, Text: text, PublishAt: publishAt}
+	jb, _ := easyjson.Marshal(j)
+
+	return j, sc.s.Put(bucket, id, jb)
+}
+
+func (sc *Scheduler) List() ([]Job, error) {
+	items, err := sc.s.List(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(items))
+
+	for i := range items {
+		var j Job
+		if err := easyjson.Unmarshal(items[i].Value, &j); err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, j)
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].PublishAt.Before(jobs[j].PublishAt)
+	})
+
+	return jobs, nil
+}
+
+func (sc *Scheduler) Unschedule(id string) error {
+	return sc.s.Delete(bucket, id)
+}
+
+// PublishDue publishes the posts whose time has come. Each post is taken out of the schedule before publishing it, so
+// a post unscheduled meanwhile isn't published, and it's put back when it can't be published.
+func (sc *Scheduler) PublishDue() error {
+	jobs, err := sc.List()
+	if err != nil {
+		return err
+	}
+
+	now := sc.clk.Now()
+
+	for i := range jobs {
+		if jobs[i].PublishAt.After(now) {
+			break
+		}
+
+		jb, err := sc.s.Take(bucket, jobs[i].ID)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := sc.q.Publish(jobs[i].Topic, message.NewMessage(watermill.NewUUID(), jobs[i].Payload)); err != nil {
+			return errors.Join(err, sc.s.Put(bucket, jobs[i].ID, jb))
+		}
+	}
+
+	return nil
+}
+
+func (sc *Scheduler) parseTime(when string) (time.Time, error) {
+	now := sc.clk.Now()
+
+	var publishAt time.Time
+
+	if strings.HasPrefix(when, "+") {
+		d, err := time.ParseDuration(strings.TrimPrefix(when, "+"))
+		if err != nil {
+			return time.Time{}, ErrInvalidTime
+		}
+
+		publishAt = now.Add(d)
+	} else {
+		t, err := time.Parse(time.RFC3339, when)
+		if err != nil {
+			return time.Time{}, ErrInvalidTime
+		}
+
+		publishAt = t.UTC()
+	}
+
+	if !publishAt.After(now) {
+		return time.Time{}, ErrPastTime
+	}
+
+	return publishAt, nil
+}
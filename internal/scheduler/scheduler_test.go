@@ -0,0 +1,161 @@
+package scheduler_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/scheduler"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	"github.com/quintodown/quintodownbot/mocks/clock"
+	mq "github.com/quintodown/quintodownbot/mocks/pubsub"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type publishError struct{}
+
+func (p publishError) Error() string {
+	return "error publishing message"
+}
+
+func TestScheduler_Schedule(t *testing.T) {
+	now := time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC)
+	sc, _, _ := generateSchedulerAndMocks(t, now)
+
+	t.Run("it should fail when time is not valid", func(t *testing.T) {
+		_, err := sc.Schedule("tomorrow", pubsub.TextTopic, "testing", []byte("testing"))
+
+		require.ErrorIs(t, err, scheduler.ErrInvalidTime)
+	})
+
+	t.Run("it should fail when duration is not valid", func(t *testing.T) {
+		_, err := sc.Schedule("+tomorrow", pubsub.TextTopic, "testing", []byte("testing"))
+
+		require.ErrorIs(t, err, scheduler.ErrInvalidTime)
+	})
+
+	t.Run("it should fail when time is in the past", func(t *testing.T) {
+		_, err := sc.Schedule("2021-10-05T19:00:00Z", pubsub.TextTopic, "testing", []byte("testing"))
+
+		require.ErrorIs(t, err, scheduler.ErrPastTime)
+	})
+
+	t.Run("it should schedule a post after a duration", func(t *testing.T) {
+		j, err := sc.Schedule("+90m", pubsub.TextTopic, "testing", []byte("testing"))
+
+		require.NoError(t, err)
+		require.Equal(t, scheduler.Job{
+			ID:        "1",
+			Topic:     "TextTopic",
+			Payload:   []byte("testing"),
+			Text:      "testing",
+			PublishAt: now.Add(90 * time.Minute),
+		}, j)
+	})
+
+	t.Run("it should schedule a post at a given time", func(t *testing.T) {
+		j, err := sc.Schedule("2021-10-05T23:00:00+02:00", pubsub.PhotoTopic, "photo", []byte("photo"))
+
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2021, 10, 5, 21, 0, 0, 0, time.UTC), j.PublishAt)
+	})
+
+	t.Run("it should list scheduled posts ordered by publishing time", func(t *testing.T) {
+		jobs, err := sc.List()
+
+		require.NoError(t, err)
+		require.Len(t, jobs, 2)
+		require.Equal(t, "2", jobs[0].ID)
+		require.Equal(t, "1", jobs[1].ID)
+	})
+
+	t.Run("it should unschedule a post", func(t *testing.T) {
+		require.NoError(t, sc.Unschedule("2"))
+		require.ErrorIs(t, sc.Unschedule("2"), storage.ErrNotFound)
+	})
+}
+
+func TestScheduler_PublishDue(t *testing.T) {
+	now := time.Date(2021, 10, 5, 20, 0, 0, 0, time.UTC)
+
+	t.Run("it should publish only posts that are due", func(t *testing.T) {
+		sc, clk, q := generateSchedulerAndMocks(t, now)
+
+		_, err := sc.Schedule("+1m", pubsub.TextTopic, "due", []byte("due"))
+		require.NoError(t, err)
+		_, err = sc.Schedule("+1h", pubsub.TextTopic, "pending", []byte("pending"))
+		require.NoError(t, err)
+
+		clk.ExpectedCalls = nil
+		clk.On("Now").Return(now.Add(time.Minute))
+		q.On("Publish", pubsub.TextTopic.String(), mock.MatchedBy(func(m *message.Message) bool {
+			return string(m.Payload) == "due"
+		})).Once().Return(nil)
+
+		require.NoError(t, sc.PublishDue())
+
+		jobs, err := sc.List()
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		require.Equal(t, "pending", jobs[0].Text)
+		q.AssertExpectations(t)
+	})
+
+	t.Run("it should keep post when it couldn't be published", func(t *testing.T) {
+		sc, clk, q := generateSchedulerAndMocks(t, now)
+
+		_, err := sc.Schedule("+1m", pubsub.TextTopic, "due", []byte("due"))
+		require.NoError(t, err)
+
+		clk.ExpectedCalls = nil
+		clk.On("Now").Return(now.Add(time.Hour))
+		q.On("Publish", pubsub.TextTopic.String(), mock.Anything).Once().Return(publishError{})
+
+		require.ErrorIs(t, sc.PublishDue(), publishError{})
+
+		jobs, err := sc.List()
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+	})
+
+	t.Run("it should publish a post once when publishing concurrently", func(t *testing.T) {
+		sc, clk, q := generateSchedulerAndMocks(t, now)
+
+		_, err := sc.Schedule("+1m", pubsub.TextTopic, "due", []byte("due"))
+		require.NoError(t, err)
+
+		clk.ExpectedCalls = nil
+		clk.On("Now").Return(now.Add(time.Hour))
+		q.On("Publish", pubsub.TextTopic.String(), mock.Anything).Once().Return(nil)
+
+		errs := make(chan error, 5)
+
+		for i := 0; i < cap(errs); i++ {
+			go func() { errs <- sc.PublishDue() }()
+		}
+
+		for i := 0; i < cap(errs); i++ {
+			require.NoError(t, <-errs)
+		}
+
+		require.ErrorIs(t, sc.Unschedule("1"), storage.ErrNotFound)
+		q.AssertExpectations(t)
+	})
+}
+
+func generateSchedulerAndMocks(t *testing.T, now time.Time) (*scheduler.Scheduler, *clock.Clock, *mq.Queue) {
+	s, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = s.Close() })
+
+	clk := new(clock.Clock)
+	clk.On("Now").Return(now)
+
+	q := new(mq.Queue)
+
+	return scheduler.NewScheduler(s, q, clk), clk, q
+}
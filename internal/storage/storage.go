@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	boltFileMode    = 0o600
+	boltOpenTimeout = 5 * time.Second
+)
+
+var ErrNotFound = errors.New("key not found")
+
+type Store interface {
+	Get(bucket, key string) ([]byte, error)
+	Put(bucket, key string, value []byte) error
+	Delete(bucket, key string) error
+	Take(bucket, key string) ([]byte, error)
+	List(bucket string) ([]Item, error)
+	NextID(bucket string) (string, error)
+	Close() error
+}
+
+type Item struct {
+	Key   string
+	Value []byte
+}
+
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, boltFileMode, &bbolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (bs *BoltStore) Get(bucket, key string) ([]byte, error) {
+	var value []byte
+
+	err := bs.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		v := b.Get([]byte(key))
+		if v == nil {
+			return ErrNotFound
+		}
+
+		value = append([]byte{}, v...)
+
+		return nil
+	})
+
+	return value, err
+}
+
+func (bs *BoltStore) Put(bucket, key string, value []byte) error {
+	return bs.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(key), value)
+	})
+}
+
+func (bs *BoltStore) Delete(bucket, key string) error {
+	return bs.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil || b.Get([]byte(key)) == nil {
+			return ErrNotFound
+		}
+
+		return b.Delete([]byte(key))
+	})
+}
+
+// Take gets a value and deletes it in the same transaction, so only one caller gets it.
+func (bs *BoltStore) Take(bucket, key string) ([]byte, error) {
+	var value []byte
+
+	err := bs.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return ErrNotFound
+		}
+
+		v := b.Get([]byte(key))
+		if v == nil {
+			return ErrNotFound
+		}
+
+		value = append([]byte{}, v...)
+
+		return b.Delete([]byte(key))
+	})
+
+	return value, err
+}
+
+func (bs *BoltStore) List(bucket string) ([]Item, error) {
+	var items []Item
+
+	err := bs.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			items = append(items, Item{Key: string(k), Value: append([]byte{}, v...)})
+
+			return nil
+		})
+	})
+
+	return items, err
+}
+
+func (bs *BoltStore) NextID(bucket string) (string, error) {
+	var id uint64
+
+	err := bs.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+
+		id, err = b.NextSequence()
+
+		return err
+	})
+
+	return strconv.FormatUint(id, 10), err
+}
+
+func (bs *BoltStore) Close() error {
+	return bs.db.Close()
+}
@@ -0,0 +1,92 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/quintodown/quintodownbot/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoltStore(t *testing.T) {
+	s, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	defer func() { _ = s.Close() }()
+
+	t.Run("it should fail getting a key from a missing bucket", func(t *testing.T) {
+		_, err := s.Get("missing", "key")
+
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("it should store and get a value", func(t *testing.T) {
+		require.NoError(t, s.Put("bucket", "key", []byte("value")))
+
+		v, err := s.Get("bucket", "key")
+
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), v)
+	})
+
+	t.Run("it should fail getting a missing key", func(t *testing.T) {
+		_, err := s.Get("bucket", "missing")
+
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("it should list all values in a bucket", func(t *testing.T) {
+		require.NoError(t, s.Put("list", "b", []byte("second")))
+		require.NoError(t, s.Put("list", "a", []byte("first")))
+
+		items, err := s.List("list")
+
+		require.NoError(t, err)
+		require.Equal(t, []storage.Item{
+			{Key: "a", Value: []byte("first")},
+			{Key: "b", Value: []byte("second")},
+		}, items)
+	})
+
+	t.Run("it should return an empty list when bucket doesn't exist", func(t *testing.T) {
+		items, err := s.List("missing")
+
+		require.NoError(t, err)
+		require.Empty(t, items)
+	})
+
+	t.Run("it should delete a value", func(t *testing.T) {
+		require.NoError(t, s.Put("delete", "key", []byte("value")))
+		require.NoError(t, s.Delete("delete", "key"))
+
+		_, err := s.Get("delete", "key")
+
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("it should fail deleting a missing key", func(t *testing.T) {
+		require.ErrorIs(t, s.Delete("delete", "missing"), storage.ErrNotFound)
+	})
+
+	t.Run("it should take a value only once", func(t *testing.T) {
+		require.NoError(t, s.Put("take", "key", []byte("value")))
+
+		v, err := s.Take("take", "key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), v)
+
+		_, err = s.Take("take", "key")
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("it should generate consecutive ids", func(t *testing.T) {
+		first, err := s.NextID("ids")
+		require.NoError(t, err)
+
+		second, err := s.NextID("ids")
+		require.NoError(t, err)
+
+		require.Equal(t, "1", first)
+		require.Equal(t, "2", second)
+	})
+}
@@ -1,6 +1,7 @@
 package telegram
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/quintodown/quintodownbot/internal/bot"
+	"github.com/quintodown/quintodownbot/internal/textsplit"
 	tb "gopkg.in/telebot.v3"
 )
 
@@ -21,14 +23,20 @@ type TbBot interface {
 	Send(to tb.Recipient, what interface{}, opts ...interface{}) (*tb.Message, error)
 	File(file *tb.File) (io.ReadCloser, error)
 	FileByID(fileID string) (tb.File, error)
+	Respond(c *tb.Callback, resp ...*tb.CallbackResponse) error
+	SendAlbum(to tb.Recipient, a tb.Album, opts ...interface{}) ([]tb.Message, error)
+	Edit(msg tb.Editable, what interface{}, opts ...interface{}) (*tb.Message, error)
+	EditMedia(msg tb.Editable, media tb.Inputtable, opts ...interface{}) (*tb.Message, error)
+	Delete(msg tb.Editable) error
 }
 
 type Bot struct {
-	b TbBot
+	b        TbBot
+	splitter *textsplit.Splitter
 }
 
 func NewBot(b TbBot) bot.TelegramBot {
-	return &Bot{b: b}
+	return &Bot{b: b, splitter: textsplit.NewSplitter(textsplit.WithMaxLength(telegramMessageLength))}
 }
 
 func (b *Bot) Start() {
@@ -61,86 +69,337 @@ func (b *Bot) Handle(endpoint string, handler bot.TelegramHandler) {
 				FileID:   m.Message().Photo.FileID,
 				FileURL:  m.Message().Photo.FileURL,
 				FileSize: m.Message().Photo.FileSize,
+				AlbumID:  m.Message().AlbumID,
 			}
 		}
 
+		var replyTo string
+		if m.Message().ReplyTo != nil {
+			replyTo = strconv.Itoa(m.Message().ReplyTo.ID)
+		}
+
 		return handler(bot.TelegramMessage{
+			ID:        strconv.Itoa(m.Message().ID),
 			SenderID:  fmt.Sprintf("%v", m.Sender().ID),
+			ReplyTo:   replyTo,
 			Text:      m.Text(),
+			Entities:  entities(m.Message().Entities),
 			Payload:   m.Message().Payload,
 			Photo:     p,
+			Video:     video(m.Message()),
+			Document:  document(m.Message()),
 			IsPrivate: m.Chat().Private,
+			Edited:    endpoint == tb.OnEdited,
 		})
 	})
 }
 
+func entities(es tb.Entities) []bot.TelegramEntity {
+	if len(es) == 0 {
+		return nil
+	}
+
+	converted := make([]bot.TelegramEntity, 0, len(es))
+	for i := range es {
+		converted = append(converted, bot.TelegramEntity{
+			Type:   string(es[i].Type),
+			Offset: es[i].Offset,
+			Length: es[i].Length,
+			URL:    es[i].URL,
+		})
+	}
+
+	return converted
+}
+
+func video(m *tb.Message) bot.TelegramVideo {
+	switch {
+	case m.Video != nil:
+		return bot.TelegramVideo{
+			Caption:  m.Caption,
+			FileID:   m.Video.FileID,
+			FileURL:  m.Video.FileURL,
+			FileSize: m.Video.FileSize,
+			MimeType: m.Video.MIME,
+		}
+	case m.Animation != nil:
+		return bot.TelegramVideo{
+			Caption:   m.Caption,
+			FileID:    m.Animation.FileID,
+			FileURL:   m.Animation.FileURL,
+			FileSize:  m.Animation.FileSize,
+			MimeType:  m.Animation.MIME,
+			Animation: true,
+		}
+	default:
+		return bot.TelegramVideo{}
+	}
+}
+
+func document(m *tb.Message) bot.TelegramDocument {
+	if m.Document == nil || m.Animation != nil {
+		return bot.TelegramDocument{}
+	}
+
+	return bot.TelegramDocument{
+		Caption:  m.Caption,
+		FileID:   m.Document.FileID,
+		FileURL:  m.Document.FileURL,
+		FileSize: m.Document.FileSize,
+		FileName: m.Document.FileName,
+		MimeType: m.Document.MIME,
+	}
+}
+
+func (b *Bot) HandleCallback(unique string, handler bot.TelegramCallbackHandler) {
+	b.b.Handle("\f"+unique, func(c tb.Context) error {
+		callback := bot.TelegramCallback{
+			ID:       c.Callback().ID,
+			SenderID: fmt.Sprintf("%v", c.Sender().ID),
+			Data:     c.Callback().Data,
+		}
+
+		if c.Callback().Message != nil {
+			callback.MessageID = strconv.Itoa(c.Callback().Message.ID)
+		}
+
+		return handler(callback)
+	})
+}
+
+func (b *Bot) Respond(callbackID string, text string) error {
+	return b.b.Respond(&tb.Callback{ID: callbackID}, &tb.CallbackResponse{Text: text})
+}
+
+func (b *Bot) Chunks(s string) []string {
+	return b.splitter.Split(s)
+}
+
 func (b *Bot) Send(to string, what interface{}, options ...interface{}) error {
+	_, err := b.Publish(to, what, options...)
+
+	return err
+}
+
+func (b *Bot) Publish(to string, what interface{}, options ...interface{}) ([]string, error) {
 	toInt, err := strconv.ParseFloat(to, 0)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var whatTB interface{}
+	options, markup := b.replyMarkup(options)
 
 	switch v := what.(type) {
 	case string:
-		var replyTo *tb.Message
-
-		for _, ts := range b.chunks(v, telegramMessageLength) {
-			options = append(options, &tb.SendOptions{ReplyTo: replyTo})
+		return b.sendChunks(tb.ChatID(toInt), nil, b.Chunks(v), "", markup, options)
+	case bot.TelegramText:
+		return b.sendChunks(tb.ChatID(toInt), nil, b.htmlChunks(v), tb.ModeHTML, markup, options)
+	case bot.TelegramAlbum:
+		album := make(tb.Album, 0, len(v))
+		for i := range v {
+			album = append(album, &tb.Photo{
+				Caption: v[i].Caption,
+				File: tb.File{
+					FileID:   v[i].FileID,
+					FileURL:  v[i].FileURL,
+					FileSize: v[i].FileSize,
+				},
+			})
+		}
 
-			replyTo, err = b.b.Send(tb.ChatID(toInt), ts, options...)
-			if err != nil {
-				return err
-			}
+		sent, err := b.b.SendAlbum(tb.ChatID(toInt), album, options...)
+		if err != nil {
+			return nil, err
 		}
 
-		return nil
-	case bot.TelegramPhoto:
-		whatTB = &tb.Photo{
-			Caption: v.Caption,
-			File: tb.File{
-				FileID:   v.FileID,
-				FileURL:  v.FileURL,
-				FileSize: v.FileSize,
-			},
+		ids := make([]string, 0, len(sent))
+		for i := range sent {
+			ids = append(ids, strconv.Itoa(sent[i].ID))
 		}
-	default:
-		return errors.New("unsupported type")
+
+		return ids, nil
+	}
+
+	whatTB, err := media(what)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = b.b.Send(tb.ChatID(toInt), whatTB, options...)
+	if markup != nil {
+		options = append(options, markup)
+	}
 
-	return err
+	sent, err := b.b.Send(tb.ChatID(toInt), whatTB, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{strconv.Itoa(sent.ID)}, nil
 }
 
-func (b *Bot) GetFile(fileID string) (io.ReadCloser, error) {
-	fileByID, err := b.b.FileByID(fileID)
+func (b *Bot) Edit(to string, ids []string, what interface{}) ([]string, error) {
+	toInt, err := strconv.ParseInt(to, 10, 64)
 	if err != nil {
 		return nil, err
 	}
 
-	return b.b.File(&fileByID)
+	if len(ids) == 0 {
+		return nil, errors.New("no messages to edit")
+	}
+
+	var (
+		chunks    []string
+		parseMode tb.ParseMode
+	)
+
+	switch v := what.(type) {
+	case string:
+		chunks = b.Chunks(v)
+	case bot.TelegramText:
+		chunks, parseMode = b.htmlChunks(v), tb.ModeHTML
+	default:
+		whatTB, err := media(what)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = b.b.EditMedia(tb.StoredMessage{MessageID: ids[0], ChatID: toInt}, whatTB)
+		if err != nil && !notModified(err) {
+			return nil, err
+		}
+
+		return ids, nil
+	}
+
+	edited := make([]string, 0, len(chunks))
+
+	for i := range chunks {
+		if i >= len(ids) {
+			replyTo := &tb.Message{Chat: &tb.Chat{ID: toInt}}
+			replyTo.ID, _ = strconv.Atoi(edited[len(edited)-1])
+
+			sent, err := b.sendChunks(tb.ChatID(toInt), replyTo, chunks[i:], parseMode, nil, nil)
+
+			return append(edited, sent...), err
+		}
+
+		msg := tb.StoredMessage{MessageID: ids[i], ChatID: toInt}
+		if _, err := b.b.Edit(msg, chunks[i], &tb.SendOptions{ParseMode: parseMode}); err != nil && !notModified(err) {
+			return ids, err
+		}
+
+		edited = append(edited, ids[i])
+	}
+
+	return edited, b.Delete(to, ids[len(chunks):]...)
+}
+
+func (b *Bot) Delete(to string, ids ...string) error {
+	toInt, err := strconv.ParseInt(to, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	for i := range ids {
+		if err := b.b.Delete(tb.StoredMessage{MessageID: ids[i], ChatID: toInt}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func notModified(err error) bool {
+	return errors.Is(err, tb.ErrMessageNotModified) || errors.Is(err, tb.ErrSameMessageContent)
+}
+
+func media(what interface{}) (tb.Inputtable, error) {
+	switch v := what.(type) {
+	case bot.TelegramPhoto:
+		file := tb.File{FileID: v.FileID, FileURL: v.FileURL, FileSize: v.FileSize}
+		if v.FileContent != nil {
+			file.FileReader = bytes.NewReader(v.FileContent)
+		}
+
+		return &tb.Photo{Caption: v.Caption, File: file}, nil
+	case bot.TelegramVideo:
+		file := tb.File{FileID: v.FileID, FileURL: v.FileURL, FileSize: v.FileSize}
+
+		if v.Animation {
+			return &tb.Animation{File: file, Caption: v.Caption, MIME: v.MimeType}, nil
+		}
+
+		return &tb.Video{File: file, Caption: v.Caption, MIME: v.MimeType}, nil
+	case bot.TelegramDocument:
+		return &tb.Document{
+			File:     tb.File{FileID: v.FileID, FileURL: v.FileURL, FileSize: v.FileSize},
+			Caption:  v.Caption,
+			FileName: v.FileName,
+			MIME:     v.MimeType,
+		}, nil
+	default:
+		return nil, errors.New("unsupported type")
+	}
 }
 
-func (b *Bot) chunks(s string, chunkSize int) []string {
-	if chunkSize >= len(s) {
-		return []string{s}
+func (b *Bot) sendChunks(
+	to tb.Recipient,
+	replyTo *tb.Message,
+	chunks []string,
+	parseMode tb.ParseMode,
+	markup *tb.ReplyMarkup,
+	options []interface{},
+) ([]string, error) {
+	var err error
+
+	ids := make([]string, 0, len(chunks))
+
+	for _, ts := range chunks {
+		chunkOptions := append(
+			append([]interface{}{}, options...),
+			&tb.SendOptions{ReplyTo: replyTo, ReplyMarkup: markup, ParseMode: parseMode},
+		)
+
+		replyTo, err = b.b.Send(to, ts, chunkOptions...)
+		if err != nil {
+			return ids, err
+		}
+
+		ids = append(ids, strconv.Itoa(replyTo.ID))
 	}
 
-	chunks := make([]string, 0, (len(s)-1)/chunkSize+1)
-	currentLen, currentStart := 0, 0
+	return ids, nil
+}
+
+func (b *Bot) replyMarkup(options []interface{}) ([]interface{}, *tb.ReplyMarkup) {
+	var markup *tb.ReplyMarkup
+
+	tbOptions := make([]interface{}, 0, len(options))
+
+	for i := range options {
+		buttons, ok := options[i].(bot.TelegramButtons)
+		if !ok {
+			tbOptions = append(tbOptions, options[i])
 
-	for i := range s {
-		if currentLen == chunkSize {
-			chunks = append(chunks, s[currentStart:i])
-			currentLen = 0
-			currentStart = i
+			continue
 		}
-		currentLen++
+
+		row := make([]tb.InlineButton, 0, len(buttons))
+		for _, v := range buttons {
+			row = append(row, tb.InlineButton{Unique: v.Unique, Text: v.Text, Data: v.Data})
+		}
+
+		markup = &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{row}}
 	}
 
-	chunks = append(chunks, s[currentStart:])
+	return tbOptions, markup
+}
 
-	return chunks
+func (b *Bot) GetFile(fileID string) (io.ReadCloser, error) {
+	fileByID, err := b.b.FileByID(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.b.File(&fileByID)
 }
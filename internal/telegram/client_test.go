@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/quintodown/quintodownbot/internal/telegram"
 
 	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	tb "gopkg.in/telebot.v3"
 )
@@ -109,6 +111,320 @@ func TestBot_Stop(t *testing.T) {
 	tbBot.AssertCalled(t, "Stop")
 }
 
+func TestBot_HandleCallback(t *testing.T) {
+	var handled bot.TelegramCallback
+
+	tbBot := tbBotMock.NewTbBot(t)
+	tbBot.On("Handle", "\fpublish", mock.Anything).Once().Run(func(args mock.Arguments) {
+		c := new(telebot.Context)
+		c.On("Callback").Return(&tb.Callback{ID: "1", Data: "draft", Message: &tb.Message{ID: 56}})
+		c.On("Sender").Return(&tb.User{ID: 1234})
+
+		require.NoError(t, args.Get(1).(tb.HandlerFunc)(c))
+	})
+
+	telegram.NewBot(tbBot).HandleCallback("publish", func(c bot.TelegramCallback) error {
+		handled = c
+
+		return nil
+	})
+
+	require.Equal(t, bot.TelegramCallback{ID: "1", SenderID: "1234", Data: "draft", MessageID: "56"}, handled)
+}
+
+func TestBot_Respond(t *testing.T) {
+	tbBot := tbBotMock.NewTbBot(t)
+	tbBot.On("Respond", &tb.Callback{ID: "1"}, &tb.CallbackResponse{Text: "Post published"}).Once().Return(nil)
+
+	require.NoError(t, telegram.NewBot(tbBot).Respond("1", "Post published"))
+}
+
+func TestBot_SendWithButtons(t *testing.T) {
+	tbBot := tbBotMock.NewTbBot(t)
+	tbBot.On("Send", tb.ChatID(1234), "Publish?", &tb.SendOptions{
+		ReplyMarkup: &tb.ReplyMarkup{InlineKeyboard: [][]tb.InlineButton{{
+			{Unique: "publish", Text: "Publish", Data: "draft"},
+			{Unique: "cancel", Text: "Cancel", Data: "draft"},
+		}}},
+	}).Once().Return(&tb.Message{}, nil)
+
+	err := telegram.NewBot(tbBot).Send("1234", "Publish?", bot.TelegramButtons{
+		{Unique: "publish", Text: "Publish", Data: "draft"},
+		{Unique: "cancel", Text: "Cancel", Data: "draft"},
+	})
+
+	require.NoError(t, err)
+}
+
+func TestBot_SendAlbum(t *testing.T) {
+	tbBot := tbBotMock.NewTbBot(t)
+	tbBot.On("SendAlbum", tb.ChatID(1234), tb.Album{
+		&tb.Photo{Caption: "test", File: tb.File{FileID: "123456", FileURL: "http://image.url", FileSize: 1234}},
+		&tb.Photo{File: tb.File{FileID: "654321", FileURL: "http://image2.url", FileSize: 4321}},
+	}).Once().Return([]tb.Message{{}, {}}, nil)
+
+	err := telegram.NewBot(tbBot).Send("1234", bot.TelegramAlbum{
+		{Caption: "test", FileID: "123456", FileURL: "http://image.url", FileSize: 1234},
+		{FileID: "654321", FileURL: "http://image2.url", FileSize: 4321},
+	})
+
+	require.NoError(t, err)
+}
+
+func TestBot_Publish(t *testing.T) {
+	t.Run("it should return the ids of the sent messages", func(t *testing.T) {
+		tbBot := tbBotMock.NewTbBot(t)
+		b := telegram.NewBot(tbBot)
+		text := strings.Repeat("gol ", 2000)
+		chunks := b.Chunks(text)
+
+		tbBot.On("Send", tb.ChatID(1234), chunks[0], &tb.SendOptions{}).Once().Return(&tb.Message{ID: 10}, nil)
+		tbBot.On("Send", tb.ChatID(1234), chunks[1], &tb.SendOptions{ReplyTo: &tb.Message{ID: 10}}).
+			Once().
+			Return(&tb.Message{ID: 11}, nil)
+
+		ids, err := b.Publish("1234", text)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"10", "11"}, ids)
+	})
+
+	t.Run("it should return the ids of the album messages", func(t *testing.T) {
+		tbBot := tbBotMock.NewTbBot(t)
+		tbBot.On("SendAlbum", tb.ChatID(1234), mock.Anything).Once().Return([]tb.Message{{ID: 10}, {ID: 11}}, nil)
+
+		ids, err := telegram.NewBot(tbBot).Publish("1234", bot.TelegramAlbum{{FileID: "123456"}, {FileID: "654321"}})
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"10", "11"}, ids)
+	})
+}
+
+func TestBot_Edit(t *testing.T) {
+	t.Run("it should fail when there are no messages to edit", func(t *testing.T) {
+		_, err := telegram.NewBot(tbBotMock.NewTbBot(t)).Edit("1234", nil, "testing")
+
+		require.Error(t, err)
+	})
+
+	t.Run("it should edit the text and delete the remaining messages", func(t *testing.T) {
+		tbBot := tbBotMock.NewTbBot(t)
+		tbBot.On("Edit", tb.StoredMessage{MessageID: "10", ChatID: 1234}, "testing", &tb.SendOptions{}).
+			Once().
+			Return(nil, tb.ErrMessageNotModified)
+		tbBot.On("Delete", tb.StoredMessage{MessageID: "11", ChatID: 1234}).Once().Return(nil)
+
+		ids, err := telegram.NewBot(tbBot).Edit("1234", []string{"10", "11"}, "testing")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"10"}, ids)
+	})
+
+	t.Run("it should reply with the chunks that don't fit in the edited messages", func(t *testing.T) {
+		tbBot := tbBotMock.NewTbBot(t)
+		b := telegram.NewBot(tbBot)
+		text := strings.Repeat("gol ", 2000)
+		chunks := b.Chunks(text)
+
+		tbBot.On("Edit", tb.StoredMessage{MessageID: "10", ChatID: 1234}, chunks[0], &tb.SendOptions{}).
+			Once().
+			Return(&tb.Message{ID: 10}, nil)
+		tbBot.On("Send", tb.ChatID(1234), chunks[1], &tb.SendOptions{
+			ReplyTo: &tb.Message{ID: 10, Chat: &tb.Chat{ID: 1234}},
+		}).Once().Return(&tb.Message{ID: 12}, nil)
+
+		ids, err := b.Edit("1234", []string{"10"}, text)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"10", "12"}, ids)
+	})
+
+	t.Run("it should keep every message when one couldn't be edited", func(t *testing.T) {
+		tbBot := tbBotMock.NewTbBot(t)
+		tbBot.On("Edit", tb.StoredMessage{MessageID: "10", ChatID: 1234}, "testing", &tb.SendOptions{}).
+			Once().
+			Return(nil, tb.ErrChatNotFound)
+
+		ids, err := telegram.NewBot(tbBot).Edit("1234", []string{"10", "11"}, "testing")
+
+		require.ErrorIs(t, err, tb.ErrChatNotFound)
+		require.Equal(t, []string{"10", "11"}, ids)
+	})
+
+	t.Run("it should edit the media of the first message", func(t *testing.T) {
+		tbBot := tbBotMock.NewTbBot(t)
+		tbBot.On("EditMedia", tb.StoredMessage{MessageID: "10", ChatID: 1234}, &tb.Photo{
+			Caption: "test",
+			File:    tb.File{FileID: "123456"},
+		}).Once().Return(&tb.Message{ID: 10}, nil)
+
+		ids, err := telegram.NewBot(tbBot).Edit("1234", []string{"10"}, bot.TelegramPhoto{Caption: "test", FileID: "123456"})
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"10"}, ids)
+	})
+}
+
+func TestBot_Delete(t *testing.T) {
+	tbBot := tbBotMock.NewTbBot(t)
+	tbBot.On("Delete", tb.StoredMessage{MessageID: "10", ChatID: 1234}).Once().Return(nil)
+	tbBot.On("Delete", tb.StoredMessage{MessageID: "11", ChatID: 1234}).Once().Return(errors.New("not found"))
+
+	require.Error(t, telegram.NewBot(tbBot).Delete("1234", "10", "11"))
+}
+
+func TestBot_SendVideoAndDocument(t *testing.T) {
+	file := tb.File{FileID: "123456", FileURL: "http://file.url", FileSize: 1234}
+
+	t.Run("it should send an animation", func(t *testing.T) {
+		tbBot := tbBotMock.NewTbBot(t)
+		tbBot.On("Send", tb.ChatID(1234), &tb.Animation{File: file, Caption: "test", MIME: "video/mp4"}).
+			Once().
+			Return(&tb.Message{}, nil)
+
+		require.NoError(t, telegram.NewBot(tbBot).Send("1234", bot.TelegramVideo{
+			Caption:   "test",
+			FileID:    "123456",
+			FileURL:   "http://file.url",
+			FileSize:  1234,
+			MimeType:  "video/mp4",
+			Animation: true,
+		}))
+	})
+
+	t.Run("it should send a video", func(t *testing.T) {
+		tbBot := tbBotMock.NewTbBot(t)
+		tbBot.On("Send", tb.ChatID(1234), &tb.Video{File: file, Caption: "test", MIME: "video/mp4"}).
+			Once().
+			Return(&tb.Message{}, nil)
+
+		require.NoError(t, telegram.NewBot(tbBot).Send("1234", bot.TelegramVideo{
+			Caption:  "test",
+			FileID:   "123456",
+			FileURL:  "http://file.url",
+			FileSize: 1234,
+			MimeType: "video/mp4",
+		}))
+	})
+
+	t.Run("it should send a document", func(t *testing.T) {
+		tbBot := tbBotMock.NewTbBot(t)
+		tbBot.On("Send", tb.ChatID(1234), &tb.Document{
+			File:     file,
+			Caption:  "test",
+			FileName: "test.pdf",
+			MIME:     "application/pdf",
+		}).Once().Return(&tb.Message{}, nil)
+
+		require.NoError(t, telegram.NewBot(tbBot).Send("1234", bot.TelegramDocument{
+			Caption:  "test",
+			FileID:   "123456",
+			FileURL:  "http://file.url",
+			FileSize: 1234,
+			FileName: "test.pdf",
+			MimeType: "application/pdf",
+		}))
+	})
+}
+
+func TestBot_HandleAnimation(t *testing.T) {
+	var handled bot.TelegramMessage
+
+	tbBot := tbBotMock.NewTbBot(t)
+	tbBot.On("Handle", tb.OnAnimation, mock.Anything).Once().Run(func(args mock.Arguments) {
+		file := tb.File{FileID: "123456", FileSize: 1234}
+
+		c := new(telebot.Context)
+		c.On("Sender").Return(&tb.User{ID: 1234})
+		c.On("Text").Return("")
+		c.On("Chat").Return(&tb.Chat{Type: tb.ChatPrivate})
+		c.On("Message").Return(&tb.Message{
+			Caption:   "goal",
+			Animation: &tb.Animation{File: file, MIME: "video/mp4"},
+			Document:  &tb.Document{File: file, MIME: "video/mp4"},
+		})
+
+		require.NoError(t, args.Get(1).(tb.HandlerFunc)(c))
+	})
+
+	telegram.NewBot(tbBot).Handle(tb.OnAnimation, func(m bot.TelegramMessage) error {
+		handled = m
+
+		return nil
+	})
+
+	require.Equal(t, bot.TelegramVideo{
+		Caption:   "goal",
+		FileID:    "123456",
+		FileSize:  1234,
+		MimeType:  "video/mp4",
+		Animation: true,
+	}, handled.Video)
+	require.Equal(t, bot.TelegramDocument{}, handled.Document)
+}
+
+func TestBot_SendFormattedText(t *testing.T) {
+	for name, tc := range map[string]struct {
+		text     bot.TelegramText
+		expected string
+	}{
+		"it should render entities as HTML": {
+			text: bot.TelegramText{
+				Text: "Gol 😀 de Pedri <3",
+				Entities: []bot.TelegramEntity{
+					{Type: "bold", Offset: 0, Length: 3},
+					{Type: "italic", Offset: 7, Length: 8},
+					{Type: "text_link", Offset: 10, Length: 5, URL: "https://quintodown.com"},
+					{Type: "hashtag", Offset: 0, Length: 3},
+				},
+			},
+			expected: "<b>Gol</b> 😀 <i>de <a href=\"https://quintodown.com\">Pedri</a></i> &lt;3",
+		},
+		"it should close and reopen overlapping entities": {
+			text: bot.TelegramText{
+				Text: "Touchdown de Pedri",
+				Entities: []bot.TelegramEntity{
+					{Type: "bold", Offset: 0, Length: 12},
+					{Type: "italic", Offset: 10, Length: 8},
+				},
+			},
+			expected: "<b>Touchdown <i>de</i></b><i> Pedri</i>",
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			tbBot := tbBotMock.NewTbBot(t)
+			tbBot.On("Send", tb.ChatID(1234), tc.expected, &tb.SendOptions{ParseMode: tb.ModeHTML}).
+				Once().
+				Return(&tb.Message{}, nil)
+
+			require.NoError(t, telegram.NewBot(tbBot).Send("1234", tc.text))
+		})
+	}
+
+	t.Run("it should split entities between chunks", func(t *testing.T) {
+		long := strings.Repeat("a", 4090) + "bold text"
+
+		tbBot := tbBotMock.NewTbBot(t)
+		tbBot.On("Send", tb.ChatID(1234), strings.Repeat("a", 4090)+"<b>bold</b>", mock.Anything).
+			Once().
+			Return(&tb.Message{ID: 1}, nil)
+		tbBot.On("Send", tb.ChatID(1234), "<b>text</b>", mock.Anything).Once().Return(&tb.Message{ID: 2}, nil)
+
+		require.NoError(t, telegram.NewBot(tbBot).Send("1234", bot.TelegramText{
+			Text:     long,
+			Entities: []bot.TelegramEntity{{Type: "bold", Offset: 4090, Length: 9}},
+		}))
+	})
+}
+
+func TestBot_Chunks(t *testing.T) {
+	b := telegram.NewBot(tbBotMock.NewTbBot(t))
+
+	require.Equal(t, []string{"testing"}, b.Chunks("testing"))
+	require.Len(t, b.Chunks(string(generateRandomString())), 2)
+}
+
 func TestBot_SetCommands(t *testing.T) {
 	tlgmbot, err := tb.NewBot(tb.Settings{
 		URL:   "https://api.telegram.mock",
@@ -215,6 +531,17 @@ func TestBot_Send(t *testing.T) {
 		}))
 		require.Eventually(t, checkResponderCalled(&photoSent), time.Second, time.Millisecond)
 	})
+
+	t.Run("it should upload a picture without file id", func(t *testing.T) {
+		photoSent.Store(false)
+
+		require.NoError(t, bt.Send("1234567890", bot.TelegramPhoto{
+			Caption:     "test",
+			FileSize:    8,
+			FileContent: []byte("\x89PNG\x0D\x0A\x1A\x0A"),
+		}))
+		require.Eventually(t, checkResponderCalled(&photoSent), time.Second, time.Millisecond)
+	})
 }
 
 func TestBot_GetFile(t *testing.T) {
@@ -0,0 +1,145 @@
+package telegram
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/quintodown/quintodownbot/internal/bot"
+)
+
+var htmlTags = map[string]string{
+	"bold":          "b",
+	"italic":        "i",
+	"underline":     "u",
+	"strikethrough": "s",
+	"spoiler":       "tg-spoiler",
+	"code":          "code",
+	"pre":           "pre",
+	"text_link":     "a",
+}
+
+func (b *Bot) htmlChunks(t bot.TelegramText) []string {
+	segments := b.splitter.Segments(t.Text)
+	chunks := make([]string, len(segments))
+
+	for i, sg := range segments {
+		start, end := utf16Length(t.Text[:sg.Start]), utf16Length(t.Text[:sg.End])
+		chunks[i] = renderHTML(t.Text[sg.Start:sg.End], clipEntities(t.Entities, start, end))
+	}
+
+	return chunks
+}
+
+func utf16Length(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+func clipEntities(entities []bot.TelegramEntity, start, end int) []bot.TelegramEntity {
+	var clipped []bot.TelegramEntity
+
+	for _, e := range entities {
+		from, to := max(e.Offset, start), min(e.Offset+e.Length, end)
+		if from >= to {
+			continue
+		}
+
+		e.Offset, e.Length = from-start, to-from
+		clipped = append(clipped, e)
+	}
+
+	return clipped
+}
+
+func renderHTML(text string, entities []bot.TelegramEntity) string {
+	var tagged []bot.TelegramEntity
+
+	for _, e := range entities {
+		if _, ok := htmlTags[e.Type]; ok {
+			tagged = append(tagged, e)
+		}
+	}
+
+	sort.SliceStable(tagged, func(i, j int) bool {
+		if tagged[i].Offset == tagged[j].Offset {
+			return tagged[i].Length > tagged[j].Length
+		}
+
+		return tagged[i].Offset < tagged[j].Offset
+	})
+
+	var (
+		sb      strings.Builder
+		open    []bot.TelegramEntity
+		next    int
+		pending []uint16
+		units   = utf16.Encode([]rune(text))
+	)
+
+	flush := func() {
+		sb.WriteString(html.EscapeString(string(utf16.Decode(pending))))
+		pending = pending[:0]
+	}
+
+	for i := 0; i <= len(units); i++ {
+		if ended := firstEnded(open, i); ended < len(open) {
+			flush()
+
+			for k := len(open) - 1; k >= ended; k-- {
+				sb.WriteString("</" + htmlTags[open[k].Type] + ">")
+			}
+
+			// Entities opened after the ended one overlap it without being nested, so they are reopened.
+			var reopened []bot.TelegramEntity
+
+			for _, e := range open[ended:] {
+				if e.Offset+e.Length > i {
+					sb.WriteString(openTag(e))
+					reopened = append(reopened, e)
+				}
+			}
+
+			open = append(open[:ended], reopened...)
+		}
+
+		for next < len(tagged) && tagged[next].Offset == i {
+			flush()
+			sb.WriteString(openTag(tagged[next]))
+			open = append(open, tagged[next])
+			next++
+		}
+
+		if i < len(units) {
+			pending = append(pending, units[i])
+		}
+	}
+
+	flush()
+
+	for len(open) > 0 {
+		sb.WriteString("</" + htmlTags[open[len(open)-1].Type] + ">")
+		open = open[:len(open)-1]
+	}
+
+	return sb.String()
+}
+
+// firstEnded returns the position in the stack of open entities of the first one ending at i, or the stack length.
+func firstEnded(open []bot.TelegramEntity, i int) int {
+	for k, e := range open {
+		if e.Offset+e.Length <= i {
+			return k
+		}
+	}
+
+	return len(open)
+}
+
+func openTag(e bot.TelegramEntity) string {
+	if e.Type == "text_link" {
+		return "<a href=\"" + html.EscapeString(e.URL) + "\">"
+	}
+
+	return "<" + htmlTags[e.Type] + ">"
+}
@@ -0,0 +1,214 @@
+package textsplit
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/javiyt/twitter-text-go/extract"
+	"github.com/javiyt/twitter-text-go/validate"
+)
+
+type Segment struct {
+	Start int
+	End   int
+}
+
+type Splitter struct {
+	maxLength int
+	length    func(string) int
+	numbering bool
+}
+
+type Option func(s *Splitter)
+
+func WithMaxLength(maxLength int) Option {
+	return func(s *Splitter) {
+		s.maxLength = maxLength
+	}
+}
+
+func WithLengthFunc(length func(string) int) Option {
+	return func(s *Splitter) {
+		s.length = length
+	}
+}
+
+func WithTwitterLength() Option {
+	return WithLengthFunc(validate.TweetLength)
+}
+
+func WithNumbering(numbering bool) Option {
+	return func(s *Splitter) {
+		s.numbering = numbering
+	}
+}
+
+func NewSplitter(options ...Option) *Splitter {
+	s := &Splitter{length: utf8.RuneCountInString}
+
+	for _, o := range options {
+		o(s)
+	}
+
+	return s
+}
+
+func (s *Splitter) Split(text string) []string {
+	segments := s.Segments(text)
+	chunks := make([]string, len(segments))
+
+	for i, sg := range segments {
+		chunks[i] = text[sg.Start:sg.End]
+		if s.numbering && len(segments) > 1 {
+			chunks[i] += counter(i+1, len(segments))
+		}
+	}
+
+	return chunks
+}
+
+func (s *Splitter) Segments(text string) []Segment {
+	if s.maxLength <= 0 || s.length(text) <= s.maxLength {
+		return []Segment{{Start: 0, End: len(text)}}
+	}
+
+	if !s.numbering {
+		return s.split(text, s.maxLength)
+	}
+
+	total := 1
+	for {
+		segments := s.split(text, s.maxLength-s.length(counter(total, total)))
+		if len(fmt.Sprint(len(segments))) <= len(fmt.Sprint(total)) {
+			return segments
+		}
+
+		total = len(segments)
+	}
+}
+
+func (s *Splitter) split(text string, maxLength int) []Segment {
+	words := s.words(text)
+	segments := make([]Segment, 0, 2)
+
+	for len(words) > 0 {
+		start := words[0].Start
+		end, last, sentence := words[0].End, 0, -1
+
+		for i := 1; i < len(words) && s.length(text[start:words[i].End]) <= maxLength; i++ {
+			if endsSentence(text, words[i-1]) {
+				sentence = i - 1
+			}
+
+			end, last = words[i].End, i
+		}
+
+		if last < len(words)-1 && sentence >= 0 && s.length(text[start:words[sentence].End]) >= maxLength/2 {
+			end, last = words[sentence].End, sentence
+		}
+
+		if last == 0 && s.length(text[start:end]) > maxLength {
+			end = s.cut(text, words[0], maxLength)
+			if end < words[0].End {
+				words[0].Start = end
+				segments = append(segments, Segment{Start: start, End: end})
+
+				continue
+			}
+		}
+
+		segments = append(segments, Segment{Start: start, End: end})
+		words = words[last+1:]
+	}
+
+	return segments
+}
+
+func (s *Splitter) words(text string) []Segment {
+	var words []Segment
+
+	start := -1
+
+	for i, r := range text {
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			words = append(words, Segment{Start: start, End: i})
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, Segment{Start: start, End: len(text)})
+	}
+
+	return words
+}
+
+func (s *Splitter) cut(text string, word Segment, maxLength int) int {
+	protected := extract.ExtractEntities(text[word.Start:word.End])
+	end := word.Start
+
+	for i, r := range text[word.Start:word.End] {
+		next := word.Start + i + utf8.RuneLen(r)
+		if s.length(text[word.Start:next]) > maxLength {
+			break
+		}
+
+		if !insideEntity(protected, next-word.Start) {
+			end = next
+		}
+	}
+
+	if end > word.Start {
+		return end
+	}
+
+	for _, e := range protected {
+		if e.ByteRange.Start == 0 {
+			return word.Start + e.ByteRange.Stop
+		}
+	}
+
+	_, size := utf8.DecodeRuneInString(text[word.Start:])
+
+	return word.Start + size
+}
+
+func insideEntity(entities []*extract.TwitterEntity, offset int) bool {
+	for _, e := range entities {
+		if offset > e.ByteRange.Start && offset < e.ByteRange.Stop {
+			return true
+		}
+	}
+
+	return false
+}
+
+func endsSentence(text string, word Segment) bool {
+	w := strings.TrimRight(text[word.Start:word.End], `"')]»”’`)
+
+	if strings.HasSuffix(w, ".") || strings.HasSuffix(w, "!") || strings.HasSuffix(w, "?") || strings.HasSuffix(w, "…") {
+		return true
+	}
+
+	following := text[word.End:]
+
+	return strings.Contains(following[:len(following)-len(strings.TrimLeftFunc(following, unicode.IsSpace))], "\n")
+}
+
+func counter(current, total int) string {
+	return fmt.Sprintf(" %d/%d", current, total)
+}
+
+// Remaining returns the chunks still to be sent after the first sent ones, to continue a thread that failed partway.
+func Remaining(chunks []string, sent int) []string {
+	if sent >= len(chunks) {
+		return nil
+	}
+
+	return chunks[sent:]
+}
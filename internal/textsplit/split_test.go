@@ -0,0 +1,99 @@
+package textsplit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/quintodown/quintodownbot/internal/textsplit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitter_Split(t *testing.T) {
+	t.Run("it should not split short texts", func(t *testing.T) {
+		s := textsplit.NewSplitter(textsplit.WithMaxLength(10), textsplit.WithNumbering(true))
+
+		require.Equal(t, []string{" testing "}, s.Split(" testing "))
+	})
+
+	t.Run("it should split between words", func(t *testing.T) {
+		s := textsplit.NewSplitter(textsplit.WithMaxLength(12))
+
+		require.Equal(t, []string{"Gol de Pedri", "en el minuto", "90"}, s.Split("Gol de Pedri en el minuto 90"))
+	})
+
+	t.Run("it should prefer splitting at the end of a sentence", func(t *testing.T) {
+		s := textsplit.NewSplitter(textsplit.WithMaxLength(20))
+
+		require.Equal(
+			t,
+			[]string{"Gol de Pedri!", "Empata el Barça en", "el 90"},
+			s.Split("Gol de Pedri! Empata el Barça en el 90"),
+		)
+	})
+
+	t.Run("it should split at line breaks", func(t *testing.T) {
+		s := textsplit.NewSplitter(textsplit.WithMaxLength(20))
+
+		require.Equal(t, []string{"Final del partido", "Barça 2 Madrid 1"}, s.Split("Final del partido\nBarça 2 Madrid 1"))
+	})
+
+	t.Run("it should split words longer than the max length", func(t *testing.T) {
+		s := textsplit.NewSplitter(textsplit.WithMaxLength(4))
+
+		require.Equal(t, []string{"abcd", "efgh", "ij"}, s.Split("abcdefghij"))
+	})
+
+	t.Run("it should not split links, mentions or hashtags", func(t *testing.T) {
+		s := textsplit.NewSplitter(textsplit.WithMaxLength(10))
+
+		require.Equal(
+			t,
+			[]string{"https://quintodown.com", "@quintodown", "#LaLiga2021"},
+			s.Split("https://quintodown.com @quintodown #LaLiga2021"),
+		)
+		require.Equal(t, []string{"goool(", "https://quintodown.com"}, s.Split("goool(https://quintodown.com"))
+	})
+
+	t.Run("it should measure texts with the given length", func(t *testing.T) {
+		s := textsplit.NewSplitter(textsplit.WithMaxLength(30), textsplit.WithTwitterLength())
+		text := "Crónica del partido https://quintodown.com/cronicas/barca-madrid"
+
+		require.Equal(t, []string{"Crónica del partido", "https://quintodown.com/cronicas/barca-madrid"}, s.Split(text))
+		require.Equal(
+			t,
+			[]string{"Gol https://quintodown.com/cronicas/barca-madrid"},
+			s.Split("Gol https://quintodown.com/cronicas/barca-madrid"),
+		)
+	})
+
+	t.Run("it should number the chunks", func(t *testing.T) {
+		s := textsplit.NewSplitter(textsplit.WithMaxLength(16), textsplit.WithNumbering(true))
+
+		require.Equal(
+			t,
+			[]string{"Gol de Pedri 1/3", "en el minuto 2/3", "90 3/3"},
+			s.Split("Gol de Pedri en el minuto 90"),
+		)
+	})
+
+	t.Run("it should reserve room for numbers with more digits", func(t *testing.T) {
+		s := textsplit.NewSplitter(textsplit.WithMaxLength(8), textsplit.WithNumbering(true))
+
+		chunks := s.Split(strings.Repeat("ab ", 12))
+
+		require.Len(t, chunks, 12)
+		require.Equal(t, "ab 1/12", chunks[0])
+		require.Equal(t, "ab 12/12", chunks[11])
+	})
+}
+
+func TestSplitter_Segments(t *testing.T) {
+	s := textsplit.NewSplitter(textsplit.WithMaxLength(12))
+	text := "Gol de Pedri  en el minuto 90 "
+
+	require.Equal(
+		t,
+		[]textsplit.Segment{{Start: 0, End: 12}, {Start: 14, End: 26}, {Start: 27, End: 29}},
+		s.Segments(text),
+	)
+}
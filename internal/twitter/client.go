@@ -1,110 +1,225 @@
 package twitter
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	gt "github.com/javiyt/go-twitter/twitter"
 	"github.com/javiyt/twitter-text-go/validate"
+	"github.com/quintodown/quintodownbot/internal/textsplit"
 )
 
 const (
 	tweetMaxLength = 280
-	joinString     = "..."
+	tweetMaxMedia  = 4
+	mediaSucceeded = "succeeded"
+	mediaFailed    = "failed"
 )
 
 type Client struct {
-	tc *gt.Client
+	tc        *gt.Client
+	numbering bool
+	splitter  *textsplit.Splitter
 }
 
-func NewTwitterClient(tc *gt.Client) *Client {
-	return &Client{tc: tc}
+type Option func(c *Client)
+
+func WithThreadNumbering(numbering bool) Option {
+	return func(c *Client) {
+		c.numbering = numbering
+	}
 }
 
-func (c *Client) SendUpdate(s string) error {
-	return c.publishTweet(s, &gt.StatusUpdateParams{})
+func NewTwitterClient(tc *gt.Client, options ...Option) *Client {
+	c := &Client{tc: tc}
+
+	for _, o := range options {
+		o(c)
+	}
+
+	c.splitter = textsplit.NewSplitter(
+		textsplit.WithMaxLength(tweetMaxLength),
+		textsplit.WithTwitterLength(),
+		textsplit.WithNumbering(c.numbering),
+	)
+
+	return c
 }
 
-func (c *Client) SendUpdateWithPhoto(s string, pic []byte) error {
-	uploadResult, resp, err := c.tc.Media.Upload(pic, http.DetectContentType(pic))
+func (c *Client) SendUpdate(s string) ([]string, error) {
+	return c.publishTweet(s, &gt.StatusUpdateParams{}, nil)
+}
 
-	defer func() { _ = resp.Body.Close() }()
+// ContinueThread publishes the chunks of s missing from a thread that failed partway, replying to its last tweet.
+func (c *Client) ContinueThread(s string, thread []string) ([]string, error) {
+	return c.publishTweet(s, &gt.StatusUpdateParams{}, thread)
+}
 
-	if err != nil {
-		buf := new(strings.Builder)
-		_, _ = io.Copy(buf, resp.Body)
-
-		return fmt.Errorf(
-			"error sending status update: %w. Response status code: %v and body: %s",
-			err,
-			resp.StatusCode,
-			buf.String(),
-		)
+func (c *Client) SendUpdateWithPhoto(s string, pics ...[]byte) ([]string, error) {
+	if len(pics) > tweetMaxMedia {
+		pics = pics[:tweetMaxMedia]
 	}
 
-	return c.publishTweet(s, &gt.StatusUpdateParams{MediaIds: []int64{uploadResult.MediaID}})
+	mediaIDs := make([]int64, 0, len(pics))
+
+	for _, pic := range pics {
+		uploadResult, err := c.uploadMedia(pic)
+		if err != nil {
+			return nil, err
+		}
+
+		mediaIDs = append(mediaIDs, uploadResult.MediaID)
+	}
+
+	return c.publishTweet(s, &gt.StatusUpdateParams{MediaIds: mediaIDs}, nil)
 }
 
-func (c *Client) publishTweet(s string, params *gt.StatusUpdateParams) error {
-	err := validate.ValidateTweet(s)
-	switch err.(type) {
-	case validate.EmptyError:
-		return nil
-	case validate.InvalidCharacterError:
-		return fmt.Errorf("error sending status update: %w", err)
+func (c *Client) SendUpdateWithVideo(s string, video []byte) ([]string, error) {
+	uploadResult, err := c.uploadMedia(video)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.waitForProcessing(uploadResult.MediaID, uploadResult.ProcessingInfo); err != nil {
+		return nil, err
 	}
 
-	var replyToID int64
-	for _, ts := range c.chunks(s, tweetMaxLength-len(joinString)) {
-		if replyToID > 0 {
-			params.InReplyToStatusID = replyToID
+	return c.publishTweet(s, &gt.StatusUpdateParams{MediaIds: []int64{uploadResult.MediaID}}, nil)
+}
+
+func (c *Client) DeleteUpdates(ids ...string) error {
+	for i := range ids {
+		id, err := strconv.ParseInt(ids[i], 10, 64)
+		if err != nil {
+			return err
 		}
 
-		if len(ts) == tweetMaxLength-len(joinString) {
-			ts += joinString
+		_, resp, err := c.tc.Statuses.Destroy(id, nil)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			_ = resp.Body.Close()
+
+			continue
 		}
 
-		tweet, resp, err := c.tc.Statuses.Update(ts, params)
 		if err != nil {
-			buf := new(strings.Builder)
-			_, _ = io.Copy(buf, resp.Body)
-			_ = resp.Body.Close()
+			return responseError(err, resp)
+		}
+
+		_ = resp.Body.Close()
+	}
+
+	return nil
+}
 
-			return fmt.Errorf(
-				"error sending status update: %w. Response status code: %v and body: %s",
-				err,
-				resp.StatusCode,
-				buf.String(),
-			)
+func (c *Client) uploadMedia(media []byte) (*gt.MediaUploadResult, error) {
+	uploadResult, resp, err := c.tc.Media.Upload(media, http.DetectContentType(media))
+	if err != nil {
+		return nil, responseError(err, resp)
+	}
+
+	_ = resp.Body.Close()
+
+	return uploadResult, nil
+}
+
+func (c *Client) waitForProcessing(mediaID int64, info *gt.MediaProcessingInfo) error {
+	for info != nil {
+		switch info.State {
+		case mediaSucceeded:
+			return nil
+		case mediaFailed:
+			if info.Error != nil {
+				return fmt.Errorf("error processing media: %s", info.Error.Message)
+			}
+
+			return errors.New("error processing media")
+		}
+
+		time.Sleep(time.Duration(info.CheckAfterSecs) * time.Second)
+
+		status, resp, err := c.tc.Media.Status(mediaID)
+		if err != nil {
+			return responseError(err, resp)
 		}
 
-		replyToID = tweet.ID
+		_ = resp.Body.Close()
+		info = status.ProcessingInfo
 	}
 
 	return nil
 }
 
-func (c *Client) chunks(s string, chunkSize int) []string {
-	if chunkSize >= len(s) {
-		return []string{s}
+func responseError(err error, resp *http.Response) error {
+	if resp == nil {
+		return fmt.Errorf("error sending status update: %w", err)
 	}
 
-	chunks := make([]string, 0, (len(s)-1)/chunkSize+1)
-	currentLen := 0
-	currentStart := 0
+	defer func() { _ = resp.Body.Close() }()
+
+	buf := new(strings.Builder)
+	_, _ = io.Copy(buf, resp.Body)
+
+	return fmt.Errorf(
+		"error sending status update: %w. Response status code: %v and body: %s",
+		err,
+		resp.StatusCode,
+		buf.String(),
+	)
+}
 
-	for i := range s {
-		if currentLen == chunkSize {
-			chunks = append(chunks, s[currentStart:i])
-			currentLen = 0
-			currentStart = i
+func (c *Client) publishTweet(s string, params *gt.StatusUpdateParams, thread []string) ([]string, error) {
+	empty, err := validateTweet(s)
+	if err != nil || (empty && len(params.MediaIds) == 0) {
+		return thread, err
+	}
+
+	ids := append([]string(nil), thread...)
+	chunks := c.Chunks(s)
+
+	if empty {
+		chunks = []string{""}
+	}
+
+	if len(ids) > 0 {
+		last, err := strconv.ParseInt(ids[len(ids)-1], 10, 64)
+		if err != nil {
+			return ids, err
 		}
-		currentLen++
+
+		params.InReplyToStatusID = last
+		chunks = textsplit.Remaining(chunks, len(ids))
+	}
+
+	for _, ts := range chunks {
+		tweet, resp, err := c.tc.Statuses.Update(ts, params)
+		if err != nil {
+			return ids, responseError(err, resp)
+		}
+
+		params.InReplyToStatusID = tweet.ID
+		ids = append(ids, strconv.FormatInt(tweet.ID, 10))
 	}
 
-	chunks = append(chunks, s[currentStart:])
+	return ids, nil
+}
+
+func validateTweet(s string) (bool, error) {
+	err := validate.ValidateTweet(s)
+	switch err.(type) {
+	case validate.EmptyError:
+		return true, nil
+	case validate.InvalidCharacterError:
+		return false, fmt.Errorf("error sending status update: %w", err)
+	}
+
+	return false, nil
+}
 
-	return chunks
+func (c *Client) Chunks(s string) []string {
+	return c.splitter.Split(s)
 }
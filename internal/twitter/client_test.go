@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,42 +28,44 @@ func TestClient_SendUpdate(t *testing.T) {
 	client := twitter.NewTwitterClient(gt.NewClient(httpClient))
 
 	t.Run("it should fail when error happens on Twitter API", func(t *testing.T) {
-		require.EqualError(
-			t,
-			client.SendUpdate("it should fail"),
-			"error sending status update: EOF. Response status code: 403 and body: ",
-		)
+		_, err := client.SendUpdate("it should fail")
+
+		require.EqualError(t, err, "error sending status update: EOF. Response status code: 403 and body: ")
 		require.Equal(t, 1, httpmock.GetTotalCallCount())
 		httpmock.ZeroCallCounters()
 	})
 
 	t.Run("it should not send status update when status is empty", func(t *testing.T) {
-		require.NoError(
-			t,
-			client.SendUpdate(""),
-		)
+		ids, err := client.SendUpdate("")
+
+		require.NoError(t, err)
+		require.Empty(t, ids)
 		require.Zero(t, httpmock.GetTotalCallCount())
 		httpmock.ZeroCallCounters()
 	})
 
 	t.Run("it should fail when invalid character in status update", func(t *testing.T) {
-		require.EqualError(
-			t,
-			client.SendUpdate("test \uFFFE"),
-			"error sending status update: Invalid chararcter [\uFFFE] found at byte offset 5",
-		)
+		_, err := client.SendUpdate("test \uFFFE")
+
+		require.EqualError(t, err, "error sending status update: Invalid chararcter [\uFFFE] found at byte offset 5")
 		require.Zero(t, httpmock.GetTotalCallCount())
 		httpmock.ZeroCallCounters()
 	})
 
 	t.Run("it should send status update to Twitter API", func(t *testing.T) {
-		require.NoError(t, client.SendUpdate("testing"))
+		ids, err := client.SendUpdate("testing")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1050118621198921700"}, ids)
 		require.Equal(t, 1, httpmock.GetTotalCallCount())
 		httpmock.ZeroCallCounters()
 	})
 
 	t.Run("it should send long status update to Twitter API", func(t *testing.T) {
-		require.NoError(t, client.SendUpdate(longTweet))
+		ids, err := client.SendUpdate(longTweet)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1445823463904798049", "1445823463904798051"}, ids)
 		require.Equal(t, 2, httpmock.GetTotalCallCount())
 		httpmock.ZeroCallCounters()
 	})
@@ -85,11 +88,9 @@ func TestClient_SendUpdateWithPhoto(t *testing.T) {
 		buf := new(bytes.Buffer)
 		_, _ = buf.ReadFrom(file)
 
-		require.EqualError(
-			t,
-			client.SendUpdateWithPhoto("testing", buf.Bytes()),
-			"error sending status update: EOF. Response status code: 403 and body: ",
-		)
+		_, err := client.SendUpdateWithPhoto("testing", buf.Bytes())
+
+		require.EqualError(t, err, "error sending status update: EOF. Response status code: 403 and body: ")
 	})
 
 	t.Run("it should fail sending status update with photo to Twitter API", func(t *testing.T) {
@@ -98,11 +99,9 @@ func TestClient_SendUpdateWithPhoto(t *testing.T) {
 		buf := new(bytes.Buffer)
 		_, _ = buf.ReadFrom(file)
 
-		require.EqualError(
-			t,
-			client.SendUpdateWithPhoto("it should fail", buf.Bytes()),
-			"error sending status update: EOF. Response status code: 403 and body: ",
-		)
+		_, err := client.SendUpdateWithPhoto("it should fail", buf.Bytes())
+
+		require.EqualError(t, err, "error sending status update: EOF. Response status code: 403 and body: ")
 	})
 
 	t.Run("it should send status update with photo to Twitter API", func(t *testing.T) {
@@ -111,17 +110,119 @@ func TestClient_SendUpdateWithPhoto(t *testing.T) {
 		buf := new(bytes.Buffer)
 		_, _ = buf.ReadFrom(file)
 
-		require.NoError(
+		ids, err := client.SendUpdateWithPhoto("testing", buf.Bytes())
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1050118621198921700"}, ids)
+	})
+
+	t.Run("it should send photo without status text to Twitter API", func(t *testing.T) {
+		file, _ := os.Open("testdata/test.png")
+		defer func() { _ = file.Close() }()
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(file)
+
+		ids, err := client.SendUpdateWithPhoto("", buf.Bytes())
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1050118621198921701"}, ids)
+	})
+
+	t.Run("it should send status update with up to four photos to Twitter API", func(t *testing.T) {
+		file, _ := os.Open("testdata/test.png")
+		defer func() { _ = file.Close() }()
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(file)
+
+		uploadCall := "POST https://upload.twitter.com/1.1/media/upload.json"
+		uploads := httpmock.GetCallCountInfo()[uploadCall]
+
+		_, err := client.SendUpdateWithPhoto("testing", buf.Bytes())
+		require.NoError(t, err)
+
+		singleUpload := httpmock.GetCallCountInfo()[uploadCall] - uploads
+		uploads = httpmock.GetCallCountInfo()[uploadCall]
+
+		_, err = client.SendUpdateWithPhoto("testing", buf.Bytes(), buf.Bytes(), buf.Bytes(), buf.Bytes(), buf.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, uploads+4*singleUpload, httpmock.GetCallCountInfo()[uploadCall])
+	})
+}
+
+func TestClient_SendUpdateWithVideo(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	_ = mockHTTPCalls()
+
+	httpClient := oauth1.NewConfig("consumerKey", "consumerSecret").
+		Client(oauth1.NoContext, oauth1.NewToken("accessToken", "accessSecret"))
+
+	client := twitter.NewTwitterClient(gt.NewClient(httpClient))
+
+	t.Run("it should fail when video couldn't be processed by Twitter", func(t *testing.T) {
+		_, err := client.SendUpdateWithVideo("testing", append(testVideo(), 0, 0, 0, 0))
+
+		require.EqualError(t, err, "error processing media: invalid video")
+	})
+
+	t.Run("it should send status update with video once it's processed", func(t *testing.T) {
+		ids, err := client.SendUpdateWithVideo("testing", testVideo())
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1050118621198921700"}, ids)
+	})
+}
+
+func TestClient_DeleteUpdates(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"POST",
+		"https://api.twitter.com/1.1/statuses/destroy/1445823463904798049.json",
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, gt.Tweet{ID: 1445823463904798049}),
+	)
+	httpmock.RegisterResponder(
+		"POST",
+		"https://api.twitter.com/1.1/statuses/destroy/1445823463904798051.json",
+		httpmock.NewStringResponder(http.StatusNotFound, `{"errors":[{"code":144,"message":"No status found"}]}`),
+	)
+	httpmock.RegisterResponder(
+		"POST",
+		"https://api.twitter.com/1.1/statuses/destroy/1445823463904798052.json",
+		httpmock.NewStringResponder(http.StatusForbidden, ""),
+	)
+
+	client := twitter.NewTwitterClient(gt.NewClient(http.DefaultClient))
+
+	t.Run("it should delete tweets ignoring the ones already deleted", func(t *testing.T) {
+		require.NoError(t, client.DeleteUpdates("1445823463904798049", "1445823463904798051"))
+		require.Equal(t, 2, httpmock.GetTotalCallCount())
+		httpmock.ZeroCallCounters()
+	})
+
+	t.Run("it should fail when tweet couldn't be deleted", func(t *testing.T) {
+		require.EqualError(
 			t,
-			client.SendUpdateWithPhoto("testing", buf.Bytes()),
+			client.DeleteUpdates("1445823463904798052"),
+			"error sending status update: EOF. Response status code: 403 and body: ",
 		)
 	})
+
+	t.Run("it should fail when tweet id is not valid", func(t *testing.T) {
+		require.Error(t, client.DeleteUpdates("tweet"))
+	})
+}
+
+func testVideo() []byte {
+	return []byte("\x00\x00\x00\x10ftypmp42\x00\x00\x00\x00")
 }
 
 func mockHTTPCalls() string {
 	rand.Seed(time.Now().UnixNano())
 
-	letterRunes := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ ")
+	letterRunes := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 	b := make([]rune, 300)
 
 	for i := range b {
@@ -145,6 +246,10 @@ func mockHTTPCalls() string {
 
 			var resp *http.Response
 			var err error
+			if video := videoUploadResponse(req); video != nil {
+				return video, nil
+			}
+
 			if req.Form.Get("media_type") == "image/png" || req.Form.Get("media_id") == "12345" {
 				resp, err = httpmock.NewJsonResponse(200, gt.MediaUploadResult{MediaID: 12345})
 				if err != nil {
@@ -158,9 +263,50 @@ func mockHTTPCalls() string {
 		},
 	)
 
+	httpmock.RegisterResponder(
+		"GET",
+		"https://upload.twitter.com/1.1/media/upload.json",
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("command") != "STATUS" || req.URL.Query().Get("media_id") != "54321" {
+				return httpmock.NewStringResponse(http.StatusForbidden, ""), nil
+			}
+
+			return httpmock.NewJsonResponse(200, gt.MediaStatusResult{
+				MediaID:        54321,
+				ProcessingInfo: &gt.MediaProcessingInfo{State: "succeeded"},
+			})
+		},
+	)
+
 	return longTweet
 }
 
+func videoUploadResponse(req *http.Request) *http.Response {
+	var result gt.MediaUploadResult
+
+	switch {
+	case req.Form.Get("media_type") == "video/mp4" && req.Form.Get("total_bytes") == "16":
+		result = gt.MediaUploadResult{MediaID: 54321}
+	case req.Form.Get("media_type") == "video/mp4":
+		result = gt.MediaUploadResult{MediaID: 54322}
+	case req.Form.Get("media_id") == "54321" && req.Form.Get("command") == "FINALIZE":
+		result = gt.MediaUploadResult{MediaID: 54321, ProcessingInfo: &gt.MediaProcessingInfo{State: "pending"}}
+	case req.Form.Get("media_id") == "54322" && req.Form.Get("command") == "FINALIZE":
+		result = gt.MediaUploadResult{MediaID: 54322, ProcessingInfo: &gt.MediaProcessingInfo{
+			State: "failed",
+			Error: &gt.MediaProcessingError{Message: "invalid video"},
+		}}
+	case req.Form.Get("media_id") == "54321" || req.Form.Get("media_id") == "54322":
+		result = gt.MediaUploadResult{}
+	default:
+		return nil
+	}
+
+	resp, _ := httpmock.NewJsonResponse(200, result)
+
+	return resp
+}
+
 func getResponderForLongTweet(longTweet string) func(req *http.Request) (*http.Response, error) {
 	return func(req *http.Request) (*http.Response, error) {
 		_ = req.ParseForm()
@@ -185,7 +331,7 @@ func getResponderForLongTweet(longTweet string) func(req *http.Request) (*http.R
 			return resp, nil
 		}
 
-		if req.Form.Get("status") == longTweet[:277]+"..." {
+		if req.Form.Get("status") == longTweet[:280] {
 			resp, err = httpmock.NewJsonResponse(200, gt.Tweet{
 				ID:        1445823463904798049,
 				IDStr:     "1445823463904798049",
@@ -200,7 +346,7 @@ func getResponderForLongTweet(longTweet string) func(req *http.Request) (*http.R
 			return resp, nil
 		}
 
-		if req.Form.Get("status") == longTweet[277:] &&
+		if req.Form.Get("status") == longTweet[280:] &&
 			req.Form.Get("in_reply_to_status_id") == "1445823463904798049" {
 			resp, err = httpmock.NewJsonResponse(200, gt.Tweet{
 				ID:        1445823463904798051,
@@ -216,6 +362,45 @@ func getResponderForLongTweet(longTweet string) func(req *http.Request) (*http.R
 			return resp, nil
 		}
 
+		if req.Form.Get("status") == "" && req.Form.Get("media_ids") == "12345" {
+			return httpmock.NewJsonResponse(200, gt.Tweet{
+				ID:        1050118621198921701,
+				IDStr:     "1050118621198921701",
+				CreatedAt: time.Now().UTC().Format(time.RubyDate),
+			})
+		}
+
 		return httpmock.NewStringResponse(http.StatusForbidden, ""), nil
 	}
 }
+
+func TestClient_Chunks(t *testing.T) {
+	client := twitter.NewTwitterClient(gt.NewClient(http.DefaultClient))
+
+	t.Run("it should return a single tweet when text is short", func(t *testing.T) {
+		require.Equal(t, []string{"testing"}, client.Chunks("testing"))
+	})
+
+	t.Run("it should split long words", func(t *testing.T) {
+		text := strings.Repeat("a", 300)
+
+		require.Equal(t, []string{strings.Repeat("a", 280), strings.Repeat("a", 20)}, client.Chunks(text))
+	})
+
+	t.Run("it should count links as twitter does", func(t *testing.T) {
+		text := strings.Repeat("a", 250) + " https://quintodown.com/" + strings.Repeat("b", 60)
+
+		require.Equal(t, []string{text}, client.Chunks(text))
+	})
+
+	t.Run("it should number tweets when enabled", func(t *testing.T) {
+		numbered := twitter.NewTwitterClient(gt.NewClient(http.DefaultClient), twitter.WithThreadNumbering(true))
+		text := strings.Repeat("word ", 100)
+
+		chunks := numbered.Chunks(text)
+
+		require.Len(t, chunks, 2)
+		require.Equal(t, strings.Repeat("word ", 55)+"1/2", chunks[0])
+		require.Equal(t, strings.TrimSpace(strings.Repeat("word ", 45))+" 2/2", chunks[1])
+	})
+}
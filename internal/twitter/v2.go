@@ -0,0 +1,179 @@
+package twitter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	gt "github.com/javiyt/go-twitter/twitter"
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/textsplit"
+)
+
+const tweetsV2URL = "https://api.twitter.com/2/tweets"
+
+var errUnexpectedStatus = errors.New("unexpected response status")
+
+//easyjson:json
+type tweetV2Request struct {
+	Text  string        `json:"text,omitempty"`
+	Reply *tweetV2Reply `json:"reply,omitempty"`
+	Media *tweetV2Media `json:"media,omitempty"`
+}
+
+type tweetV2Reply struct {
+	InReplyToTweetID string `json:"in_reply_to_tweet_id"`
+}
+
+type tweetV2Media struct {
+	MediaIDs []string `json:"media_ids"`
+}
+
+//easyjson:json
+type tweetV2Response struct {
+	Data tweetV2Data `json:"data"`
+}
+
+type tweetV2Data struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type ClientV2 struct {
+	hc *http.Client
+	v1 *Client
+}
+
+func NewTwitterV2Client(hc *http.Client, options ...Option) *ClientV2 {
+	return &ClientV2{hc: hc, v1: NewTwitterClient(gt.NewClient(hc), options...)}
+}
+
+func (c *ClientV2) SendUpdate(s string) ([]string, error) {
+	return c.publishTweet(s, nil, nil)
+}
+
+// ContinueThread publishes the chunks of s missing from a thread that failed partway, replying to its last tweet.
+func (c *ClientV2) ContinueThread(s string, thread []string) ([]string, error) {
+	return c.publishTweet(s, nil, thread)
+}
+
+func (c *ClientV2) SendUpdateWithPhoto(s string, pics ...[]byte) ([]string, error) {
+	if len(pics) > tweetMaxMedia {
+		pics = pics[:tweetMaxMedia]
+	}
+
+	mediaIDs := make([]string, 0, len(pics))
+
+	for _, pic := range pics {
+		uploadResult, err := c.v1.uploadMedia(pic)
+		if err != nil {
+			return nil, err
+		}
+
+		mediaIDs = append(mediaIDs, strconv.FormatInt(uploadResult.MediaID, 10))
+	}
+
+	return c.publishTweet(s, mediaIDs, nil)
+}
+
+func (c *ClientV2) SendUpdateWithVideo(s string, video []byte) ([]string, error) {
+	uploadResult, err := c.v1.uploadMedia(video)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.v1.waitForProcessing(uploadResult.MediaID, uploadResult.ProcessingInfo); err != nil {
+		return nil, err
+	}
+
+	return c.publishTweet(s, []string{strconv.FormatInt(uploadResult.MediaID, 10)}, nil)
+}
+
+func (c *ClientV2) DeleteUpdates(ids ...string) error {
+	for i := range ids {
+		if _, err := strconv.ParseInt(ids[i], 10, 64); err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest(http.MethodDelete, tweetsV2URL+"/"+ids[i], http.NoBody)
+		if err != nil {
+			return err
+		}
+
+		resp, err := c.hc.Do(req)
+		if err != nil {
+			return responseError(err, nil)
+		}
+
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+			return responseError(errUnexpectedStatus, resp)
+		}
+
+		_ = resp.Body.Close()
+	}
+
+	return nil
+}
+
+func (c *ClientV2) Chunks(s string) []string {
+	return c.v1.Chunks(s)
+}
+
+func (c *ClientV2) publishTweet(s string, mediaIDs, thread []string) ([]string, error) {
+	empty, err := validateTweet(s)
+	if err != nil || (empty && len(mediaIDs) == 0) {
+		return thread, err
+	}
+
+	ids := append([]string(nil), thread...)
+	chunks := c.Chunks(s)
+
+	if empty {
+		chunks = []string{""}
+	}
+
+	for _, ts := range textsplit.Remaining(chunks, len(ids)) {
+		tweet := tweetV2Request{Text: ts}
+
+		if len(ids) == 0 && len(mediaIDs) > 0 {
+			tweet.Media = &tweetV2Media{MediaIDs: mediaIDs}
+		}
+
+		if len(ids) > 0 {
+			tweet.Reply = &tweetV2Reply{InReplyToTweetID: ids[len(ids)-1]}
+		}
+
+		id, err := c.postTweet(tweet)
+		if err != nil {
+			return ids, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (c *ClientV2) postTweet(tweet tweetV2Request) (string, error) {
+	body, _ := easyjson.Marshal(tweet)
+
+	resp, err := c.hc.Post(tweetsV2URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", responseError(err, nil)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", responseError(errUnexpectedStatus, resp)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	var created tweetV2Response
+	if err := easyjson.UnmarshalFromReader(resp.Body, &created); err != nil {
+		return "", fmt.Errorf("error sending status update: %w", err)
+	}
+
+	return created.Data.ID, nil
+}
@@ -0,0 +1,225 @@
+package twitter_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dghubble/oauth1"
+	"github.com/jarcoal/httpmock"
+	"github.com/quintodown/quintodownbot/internal/twitter"
+	"github.com/stretchr/testify/require"
+)
+
+const tweetsV2URL = "https://api.twitter.com/2/tweets"
+
+type tweetV2 struct {
+	Text  string `json:"text"`
+	Reply *struct {
+		InReplyToTweetID string `json:"in_reply_to_tweet_id"`
+	} `json:"reply"`
+	Media *struct {
+		MediaIDs []string `json:"media_ids"`
+	} `json:"media"`
+}
+
+func TestClientV2_SendUpdate(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	longTweet := strings.Repeat("a", 300)
+	httpmock.RegisterResponder("POST", tweetsV2URL, tweetsV2Responder(t, longTweet))
+
+	client := twitter.NewTwitterV2Client(newOAuthClient())
+
+	t.Run("it should fail when error happens on Twitter API", func(t *testing.T) {
+		_, err := client.SendUpdate("it should fail")
+
+		require.EqualError(
+			t,
+			err,
+			"error sending status update: unexpected response status. Response status code: 403 and body: "+
+				`{"title":"Forbidden"}`,
+		)
+		require.Equal(t, 1, httpmock.GetTotalCallCount())
+		httpmock.ZeroCallCounters()
+	})
+
+	t.Run("it should not send status update when status is empty", func(t *testing.T) {
+		ids, err := client.SendUpdate("")
+
+		require.NoError(t, err)
+		require.Empty(t, ids)
+		require.Zero(t, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("it should fail when invalid character in status update", func(t *testing.T) {
+		_, err := client.SendUpdate("test ￾")
+
+		require.EqualError(t, err, "error sending status update: Invalid chararcter [￾] found at byte offset 5")
+		require.Zero(t, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("it should send status update to Twitter API", func(t *testing.T) {
+		ids, err := client.SendUpdate("testing")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1050118621198921728"}, ids)
+		require.Equal(t, 1, httpmock.GetTotalCallCount())
+		httpmock.ZeroCallCounters()
+	})
+
+	t.Run("it should send long status update as a thread", func(t *testing.T) {
+		ids, err := client.SendUpdate(longTweet)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1445823463904798049", "1445823463904798051"}, ids)
+		require.Equal(t, 2, httpmock.GetTotalCallCount())
+		httpmock.ZeroCallCounters()
+	})
+
+	t.Run("it should continue a thread from its last tweet", func(t *testing.T) {
+		ids, err := client.ContinueThread(longTweet, []string{"1445823463904798049"})
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1445823463904798049", "1445823463904798051"}, ids)
+		require.Equal(t, 1, httpmock.GetTotalCallCount())
+		httpmock.ZeroCallCounters()
+	})
+}
+
+func TestClientV2_SendUpdateWithMedia(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	_ = mockHTTPCalls()
+	longTweet := strings.Repeat("a", 300)
+	httpmock.RegisterResponder("POST", tweetsV2URL, tweetsV2Responder(t, longTweet))
+
+	client := twitter.NewTwitterV2Client(newOAuthClient())
+
+	t.Run("it should fail when media couldn't be uploaded", func(t *testing.T) {
+		pic, _ := os.ReadFile("testdata/icon_gopher.jpg")
+
+		_, err := client.SendUpdateWithPhoto("testing", pic)
+
+		require.EqualError(t, err, "error sending status update: EOF. Response status code: 403 and body: ")
+	})
+
+	t.Run("it should attach the photos to the first tweet", func(t *testing.T) {
+		pic, _ := os.ReadFile("testdata/test.png")
+
+		ids, err := client.SendUpdateWithPhoto(longTweet, pic, pic)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1445823463904798049", "1445823463904798051"}, ids)
+	})
+
+	t.Run("it should send photos without text", func(t *testing.T) {
+		pic, _ := os.ReadFile("testdata/test.png")
+
+		ids, err := client.SendUpdateWithPhoto("", pic)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1050118621198921730"}, ids)
+	})
+
+	t.Run("it should fail when video couldn't be processed by Twitter", func(t *testing.T) {
+		_, err := client.SendUpdateWithVideo("testing", append(testVideo(), 0, 0, 0, 0))
+
+		require.EqualError(t, err, "error processing media: invalid video")
+	})
+
+	t.Run("it should send status update with video once it's processed", func(t *testing.T) {
+		ids, err := client.SendUpdateWithVideo("testing", testVideo())
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1050118621198921729"}, ids)
+	})
+}
+
+func TestClientV2_DeleteUpdates(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"DELETE",
+		tweetsV2URL+"/1445823463904798049",
+		httpmock.NewStringResponder(http.StatusOK, `{"data":{"deleted":true}}`),
+	)
+	httpmock.RegisterResponder(
+		"DELETE",
+		tweetsV2URL+"/1445823463904798051",
+		httpmock.NewStringResponder(http.StatusNotFound, `{"title":"Not Found Error"}`),
+	)
+	httpmock.RegisterResponder(
+		"DELETE",
+		tweetsV2URL+"/1445823463904798052",
+		httpmock.NewStringResponder(http.StatusForbidden, `{"title":"Forbidden"}`),
+	)
+
+	client := twitter.NewTwitterV2Client(newOAuthClient())
+
+	t.Run("it should delete tweets ignoring the ones already deleted", func(t *testing.T) {
+		require.NoError(t, client.DeleteUpdates("1445823463904798049", "1445823463904798051"))
+		require.Equal(t, 2, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("it should fail when tweet couldn't be deleted", func(t *testing.T) {
+		require.EqualError(
+			t,
+			client.DeleteUpdates("1445823463904798052"),
+			"error sending status update: unexpected response status. Response status code: 403 and body: "+
+				`{"title":"Forbidden"}`,
+		)
+	})
+
+	t.Run("it should fail when tweet id is not valid", func(t *testing.T) {
+		require.Error(t, client.DeleteUpdates("tweet"))
+	})
+}
+
+func TestClientV2_Chunks(t *testing.T) {
+	client := twitter.NewTwitterV2Client(http.DefaultClient, twitter.WithThreadNumbering(true))
+
+	require.Equal(t, []string{"testing"}, client.Chunks("testing"))
+	require.Len(t, client.Chunks(strings.Repeat("word ", 100)), 2)
+}
+
+func newOAuthClient() *http.Client {
+	return oauth1.NewConfig("consumerKey", "consumerSecret").
+		Client(oauth1.NoContext, oauth1.NewToken("accessToken", "accessSecret"))
+}
+
+func tweetsV2Responder(t *testing.T, longTweet string) httpmock.Responder {
+	t.Helper()
+
+	created := func(id string) (*http.Response, error) {
+		return httpmock.NewStringResponse(http.StatusCreated, `{"data":{"id":"`+id+`","text":""}}`), nil
+	}
+
+	return func(req *http.Request) (*http.Response, error) {
+		var tweet tweetV2
+		if err := json.NewDecoder(req.Body).Decode(&tweet); err != nil {
+			return httpmock.NewStringResponse(http.StatusBadRequest, `{"title":"Invalid Request"}`), nil
+		}
+
+		switch {
+		case tweet.Text == "testing" && tweet.Media == nil:
+			return created("1050118621198921728")
+		case tweet.Text == "testing" && len(tweet.Media.MediaIDs) == 1 && tweet.Media.MediaIDs[0] == "54321":
+			return created("1050118621198921729")
+		case tweet.Text == "" && tweet.Media != nil && len(tweet.Media.MediaIDs) == 1 && tweet.Media.MediaIDs[0] == "12345":
+			return created("1050118621198921730")
+		case tweet.Text == longTweet[:280] && tweet.Reply == nil && (tweet.Media == nil || len(tweet.Media.MediaIDs) == 2):
+			return created("1445823463904798049")
+		case tweet.Text == longTweet[280:] && tweet.Media == nil &&
+			tweet.Reply != nil && tweet.Reply.InReplyToTweetID == "1445823463904798049":
+			return created("1445823463904798051")
+		}
+
+		return httpmock.NewStringResponse(http.StatusForbidden, `{"title":"Forbidden"}`), nil
+	}
+}
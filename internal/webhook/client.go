@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+)
+
+const (
+	SignatureHeader = "X-Quintodown-Signature"
+	TopicHeader     = "X-Quintodown-Topic"
+	DeliveryHeader  = "X-Quintodown-Delivery"
+
+	signaturePrefix    = "sha256="
+	maxErrorBodyLength = 512
+)
+
+var errUnexpectedStatus = errors.New("unexpected response status")
+
+type Delivery struct {
+	ID    string
+	Topic string
+	Body  []byte
+}
+
+//easyjson:json
+type Payload struct {
+	ID    string `json:"id"`
+	Topic string `json:"topic"`
+	Event Event  `json:"event"`
+}
+
+// Event is what webhooks receive of the events of the queue. The content and ids of the files and the destinations
+// of the posts are left out, they are only meaningful for the bot and shouldn't leave it.
+type Event struct {
+	Source    string            `json:"source,omitempty"`
+	Edit      bool              `json:"edit,omitempty"`
+	Kind      string            `json:"kind,omitempty"`
+	Text      string            `json:"text,omitempty"`
+	Caption   string            `json:"caption,omitempty"`
+	Photos    int               `json:"photos,omitempty"`
+	FileName  string            `json:"fileName,omitempty"`
+	FileSize  int64             `json:"fileSize,omitempty"`
+	MimeType  string            `json:"mimeType,omitempty"`
+	Animation bool              `json:"animation,omitempty"`
+	Game      *pubsub.GameEvent `json:"game,omitempty"`
+}
+
+type Client struct {
+	hc *http.Client
+}
+
+func NewWebhookClient(hc *http.Client) *Client {
+	return &Client{hc: hc}
+}
+
+// Deliver posts the body of a delivery once. Retries are left to the caller, so a failing webhook doesn't hold back
+// the deliveries to the rest.
+func (c *Client) Deliver(ctx context.Context, s Subscription, d Delivery) error {
+	if err := c.post(ctx, s, d); err != nil {
+		return fmt.Errorf("error delivering webhook %s: %w", s.ID, err)
+	}
+
+	return nil
+}
+
+func (c *Client) post(ctx context.Context, s Subscription, d Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TopicHeader, d.Topic)
+	req.Header.Set(DeliveryHeader, d.ID)
+	req.Header.Set(SignatureHeader, Sign(s.Secret, d.Body))
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	buf := new(strings.Builder)
+	_, _ = io.Copy(buf, io.LimitReader(resp.Body, maxErrorBodyLength))
+
+	return fmt.Errorf(
+		"%w. Response status code: %v and body: %s",
+		errUnexpectedStatus,
+		resp.StatusCode,
+		buf.String(),
+	)
+}
+
+// Sign returns the value of the signature header, the HMAC-SHA256 of the body with the secret of the subscription.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,97 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/quintodown/quintodownbot/internal/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, string(body))
+
+	if len(rc.statuses) > 0 {
+		status := rc.statuses[0]
+		rc.statuses = rc.statuses[1:]
+
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, http.StatusText(status))
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestClient_Deliver(t *testing.T) {
+	delivery := webhook.Delivery{ID: "uuid", Topic: "text", Body: []byte(`{"id":"uuid","topic":"text"}`)}
+
+	t.Run("it should post the signed body", func(t *testing.T) {
+		rc, s := getReceiver(t)
+		client := webhook.NewWebhookClient(http.DefaultClient)
+
+		require.NoError(t, client.Deliver(context.Background(), s, delivery))
+		require.Len(t, rc.requests, 1)
+		require.Equal(t, http.MethodPost, rc.requests[0].Method)
+		require.Equal(t, "application/json", rc.requests[0].Header.Get("Content-Type"))
+		require.Equal(t, "text", rc.requests[0].Header.Get(webhook.TopicHeader))
+		require.Equal(t, "uuid", rc.requests[0].Header.Get(webhook.DeliveryHeader))
+		require.Equal(t, `{"id":"uuid","topic":"text"}`, rc.bodies[0])
+		require.Equal(
+			t,
+			webhook.Sign("secret", []byte(rc.bodies[0])),
+			rc.requests[0].Header.Get(webhook.SignatureHeader),
+		)
+	})
+
+	t.Run("it should fail without retrying when the webhook answers with an error", func(t *testing.T) {
+		rc, s := getReceiver(t)
+		rc.statuses = []int{http.StatusInternalServerError}
+		client := webhook.NewWebhookClient(http.DefaultClient)
+
+		err := client.Deliver(context.Background(), s, delivery)
+
+		require.EqualError(
+			t,
+			err,
+			"error delivering webhook 1: unexpected response status. Response status code: 500 and body: "+
+				"Internal Server Error",
+		)
+		require.Len(t, rc.requests, 1)
+	})
+}
+
+func TestSign(t *testing.T) {
+	require.Equal(
+		t,
+		"sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		webhook.Sign("key", []byte("The quick brown fox jumps over the lazy dog")),
+	)
+}
+
+func getReceiver(t *testing.T) (*receiver, webhook.Subscription) {
+	t.Helper()
+
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	return rc, webhook.Subscription{ID: "1", URL: server.URL + "/hooks", Secret: "secret"}
+}
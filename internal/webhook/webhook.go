@@ -0,0 +1,242 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mailru/easyjson"
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
+)
+
+const (
+	bucket        = "webhooks"
+	configPrefix  = "config"
+	secretLength  = 32
+	topicsDivider = "+"
+)
+
+var (
+	ErrInvalidURL    = errors.New("invalid webhook url")
+	ErrUnknownTopic  = errors.New("unknown topic")
+	ErrConfigured    = errors.New("configured webhooks can't be removed")
+	ErrMissingSecret = errors.New("missing webhook secret")
+)
+
+var topics = []pubsub.TopicName{
+	pubsub.TextTopic,
+	pubsub.PhotoTopic,
+	pubsub.AlbumTopic,
+	pubsub.VideoTopic,
+	pubsub.DocumentTopic,
+	pubsub.GamesTopic,
+	pubsub.DeleteTopic,
+}
+
+//easyjson:json
+type Subscription struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Topics []string `json:"topics,omitempty"`
+}
+
+func (s Subscription) Includes(topic pubsub.TopicName) bool {
+	if len(s.Topics) == 0 {
+		return true
+	}
+
+	for _, t := range s.Topics {
+		if t == TopicName(topic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Repository struct {
+	s          storage.Store
+	configured []Subscription
+}
+
+func NewRepository(s storage.Store, configured []Subscription) *Repository {
+	r := &Repository{s: s}
+
+	for i := range configured {
+		sub := configured[i]
+		sub.ID = configPrefix + strconv.Itoa(i+1)
+		r.configured = append(r.configured, sub)
+	}
+
+	return r
+}
+
+func (r *Repository) Add(rawURL string, names []string) (Subscription, error) {
+	if err := validateURL(rawURL); err != nil {
+		return Subscription{}, err
+	}
+
+	id, err := r.s.NextID(bucket)
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	sub := Subscription{ID: id, URL: rawURL, Secret: secret, Topics: names}
+	sb, _ := easyjson.Marshal(sub)
+
+	if err := r.s.Put(bucket, id, sb); err != nil {
+		return Subscription{}, err
+	}
+
+	return sub, nil
+}
+
+func (r *Repository) List() ([]Subscription, error) {
+	items, err := r.s.List(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]Subscription, 0, len(items))
+
+	for i := range items {
+		var s Subscription
+		if err := easyjson.Unmarshal(items[i].Value, &s); err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, s)
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		a, _ := strconv.Atoi(subscriptions[i].ID)
+		b, _ := strconv.Atoi(subscriptions[j].ID)
+
+		return a < b
+	})
+
+	return append(append([]Subscription{}, r.configured...), subscriptions...), nil
+}
+
+func (r *Repository) Remove(id string) error {
+	if strings.HasPrefix(id, configPrefix) {
+		return ErrConfigured
+	}
+
+	if _, err := r.s.Get(bucket, id); err != nil {
+		return err
+	}
+
+	return r.s.Delete(bucket, id)
+}
+
+// ParseSubscription reads a subscription written as "<url> [topic+topic]", the way it's configured and added from
+// the bot commands. Without topics the subscription receives every topic.
+func ParseSubscription(s, secret string) (Subscription, error) {
+	rawURL, rawTopics, _ := strings.Cut(strings.TrimSpace(s), " ")
+
+	if err := validateURL(rawURL); err != nil {
+		return Subscription{}, err
+	}
+
+	t, err := ParseTopics(rawTopics)
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	return Subscription{URL: rawURL, Secret: secret, Topics: t}, nil
+}
+
+// ParseConfigured reads the configured webhooks. Each one is signed with its entry of secrets, in the same order, or
+// with the shared secret when it has none, and a webhook without any secret is an error.
+func ParseConfigured(webhooks, secrets []string, shared string) ([]Subscription, error) {
+	subscriptions := make([]Subscription, 0, len(webhooks))
+
+	for i, w := range webhooks {
+		secret := shared
+		if i < len(secrets) && strings.TrimSpace(secrets[i]) != "" {
+			secret = strings.TrimSpace(secrets[i])
+		}
+
+		s, err := ParseSubscription(w, secret)
+		if err != nil {
+			return nil, err
+		}
+
+		if secret == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMissingSecret, s.URL)
+		}
+
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, nil
+}
+
+func ParseTopics(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	var names []string
+
+	for _, name := range strings.Split(s, topicsDivider) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := Topic(name); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTopic, name)
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func Topics() []pubsub.TopicName {
+	return append([]pubsub.TopicName{}, topics...)
+}
+
+func Topic(name string) (pubsub.TopicName, bool) {
+	for _, t := range topics {
+		if TopicName(t) == name {
+			return t, true
+		}
+	}
+
+	return 0, false
+}
+
+func TopicName(t pubsub.TopicName) string {
+	return strings.ToLower(strings.TrimSuffix(t.String(), "Topic"))
+}
+
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidURL, rawURL)
+	}
+
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, secretLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,123 @@
+package webhook_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/quintodown/quintodownbot/internal/pubsub"
+	"github.com/quintodown/quintodownbot/internal/storage"
+	"github.com/quintodown/quintodownbot/internal/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository(t *testing.T) {
+	s, err := storage.NewBoltStore(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	defer func() { _ = s.Close() }()
+
+	r := webhook.NewRepository(s, []webhook.Subscription{{URL: "https://quintodown.com/hooks", Secret: "secret"}})
+
+	t.Run("it should list the configured webhooks", func(t *testing.T) {
+		subscriptions, err := r.List()
+
+		require.NoError(t, err)
+		require.Equal(t, []webhook.Subscription{
+			{ID: "config1", URL: "https://quintodown.com/hooks", Secret: "secret"},
+		}, subscriptions)
+	})
+
+	t.Run("it should fail adding webhook with invalid url", func(t *testing.T) {
+		_, err := r.Add("ftp://quintodown.com", nil)
+
+		require.ErrorIs(t, err, webhook.ErrInvalidURL)
+	})
+
+	t.Run("it should add webhooks with a random secret", func(t *testing.T) {
+		first, err := r.Add("https://hooks.slack.com/services/T0/B0/X", []string{"games"})
+		require.NoError(t, err)
+
+		second, err := r.Add("https://quintodown.com/other", nil)
+		require.NoError(t, err)
+
+		require.Equal(t, "1", first.ID)
+		require.Equal(t, "2", second.ID)
+		require.Len(t, first.Secret, 64)
+		require.NotEqual(t, first.Secret, second.Secret)
+
+		subscriptions, err := r.List()
+
+		require.NoError(t, err)
+		require.Equal(t, []webhook.Subscription{
+			{ID: "config1", URL: "https://quintodown.com/hooks", Secret: "secret"},
+			first,
+			second,
+		}, subscriptions)
+	})
+
+	t.Run("it should remove added webhooks", func(t *testing.T) {
+		require.NoError(t, r.Remove("1"))
+		require.ErrorIs(t, r.Remove("1"), storage.ErrNotFound)
+		require.ErrorIs(t, r.Remove("config1"), webhook.ErrConfigured)
+
+		subscriptions, err := r.List()
+
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 2)
+		require.Equal(t, "2", subscriptions[1].ID)
+	})
+}
+
+func TestParseSubscription(t *testing.T) {
+	t.Run("it should parse url and topics", func(t *testing.T) {
+		s, err := webhook.ParseSubscription(" https://quintodown.com/hooks Text+games ", "secret")
+
+		require.NoError(t, err)
+		require.Equal(t, webhook.Subscription{
+			URL:    "https://quintodown.com/hooks",
+			Secret: "secret",
+			Topics: []string{"text", "games"},
+		}, s)
+		require.True(t, s.Includes(pubsub.GamesTopic))
+		require.False(t, s.Includes(pubsub.PhotoTopic))
+	})
+
+	t.Run("it should include every topic when there is no filter", func(t *testing.T) {
+		s, err := webhook.ParseSubscription("https://quintodown.com/hooks", "")
+
+		require.NoError(t, err)
+		require.True(t, s.Includes(pubsub.DeleteTopic))
+	})
+
+	t.Run("it should fail with unknown topics", func(t *testing.T) {
+		_, err := webhook.ParseSubscription("https://quintodown.com/hooks text+command", "")
+
+		require.ErrorIs(t, err, webhook.ErrUnknownTopic)
+	})
+
+	t.Run("it should fail with invalid urls", func(t *testing.T) {
+		_, err := webhook.ParseSubscription("quintodown.com text", "")
+
+		require.ErrorIs(t, err, webhook.ErrInvalidURL)
+	})
+}
+
+func TestParseConfigured(t *testing.T) {
+	webhooks := []string{"https://quintodown.com/hooks", "https://hooks.slack.com/services/T0/B0/X games"}
+
+	t.Run("it should sign each webhook with its own secret or the shared one", func(t *testing.T) {
+		subscriptions, err := webhook.ParseConfigured(webhooks, []string{"", "slack"}, "shared")
+
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 2)
+		require.Equal(t, "shared", subscriptions[0].Secret)
+		require.Equal(t, "slack", subscriptions[1].Secret)
+	})
+
+	t.Run("it should fail when a webhook has no secret", func(t *testing.T) {
+		_, err := webhook.ParseConfigured(webhooks, []string{"quintodown"}, "")
+
+		require.ErrorIs(t, err, webhook.ErrMissingSecret)
+		require.ErrorContains(t, err, "https://hooks.slack.com/services/T0/B0/X")
+	})
+}